@@ -1,8 +1,10 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
 	"strings"
 	"time"
 
@@ -12,6 +14,14 @@ import (
 	"github.com/NStegura/metrics/internal/app/agent"
 	"github.com/NStegura/metrics/internal/clients/base"
 	"github.com/NStegura/metrics/internal/clients/metric"
+	"github.com/NStegura/metrics/internal/utils/certs"
+)
+
+const (
+	retryBudgetTokens = 10
+	retryBudgetRatio  = 0.1
+	breakerThreshold  = 5
+	breakerCooldown   = 30 * time.Second
 )
 
 var (
@@ -41,12 +51,36 @@ func startAgent() error {
 	if err != nil {
 		return fmt.Errorf("failed to configure logger: %w", err)
 	}
+	if cfg.DryRun {
+		if err = agent.New(cfg, nil, logger).Start(); err != nil {
+			return fmt.Errorf("failed to dry run agent: %w", err)
+		}
+		return nil
+	}
+	var tlsConfig *tls.Config
+	if cfg.TLSEnabled() {
+		// сертификат сервера проверяется по имени хоста из адреса gRPC сервера.
+		serverName, _, splitErr := net.SplitHostPort(cfg.GRPCAddr)
+		if splitErr != nil {
+			serverName = cfg.GRPCAddr
+		}
+		tlsConfig, err = certs.ClientConfig(serverName, cfg.TLSCertPath, cfg.TLSKeyPath, cfg.TLSCAPath)
+		if err != nil {
+			return fmt.Errorf("failed to init tls: %w", err)
+		}
+	}
 	metricsCli, err := metric.NewGRPCClient(cfg.GRPCAddr,
 		base.WithLogger(logger),
+		base.WithTLSConfig(tlsConfig),
+		base.WithSigningKey(cfg.SigningKeyPath, cfg.SigningKeyID),
+		base.WithMaxBatchBytes(cfg.MaxBatchBytes),
+		base.WithMaxBatchMetrics(cfg.MaxBatchMetrics),
 		base.WithRetryPolicy(
 			[]time.Duration{1 * time.Second, 2 * time.Second, 5 * time.Second},
 			base.IsRetryableGRPCRequest,
 		),
+		base.WithRetryBudget(retryBudgetTokens, retryBudgetRatio),
+		base.WithCircuitBreaker(breakerThreshold, breakerCooldown),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to init metric client: %w", err)
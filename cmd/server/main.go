@@ -68,6 +68,9 @@ func runRest() error {
 		cfg.FileStoragePath,
 		cfg.Restore,
 		logger,
+		repo.WithBackupRetain(cfg.BackupRetain),
+		repo.WithAllowEmptyRestore(cfg.RestoreAllowEmpty),
+		repo.WithWAL(cfg.WAL),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create repo: %w", err)
@@ -14,18 +14,33 @@ const (
 	defaultRateLimit      int      = 3
 	defaultReportInterval Duration = 10
 	defaultPollInterval   Duration = 2
+
+	redactedValue = "***"
 )
 
 // AgentConfig хранит параметры для старта приложения сбора метрик.
+// Поля с секретами помечаются тегом secret:"true", Redacted скрывает их все.
 type AgentConfig struct {
 	PublicCryptoKeyPath string   `json:"crypto_key"`
 	HTTPAddr            string   `json:"address"`
 	GRPCAddr            string   `json:"grpc_addr"`
-	BodyHashKey         string   `json:"body_hash_key"`
+	StatusAddr          string   `json:"status_addr"`
+	TLSCertPath         string   `json:"tls_cert"`
+	TLSKeyPath          string   `json:"tls_key"`
+	TLSCAPath           string   `json:"tls_ca"`
+	BodyHashKey         string   `json:"body_hash_key" secret:"true"`
+	SigningKeyPath      string   `json:"signing_key"`
+	SigningKeyID        string   `json:"signing_key_id"`
 	LogLevel            string   `json:"log_level"`
 	RateLimit           int      `json:"rate_limit"`
 	ReportInterval      Duration `json:"report_interval"`
 	PollInterval        Duration `json:"poll_interval"`
+	MaxBatchBytes       int      `json:"max_batch_bytes"`
+	MaxBatchMetrics     int      `json:"max_batch_metrics"`
+	MaxBytesPerSecond   int      `json:"max_bytes_per_second"`
+	DisableHostMetrics  bool     `json:"disable_host_metrics"`
+	Once                bool     `json:"once"`
+	DryRun              bool     `json:"dry_run"`
 }
 
 func NewAgentConfig() *AgentConfig {
@@ -38,6 +53,18 @@ func NewAgentConfig() *AgentConfig {
 	}
 }
 
+// TLSEnabled проверяет, что агенту передан CA или клиентский сертификат.
+func (c *AgentConfig) TLSEnabled() bool {
+	return c.TLSCAPath != "" || c.TLSCertPath != ""
+}
+
+// Redacted возвращает копию конфига со скрытыми секретами.
+func (c *AgentConfig) Redacted() AgentConfig {
+	redacted := *c
+	redactSecrets(&redacted)
+	return redacted
+}
+
 // ParseFlags определяет энвы и заполняет конфиг Config.
 func (c *AgentConfig) ParseFlags() (err error) {
 	var (
@@ -47,7 +74,7 @@ func (c *AgentConfig) ParseFlags() (err error) {
 		configFile       string
 	)
 	flag.StringVar(&configFile, "c", "", "path to config file")
-	flag.Parse()
+	configFile = lookupConfigFlag(os.Args[1:])
 	if envConfigFile, ok := os.LookupEnv("CONFIG"); ok {
 		configFile = envConfigFile
 	}
@@ -74,6 +101,28 @@ func (c *AgentConfig) ParseFlags() (err error) {
 	flag.StringVar(&c.BodyHashKey, "k", "", "add key to sign requests")
 	flag.StringVar(&c.PublicCryptoKeyPath, "crypto-key", "", "add key to send requests")
 	flag.IntVar(&c.RateLimit, "l", defaultRateLimit, "rate limit")
+	flag.StringVar(&c.SigningKeyPath, "signing-key", c.SigningKeyPath, "path to agent ed25519 or ecdsa key to sign requests")
+	flag.StringVar(&c.SigningKeyID, "signing-key-id", c.SigningKeyID, "signing key id, public key fingerprint if empty")
+	flag.StringVar(&c.TLSCertPath, "tls-cert", c.TLSCertPath, "path to client tls certificate for mTLS")
+	flag.StringVar(&c.TLSKeyPath, "tls-key", c.TLSKeyPath, "path to client tls key for mTLS")
+	flag.StringVar(&c.TLSCAPath, "tls-ca", c.TLSCAPath, "path to CA to verify server certificate")
+	flag.IntVar(&c.MaxBatchBytes, "max-batch-bytes", c.MaxBatchBytes, "max size of one request, 0 - unlimited")
+	flag.IntVar(&c.MaxBatchMetrics, "max-batch-metrics", c.MaxBatchMetrics, "max metrics in one request, 0 - unlimited")
+	flag.IntVar(
+		&c.MaxBytesPerSecond,
+		"max-bytes-per-second",
+		c.MaxBytesPerSecond,
+		"bandwidth cap, low priority metrics are dropped first, 0 - unlimited",
+	)
+	flag.StringVar(&c.StatusAddr, "status-addr", c.StatusAddr, "address of local status server, disabled if empty")
+	flag.BoolVar(
+		&c.DisableHostMetrics,
+		"disable-host-metrics",
+		c.DisableHostMetrics,
+		"collect only runtime metrics of the agent process",
+	)
+	flag.BoolVar(&c.Once, "once", c.Once, "collect and send metrics once, then exit")
+	flag.BoolVar(&c.DryRun, "dry-run", c.DryRun, "print metrics batches to stdout without sending")
 	flag.Parse()
 
 	if envRunHTTPAddr, ok := os.LookupEnv("ADDRESS"); ok {
@@ -106,6 +155,42 @@ func (c *AgentConfig) ParseFlags() (err error) {
 	if cryptoKey, ok := os.LookupEnv("CRYPTO_KEY"); ok {
 		c.PublicCryptoKeyPath = cryptoKey
 	}
+	if statusAddr, ok := os.LookupEnv("STATUS_ADDRESS"); ok {
+		c.StatusAddr = statusAddr
+	}
+	if signingKey, ok := os.LookupEnv("SIGNING_KEY"); ok {
+		c.SigningKeyPath = signingKey
+	}
+	if signingKeyID, ok := os.LookupEnv("SIGNING_KEY_ID"); ok {
+		c.SigningKeyID = signingKeyID
+	}
+	if tlsCert, ok := os.LookupEnv("TLS_CERT"); ok {
+		c.TLSCertPath = tlsCert
+	}
+	if tlsKey, ok := os.LookupEnv("TLS_KEY"); ok {
+		c.TLSKeyPath = tlsKey
+	}
+	if tlsCA, ok := os.LookupEnv("TLS_CA"); ok {
+		c.TLSCAPath = tlsCA
+	}
+	if maxBatchBytes, ok := os.LookupEnv("MAX_BATCH_BYTES"); ok {
+		c.MaxBatchBytes, err = strconv.Atoi(maxBatchBytes)
+		if err != nil {
+			return
+		}
+	}
+	if maxBatchMetrics, ok := os.LookupEnv("MAX_BATCH_METRICS"); ok {
+		c.MaxBatchMetrics, err = strconv.Atoi(maxBatchMetrics)
+		if err != nil {
+			return
+		}
+	}
+	if maxBytesPerSecond, ok := os.LookupEnv("MAX_BYTES_PER_SECOND"); ok {
+		c.MaxBytesPerSecond, err = strconv.Atoi(maxBytesPerSecond)
+		if err != nil {
+			return
+		}
+	}
 
 	c.ReportInterval = Duration(time.Second * time.Duration(reportIntervalIn))
 	c.PollInterval = Duration(time.Second * time.Duration(pollIntervalIn))
@@ -115,7 +200,12 @@ func (c *AgentConfig) ParseFlags() (err error) {
 		c.RateLimit = defaultRateLimit
 	}
 
-	if err = logToStdOUT(c); err != nil {
+	if c.DryRun {
+		// в stdout идут батчи метрик, дамп конфигурации смешался бы с ними. Логи logrus пишутся в stderr.
+		return
+	}
+	redacted := c.Redacted()
+	if err = logToStdOUT(&redacted); err != nil {
 		return err
 	}
 	return
@@ -22,3 +22,11 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 	*d = Duration(duration)
 	return nil
 }
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(time.Duration(d).String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal: %w", err)
+	}
+	return b, nil
+}
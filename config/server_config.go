@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
@@ -9,21 +10,45 @@ import (
 )
 
 const (
-	defaultStoreInerval Duration = 300
+	defaultStoreInerval    Duration = 300
+	defaultReplayCacheSize int      = 100000
+	defaultBackupRetain    int      = 3
 )
 
 // SrvConfig хранит параметры для старта приложения хранения метрик.
 type SrvConfig struct {
-	PrivateCryptoKeyPath string   `json:"crypto_key"`
-	BindAddr             string   `json:"address"`
-	GrpcAddr             string   `json:"grpc_address"`
-	LogLevel             string   `json:"log_level"`
-	FileStoragePath      string   `json:"store_file"`
-	DatabaseDSN          string   `json:"database_dsn"`
-	BodyHashKey          string   `json:"request_key"`
-	TrustedSubnet        string   `json:"trusted_subnet"`
-	StoreInterval        Duration `json:"store_interval"`
-	Restore              bool     `json:"restore"`
+	PrivateCryptoKeyPath string `json:"crypto_key"`
+	CryptoKeysDir        string `json:"crypto_keys_dir"`
+	// CryptoKeyPassphrase и AdminToken задаются только через окружение, чтобы секреты не попадали в файл конфига.
+	CryptoKeyPassphrase     string   `json:"-"`
+	AdminToken              string   `json:"-"`
+	AdminTokenFile          string   `json:"admin_token_file"`
+	CryptoKeyPassphraseFile string   `json:"crypto_key_passphrase_file"`
+	BindAddr                string   `json:"address"`
+	GrpcAddr                string   `json:"grpc_address"`
+	LogLevel                string   `json:"log_level"`
+	FileStoragePath         string   `json:"store_file"`
+	DatabaseDSN             string   `json:"database_dsn"`
+	BodyHashKey             string   `json:"request_key"`
+	TrustedSubnet           string   `json:"trusted_subnet"`
+	TLSCertPath             string   `json:"tls_cert"`
+	TLSKeyPath              string   `json:"tls_key"`
+	TLSCAPath               string   `json:"tls_ca"`
+	SigningKeysDir          string   `json:"signing_keys_dir"`
+	StoreInterval           Duration `json:"store_interval"`
+	ReplayWindow            Duration `json:"replay_window"`
+	ReplayCacheSize         int      `json:"replay_cache_size"`
+	BackupRetain            int      `json:"backup_retain"`
+	Restore                 bool     `json:"restore"`
+	RestoreAllowEmpty       bool     `json:"restore_allow_empty"`
+	WAL                     bool     `json:"wal"`
+	TLSClientAuth           bool     `json:"tls_client_auth"`
+	PrometheusPublic        bool     `json:"prometheus_public"`
+}
+
+// TLSEnabled проверяет, что серверу переданы сертификат и ключ.
+func (c *SrvConfig) TLSEnabled() bool {
+	return c.TLSCertPath != "" && c.TLSKeyPath != ""
 }
 
 func NewSrvConfig() *SrvConfig {
@@ -33,19 +58,57 @@ func NewSrvConfig() *SrvConfig {
 		LogLevel:        "debug",
 		FileStoragePath: "/tmp/metrics-db.json",
 		StoreInterval:   defaultStoreInerval,
+		ReplayCacheSize: defaultReplayCacheSize,
+		BackupRetain:    defaultBackupRetain,
 		Restore:         false,
 	}
 }
 
+// DecryptionEnabled проверяет, что серверу передан ключ или каталог ключей для расшифровки.
+func (c *SrvConfig) DecryptionEnabled() bool {
+	return c.PrivateCryptoKeyPath != "" || c.CryptoKeysDir != ""
+}
+
+// CryptoPassphrase возвращает пароль зашифрованных ключей расшифровки:
+// из файла CryptoKeyPassphraseFile, если он задан, иначе из CryptoKeyPassphrase.
+func (c *SrvConfig) CryptoPassphrase() ([]byte, error) {
+	if c.CryptoKeyPassphraseFile == "" {
+		return []byte(c.CryptoKeyPassphrase), nil
+	}
+	data, err := os.ReadFile(c.CryptoKeyPassphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	return bytes.TrimRight(data, "\r\n"), nil
+}
+
+// AdminCredential возвращает токен админского API: из файла AdminTokenFile,
+// если он задан, иначе из AdminToken. Пустой токен - админский API выключен.
+func (c *SrvConfig) AdminCredential() (string, error) {
+	if c.AdminTokenFile == "" {
+		return c.AdminToken, nil
+	}
+	data, err := os.ReadFile(c.AdminTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read admin token file: %w", err)
+	}
+	return string(bytes.TrimRight(data, "\r\n")), nil
+}
+
+// ReplayProtection проверяет, что включена защита подписанных запросов от повтора.
+func (c *SrvConfig) ReplayProtection() bool {
+	return c.ReplayWindow > 0
+}
+
 // ParseFlags определяет энвы и заполняет конфиг Config.
 func (c *SrvConfig) ParseFlags() (err error) {
 	var (
 		storeInterval int
+		replayWindow  int
 		configFile    string
 	)
 	flag.StringVar(&configFile, "c", "", "path to config file")
-	flag.Parse()
-
+	configFile = lookupConfigFlag(os.Args[1:])
 	if envConfigFile, ok := os.LookupEnv("CONFIG"); ok {
 		configFile = envConfigFile
 	}
@@ -65,9 +128,58 @@ func (c *SrvConfig) ParseFlags() (err error) {
 	flag.StringVar(&c.FileStoragePath, "f", "/tmp/metrics-db.json", "storage path")
 	flag.StringVar(&c.DatabaseDSN, "d", "", "database dsn")
 	flag.BoolVar(&c.Restore, "r", true, "load metrics")
+	flag.IntVar(&c.BackupRetain, "backup-retain", c.BackupRetain, "number of last backup snapshots to keep")
+	flag.BoolVar(
+		&c.RestoreAllowEmpty,
+		"restore-allow-empty",
+		c.RestoreAllowEmpty,
+		"start with empty storage if all backup snapshots are corrupt instead of failing",
+	)
+	flag.BoolVar(&c.WAL, "wal", c.WAL, "write updates to a write-ahead log next to the backup file")
 	flag.StringVar(&c.BodyHashKey, "k", "", "add key to sign requests")
 	flag.StringVar(&c.PrivateCryptoKeyPath, "crypto-key", "", "add crypto key to read requests")
+	flag.StringVar(
+		&c.CryptoKeysDir,
+		"crypto-keys-dir",
+		c.CryptoKeysDir,
+		"dir with additional private crypto keys, reread on changes to rotate keys",
+	)
+	flag.StringVar(
+		&c.CryptoKeyPassphraseFile,
+		"crypto-key-passphrase-file",
+		c.CryptoKeyPassphraseFile,
+		"file with passphrase for encrypted crypto keys, CRYPTO_KEY_PASSPHRASE env is used if empty",
+	)
 	flag.StringVar(&c.TrustedSubnet, "t", "", "trusted ip addr")
+	flag.StringVar(&c.TLSCertPath, "tls-cert", c.TLSCertPath, "path to tls certificate")
+	flag.StringVar(&c.TLSKeyPath, "tls-key", c.TLSKeyPath, "path to tls key")
+	flag.StringVar(&c.TLSCAPath, "tls-ca", c.TLSCAPath, "path to CA to verify client certificates")
+	flag.BoolVar(&c.TLSClientAuth, "tls-client-auth", c.TLSClientAuth, "require client certificates (mTLS)")
+	flag.StringVar(
+		&c.SigningKeysDir,
+		"signing-keys-dir",
+		c.SigningKeysDir,
+		"dir with trusted agent public keys, updates must be signed by one of them if set",
+	)
+	flag.IntVar(
+		&replayWindow,
+		"replay-window",
+		int(time.Duration(c.ReplayWindow)/time.Second),
+		"allowed clock skew in seconds for signed requests, 0 - replay protection disabled",
+	)
+	flag.IntVar(&c.ReplayCacheSize, "replay-cache-size", c.ReplayCacheSize, "max nonces remembered for replay protection")
+	flag.BoolVar(
+		&c.PrometheusPublic,
+		"prometheus-public",
+		c.PrometheusPublic,
+		"allow /metrics scraping from outside of trusted subnet",
+	)
+	flag.StringVar(
+		&c.AdminTokenFile,
+		"admin-token-file",
+		c.AdminTokenFile,
+		"file with admin api token, ADMIN_TOKEN env is used if empty, admin api is disabled without token",
+	)
 	flag.Parse()
 
 	if envRunAddr, ok := os.LookupEnv("ADDRESS"); ok {
@@ -99,6 +211,15 @@ func (c *SrvConfig) ParseFlags() (err error) {
 	if cryptoKey, ok := os.LookupEnv("CRYPTO_KEY"); ok {
 		c.PrivateCryptoKeyPath = cryptoKey
 	}
+	if cryptoKeysDir, ok := os.LookupEnv("CRYPTO_KEYS_DIR"); ok {
+		c.CryptoKeysDir = cryptoKeysDir
+	}
+	if passphrase, ok := os.LookupEnv("CRYPTO_KEY_PASSPHRASE"); ok {
+		c.CryptoKeyPassphrase = passphrase
+	}
+	if passphraseFile, ok := os.LookupEnv("CRYPTO_KEY_PASSPHRASE_FILE"); ok {
+		c.CryptoKeyPassphraseFile = passphraseFile
+	}
 
 	if restoreIn, ok := os.LookupEnv("RESTORE"); ok {
 		switch restoreIn {
@@ -111,11 +232,65 @@ func (c *SrvConfig) ParseFlags() (err error) {
 		}
 	}
 
+	if backupRetain, ok := os.LookupEnv("BACKUP_RETAIN"); ok {
+		c.BackupRetain, err = strconv.Atoi(backupRetain)
+		if err != nil {
+			return
+		}
+	}
+	if allowEmpty, ok := os.LookupEnv("RESTORE_ALLOW_EMPTY"); ok {
+		c.RestoreAllowEmpty = allowEmpty == "true"
+	}
+	if wal, ok := os.LookupEnv("WAL"); ok {
+		c.WAL = wal == "true"
+	}
+
 	if trustedSubnet, ok := os.LookupEnv("TRUSTED_SUBNET"); ok {
 		c.TrustedSubnet = trustedSubnet
 	}
 
+	if tlsCert, ok := os.LookupEnv("TLS_CERT"); ok {
+		c.TLSCertPath = tlsCert
+	}
+	if tlsKey, ok := os.LookupEnv("TLS_KEY"); ok {
+		c.TLSKeyPath = tlsKey
+	}
+	if tlsCA, ok := os.LookupEnv("TLS_CA"); ok {
+		c.TLSCAPath = tlsCA
+	}
+	if tlsClientAuth, ok := os.LookupEnv("TLS_CLIENT_AUTH"); ok {
+		c.TLSClientAuth = tlsClientAuth == "true"
+	}
+
+	if signingKeysDir, ok := os.LookupEnv("SIGNING_KEYS_DIR"); ok {
+		c.SigningKeysDir = signingKeysDir
+	}
+	if window, ok := os.LookupEnv("REPLAY_WINDOW"); ok {
+		replayWindow, err = strconv.Atoi(window)
+		if err != nil {
+			return
+		}
+	}
+	if cacheSize, ok := os.LookupEnv("REPLAY_CACHE_SIZE"); ok {
+		c.ReplayCacheSize, err = strconv.Atoi(cacheSize)
+		if err != nil {
+			return
+		}
+	}
+
+	if prometheusPublic, ok := os.LookupEnv("PROMETHEUS_PUBLIC"); ok {
+		c.PrometheusPublic = prometheusPublic == "true"
+	}
+
+	if adminToken, ok := os.LookupEnv("ADMIN_TOKEN"); ok {
+		c.AdminToken = adminToken
+	}
+	if adminTokenFile, ok := os.LookupEnv("ADMIN_TOKEN_FILE"); ok {
+		c.AdminTokenFile = adminTokenFile
+	}
+
 	c.StoreInterval = Duration(time.Second * time.Duration(storeInterval))
+	c.ReplayWindow = Duration(time.Second * time.Duration(replayWindow))
 	if err = logToStdOUT(c); err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -19,6 +20,9 @@ func TestConfig__ok(t *testing.T) {
 	assert.Equal(t, cfg.DatabaseDSN, "")
 	assert.Equal(t, cfg.BodyHashKey, "")
 	assert.Equal(t, cfg.StoreInterval, defaultStoreInerval)
+	assert.Equal(t, cfg.BackupRetain, defaultBackupRetain)
+	assert.False(t, cfg.RestoreAllowEmpty)
+	assert.False(t, cfg.WAL)
 }
 
 func TestConfig__ParseEnvs(t *testing.T) {
@@ -36,6 +40,12 @@ func TestConfig__ParseEnvs(t *testing.T) {
 	require.NoError(t, err)
 	err = os.Setenv("KEY", "somekey")
 	require.NoError(t, err)
+	err = os.Setenv("BACKUP_RETAIN", "5")
+	require.NoError(t, err)
+	err = os.Setenv("RESTORE_ALLOW_EMPTY", "true")
+	require.NoError(t, err)
+	err = os.Setenv("WAL", "true")
+	require.NoError(t, err)
 
 	cfg := NewSrvConfig()
 	err = cfg.ParseFlags()
@@ -48,4 +58,21 @@ func TestConfig__ParseEnvs(t *testing.T) {
 	assert.Equal(t, cfg.DatabaseDSN, "postgresql://localhost/mydb?user=other&password=secret")
 	assert.Equal(t, cfg.BodyHashKey, "somekey")
 	assert.Equal(t, time.Duration(cfg.StoreInterval), time.Second)
+	assert.Equal(t, cfg.BackupRetain, 5)
+	assert.True(t, cfg.RestoreAllowEmpty)
+	assert.True(t, cfg.WAL)
+}
+
+func TestConfig__CryptoPassphrase(t *testing.T) {
+	cfg := NewSrvConfig()
+	cfg.CryptoKeyPassphrase = "from-env"
+	passphrase, err := cfg.CryptoPassphrase()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("from-env"), passphrase)
+
+	cfg.CryptoKeyPassphraseFile = filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(cfg.CryptoKeyPassphraseFile, []byte("from-file\n"), 0600))
+	passphrase, err = cfg.CryptoPassphrase()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("from-file"), passphrase)
 }
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
@@ -44,3 +46,32 @@ func logToStdOUT[C Config](cfg *C) error {
 	logrus.Info("-----CONFIGURATION-----")
 	return nil
 }
+
+// redactSecrets заменяет на redactedValue непустые строковые поля с тегом secret:"true".
+func redactSecrets[C Config](cfg *C) {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		field := v.Field(i)
+		if v.Type().Field(i).Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "" {
+			field.SetString(redactedValue)
+		}
+	}
+}
+
+// lookupConfigFlag ищет путь к файлу конфигурации (-c) среди аргументов
+// до того, как определены остальные флаги.
+func lookupConfigFlag(args []string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "c" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupConfigFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "no flag", args: []string{"--once"}, want: ""},
+		{name: "separate value", args: []string{"--once", "-c", "cfg.json"}, want: "cfg.json"},
+		{name: "inline value", args: []string{"--c=cfg.json", "--dry-run"}, want: "cfg.json"},
+		{name: "missing value", args: []string{"-c"}, want: ""},
+	}
+	for _, v := range tests {
+		assert.Equal(t, v.want, lookupConfigFlag(v.args), v.name)
+	}
+}
+
+func TestAgentConfig_Redacted(t *testing.T) {
+	cfg := &AgentConfig{SigningKeyID: "agent-1"}
+	v := reflect.ValueOf(cfg).Elem()
+	var secrets []int
+	for i := range v.NumField() {
+		if v.Type().Field(i).Tag.Get("secret") == "true" {
+			v.Field(i).SetString("secret")
+			secrets = append(secrets, i)
+		}
+	}
+	require.NotEmpty(t, secrets)
+
+	redacted := reflect.ValueOf(cfg.Redacted())
+	for _, i := range secrets {
+		assert.Equal(t, redactedValue, redacted.Field(i).String(), v.Type().Field(i).Name)
+		assert.Equal(t, "secret", v.Field(i).String(), "original config is not changed")
+	}
+	assert.Equal(t, "agent-1", cfg.Redacted().SigningKeyID)
+	assert.Empty(t, (&AgentConfig{}).Redacted().BodyHashKey, "empty secret stays empty")
+}
@@ -2,7 +2,12 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math/rand"
+	"os"
 	"os/signal"
 	"runtime"
 	"sync"
@@ -63,39 +68,149 @@ const (
 	countGaugePsMetrics int = 3
 )
 
+// collector источник метрик агента.
+type collector struct {
+	name    string
+	collect func(counter int64) models.Metrics
+}
+
 type Agent struct {
 	cfg        *config.AgentConfig
 	metricsCli MetricCli
+	collectors []collector
+	state      *state
+	bandwidth  *bandwidthLimiter
+
+	pollCh chan models.Metrics
+	jobsCh chan models.Metrics
 
 	logger *logrus.Logger
 }
 
 func New(config *config.AgentConfig, metricsCli MetricCli, logger *logrus.Logger) *Agent {
-	return &Agent{
+	ag := &Agent{
 		cfg:        config,
 		metricsCli: metricsCli,
+		state:      newState(),
 		logger:     logger,
 	}
+	ag.collectors = []collector{
+		{name: "runtime", collect: ag.getMetricsFromStats},
+	}
+	if !config.DisableHostMetrics {
+		ag.collectors = append(ag.collectors, collector{
+			name:    "ps",
+			collect: func(int64) models.Metrics { return ag.getPSMetrics() },
+		})
+	}
+	if config.MaxBytesPerSecond > 0 {
+		ag.bandwidth = newBandwidthLimiter(config.MaxBytesPerSecond)
+	}
+	return ag
 }
 
-// Start начинает сбор и отправку метрик.
+// Start начинает сбор и отправку метрик до получения сигнала остановки.
 func (ag *Agent) Start() error {
 	ctx, cancelCtx := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer cancelCtx()
 
+	if ag.cfg.DryRun {
+		return ag.DryRun(os.Stdout)
+	}
+	if ag.cfg.Once {
+		return ag.RunOnce(ctx)
+	}
+	return ag.Run(ctx)
+}
+
+// Run собирает и отправляет метрики, пока не отменен ctx.
+func (ag *Agent) Run(ctx context.Context) error {
 	var wg sync.WaitGroup
 
-	metricsCh := ag.collectMetrics(ctx, &wg)
-	metricsJobCh := ag.addMetricsToJobs(ctx, &wg, metricsCh)
+	ag.pollCh = ag.collectMetrics(ctx, &wg)
+	ag.jobsCh = ag.addMetricsToJobs(ctx, &wg, ag.pollCh)
 
 	for w := 1; w <= ag.cfg.RateLimit; w++ {
-		ag.sendMetrics(ctx, w, &wg, metricsJobCh)
+		ag.sendMetrics(ctx, w, &wg, ag.jobsCh)
+	}
+
+	if ag.cfg.StatusAddr != "" {
+		ag.startStatusServer(ctx, &wg)
 	}
 
 	wg.Wait()
 	return nil
 }
 
+// RunOnce синхронно собирает один набор метрик со всех источников и отправляет его.
+func (ag *Agent) RunOnce(ctx context.Context) error {
+	var errs []error
+	for _, metrics := range ag.collect(1) {
+		if err := ag.send(ctx, metrics); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to send metrics: %w", err)
+	}
+	return nil
+}
+
+// DryRun собирает один набор метрик и пишет отправляемые батчи в out в формате JSON.
+func (ag *Agent) DryRun(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	for _, metrics := range ag.collect(1) {
+		if err := enc.Encode(metric.CastToMetrics(metrics)); err != nil {
+			return fmt.Errorf("failed to encode metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+// Collectors возвращает названия включенных источников метрик.
+func (ag *Agent) Collectors() []string {
+	names := make([]string, 0, len(ag.collectors))
+	for _, c := range ag.collectors {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// collect собирает метрики со всех источников.
+func (ag *Agent) collect(counter int64) []models.Metrics {
+	metrics := make([]models.Metrics, 0, len(ag.collectors))
+	for _, c := range ag.collectors {
+		m := c.collect(counter)
+		ag.state.setCollected(c.name, m)
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// send отправляет батч метрик и запоминает результат отправки.
+func (ag *Agent) send(ctx context.Context, metrics models.Metrics) error {
+	batch := metric.CastToMetrics(metrics)
+	if ag.bandwidth != nil {
+		var dropped int
+		batch, dropped = ag.bandwidth.fit(batch)
+		if dropped > 0 {
+			ag.logger.Warningf("bandwidth limit exceeded, dropped %v metrics", dropped)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+	}
+	err := ag.metricsCli.UpdateMetrics(ctx, batch)
+	ag.state.setSendResult(err)
+	return err //nolint:wrapcheck // proxy
+}
+
+// queueDepth возвращает количество батчей, ожидающих отправки.
+func (ag *Agent) queueDepth() int {
+	return len(ag.pollCh) + len(ag.jobsCh)
+}
+
 func (ag *Agent) collectMetrics(
 	ctx context.Context,
 	wg *sync.WaitGroup,
@@ -119,10 +234,15 @@ func (ag *Agent) collectMetrics(
 			case <-pollTicker.C:
 				ag.logger.Info("get metrics tick")
 				counter++
-				statMetrics := ag.getMetricsFromStats(counter)
-				metricsPollCh <- statMetrics
-				psMetrics := ag.getPSMetrics()
-				metricsPollCh <- psMetrics
+				for _, metrics := range ag.collect(counter) {
+					// очередь полна, пока ее не разберет отправка, поэтому отмена ждется и здесь.
+					select {
+					case metricsPollCh <- metrics:
+					case <-ctx.Done():
+						ag.logger.Info("collect metrics stop by ctx")
+						return
+					}
+				}
 			}
 		}
 	}()
@@ -181,7 +301,7 @@ func (ag *Agent) sendMetrics(ctx context.Context, workerID int, wg *sync.WaitGro
 				ag.logger.Infof("send metrics worker %v stop by ctx", workerID)
 				return
 			case metrics := <-metricsCh:
-				err := ag.metricsCli.UpdateMetrics(ctx, metric.CastToMetrics(metrics))
+				err := ag.send(ctx, metrics)
 				if err != nil {
 					ag.logger.Error(err)
 				}
@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"sync"
 	"testing"
 
@@ -10,9 +13,11 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
 
+	"github.com/NStegura/metrics/internal/clients/metric"
 	mock_agent "github.com/NStegura/metrics/mocks/app/agent"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_collectMetrics(t *testing.T) {
@@ -35,3 +40,37 @@ func TestAgent_collectMetrics(t *testing.T) {
 		assert.True(t, ok)
 	}
 }
+
+func TestAgent_RunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metricsCli := mock_agent.NewMockMetricCli(ctrl)
+	metricsCli.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	ag := New(config.NewAgentConfig(), metricsCli, logrus.New())
+	assert.NoError(t, ag.RunOnce(context.Background()))
+}
+
+func TestAgent_RunOnce__sendFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metricsCli := mock_agent.NewMockMetricCli(ctrl)
+	metricsCli.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).Return(errors.New("unavailable")).Times(2)
+
+	ag := New(config.NewAgentConfig(), metricsCli, logrus.New())
+	assert.Error(t, ag.RunOnce(context.Background()))
+}
+
+func TestAgent_DryRun(t *testing.T) {
+	ag := New(config.NewAgentConfig(), nil, logrus.New())
+
+	var out bytes.Buffer
+	require.NoError(t, ag.DryRun(&out))
+
+	dec := json.NewDecoder(&out)
+	var batch []metric.Metrics
+	require.NoError(t, dec.Decode(&batch))
+	assert.NotEmpty(t, batch)
+}
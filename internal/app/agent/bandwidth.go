@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NStegura/metrics/internal/clients/metric"
+)
+
+const (
+	lowPriority int = iota
+	normalPriority
+	highPriority
+	counterPriority
+)
+
+// priorities приоритеты метрик, при превышении лимита первыми отбрасываются метрики с меньшим приоритетом.
+var priorities = map[string]int{
+	string(randomValue):     lowPriority,
+	string(alloc):           highPriority,
+	string(heapAlloc):       highPriority,
+	string(sys):             highPriority,
+	string(totalMemory):     highPriority,
+	string(freeMemory):      highPriority,
+	string(CPUutilization1): highPriority,
+}
+
+func metricPriority(m metric.Metrics) int {
+	if m.MType == string(counterT) {
+		return counterPriority
+	}
+	if p, ok := priorities[m.ID]; ok {
+		return p
+	}
+	return normalPriority
+}
+
+// bandwidthLimiter ограничивает объем отправляемых данных в байтах в секунду.
+type bandwidthLimiter struct {
+	last   time.Time
+	now    func() time.Time
+	mu     sync.Mutex
+	tokens float64
+	limit  float64
+}
+
+func newBandwidthLimiter(bytesPerSecond int) *bandwidthLimiter {
+	l := &bandwidthLimiter{
+		now:    time.Now,
+		limit:  float64(bytesPerSecond),
+		tokens: float64(bytesPerSecond),
+	}
+	l.last = l.now()
+	return l
+}
+
+// fit оставляет в батче метрики, укладывающиеся в доступный лимит,
+// отбрасывая в первую очередь метрики с меньшим приоритетом.
+func (l *bandwidthLimiter) fit(batch []metric.Metrics) (kept []metric.Metrics, dropped int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.limit, l.tokens+elapsed.Seconds()*l.limit)
+		l.last = now
+	}
+
+	sorted := make([]metric.Metrics, len(batch))
+	copy(sorted, batch)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return metricPriority(sorted[i]) > metricPriority(sorted[j])
+	})
+
+	kept = make([]metric.Metrics, 0, len(sorted))
+	for _, m := range sorted {
+		size := float64(m.Size())
+		if size > l.tokens {
+			dropped++
+			continue
+		}
+		l.tokens -= size
+		kept = append(kept, m)
+	}
+	return kept, dropped
+}
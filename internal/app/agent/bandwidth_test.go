@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/NStegura/metrics/internal/clients/metric"
+)
+
+func TestBandwidthLimiter_fit(t *testing.T) {
+	delta := int64(1)
+	value := 1.0
+	counterM := metric.Metrics{ID: string(pollCount), MType: string(counterT), Delta: &delta}
+	randomM := metric.Metrics{ID: string(randomValue), MType: string(gauge), Value: &value}
+	allocM := metric.Metrics{ID: string(alloc), MType: string(gauge), Value: &value}
+
+	now := time.Now()
+	l := newBandwidthLimiter(counterM.Size() + allocM.Size())
+	l.now = func() time.Time { return now }
+
+	kept, dropped := l.fit([]metric.Metrics{randomM, allocM, counterM})
+	assert.Equal(t, []metric.Metrics{counterM, allocM}, kept)
+	assert.Equal(t, 1, dropped)
+
+	kept, dropped = l.fit([]metric.Metrics{counterM})
+	assert.Empty(t, kept)
+	assert.Equal(t, 1, dropped)
+
+	now = now.Add(time.Second)
+	kept, _ = l.fit([]metric.Metrics{counterM})
+	assert.Equal(t, []metric.Metrics{counterM}, kept)
+}
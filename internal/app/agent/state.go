@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/NStegura/metrics/internal/app/agent/models"
+)
+
+// state хранит сведения о работе агента для эндпоинтов статуса.
+type state struct {
+	collected   map[string]models.Metrics
+	lastSendAt  time.Time
+	lastSendErr error
+	mu          sync.RWMutex
+	ready       bool
+}
+
+func newState() *state {
+	return &state{collected: make(map[string]models.Metrics)}
+}
+
+func (s *state) setCollected(collector string, m models.Metrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collected[collector] = m
+}
+
+// setSendResult запоминает результат отправки, агент готов после первой успешной отправки.
+func (s *state) setSendResult(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSendAt = time.Now()
+	s.lastSendErr = err
+	if err == nil {
+		s.ready = true
+	}
+}
+
+func (s *state) isReady() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ready
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/clients/metric"
+)
+
+const (
+	statusShutdownTimeout = 5 * time.Second
+	statusReadTimeout     = 5 * time.Second
+)
+
+type statusResponse struct {
+	LastSendAt    *time.Time         `json:"last_send_at,omitempty"`
+	LastSendError string             `json:"last_send_error,omitempty"`
+	Config        config.AgentConfig `json:"config"`
+	Collectors    []string           `json:"collectors"`
+	LastValues    []metric.Metrics   `json:"last_values"`
+	QueueDepth    int                `json:"queue_depth"`
+	Ready         bool               `json:"ready"`
+}
+
+// startStatusServer запускает локальный http сервер статуса агента.
+func (ag *Agent) startStatusServer(ctx context.Context, wg *sync.WaitGroup) {
+	srv := &http.Server{
+		Addr:              statusAddr(ag.cfg.StatusAddr),
+		Handler:           ag.statusRouter(),
+		ReadHeaderTimeout: statusReadTimeout,
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ag.logger.Infof("starting status server %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			ag.logger.Errorf("status server failed: %s", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), statusShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			ag.logger.Errorf("failed to shutdown status server: %s", err)
+		}
+		ag.logger.Info("status server stop by ctx")
+	}()
+}
+
+func (ag *Agent) statusRouter() http.Handler {
+	r := http.NewServeMux()
+
+	r.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !ag.state.isReady() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	r.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ag.status()); err != nil {
+			ag.logger.Error(err)
+		}
+	})
+	return r
+}
+
+func (ag *Agent) status() statusResponse {
+	resp := statusResponse{
+		Config:     ag.cfg.Redacted(),
+		Collectors: ag.Collectors(),
+		QueueDepth: ag.queueDepth(),
+	}
+
+	ag.state.mu.RLock()
+	defer ag.state.mu.RUnlock()
+
+	resp.Ready = ag.state.ready
+	if !ag.state.lastSendAt.IsZero() {
+		lastSendAt := ag.state.lastSendAt
+		resp.LastSendAt = &lastSendAt
+	}
+	if ag.state.lastSendErr != nil {
+		resp.LastSendError = ag.state.lastSendErr.Error()
+	}
+	for _, m := range ag.state.collected {
+		resp.LastValues = append(resp.LastValues, metric.CastToMetrics(m)...)
+	}
+	sort.Slice(resp.LastValues, func(i, j int) bool {
+		return resp.LastValues[i].ID < resp.LastValues[j].ID
+	})
+	return resp
+}
+
+// statusAddr по умолчанию привязывает сервер статуса к localhost.
+func statusAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("localhost", port)
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	mock_agent "github.com/NStegura/metrics/mocks/app/agent"
+)
+
+func TestAgent_statusRouter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metricsCli := mock_agent.NewMockMetricCli(ctrl)
+	metricsCli.EXPECT().UpdateMetrics(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	cfg := config.NewAgentConfig()
+	cfg.BodyHashKey = "secret"
+	ag := New(cfg, metricsCli, logrus.New())
+
+	ts := httptest.NewServer(ag.statusRouter())
+	defer ts.Close()
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := ts.Client().Get(ts.URL + path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz").StatusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz").StatusCode)
+
+	require.NoError(t, ag.RunOnce(context.Background()))
+	assert.Equal(t, http.StatusOK, get("/readyz").StatusCode)
+
+	var status statusResponse
+	require.NoError(t, json.NewDecoder(get("/status").Body).Decode(&status))
+	assert.Equal(t, "***", status.Config.BodyHashKey)
+	assert.Equal(t, []string{"runtime", "ps"}, status.Collectors)
+	assert.NotEmpty(t, status.LastValues)
+	assert.Empty(t, status.LastSendError)
+}
+
+func TestStatusAddr(t *testing.T) {
+	assert.Equal(t, "localhost:8091", statusAddr(":8091"))
+	assert.Equal(t, "0.0.0.0:8091", statusAddr("0.0.0.0:8091"))
+}
@@ -0,0 +1,104 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+	pb "github.com/NStegura/metrics/pkg/api"
+)
+
+const authorizationKey = "authorization"
+
+// adminMethods требуют токен админского API.
+var adminMethods = map[string]bool{
+	pb.MetricsApi_DeleteMetric_FullMethodName:  true,
+	pb.MetricsApi_DeleteMetrics_FullMethodName: true,
+	pb.MetricsApi_RenameMetric_FullMethodName:  true,
+	pb.MetricsApi_ResetCounter_FullMethodName:  true,
+}
+
+// checkAdmin проверяет токен в метаданных authorization: Bearer для админских методов,
+// без настроенного токена админские методы выключены.
+func (s *MetricsGRPCServer) checkAdmin(ctx context.Context, method string) error {
+	if !adminMethods[method] {
+		return nil
+	}
+	if s.adminToken == "" {
+		return status.Error(codes.Unimplemented, "admin api is disabled")
+	}
+	token, ok := strings.CutPrefix(metadataValue(ctx, authorizationKey), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid admin token")
+	}
+	return nil
+}
+
+func (s *MetricsGRPCServer) DeleteMetric(ctx context.Context, req *pb.MetricRef) (*emptypb.Empty, error) {
+	if err := s.bll.DeleteMetric(ctx, metricType(req.GetMtype()), req.GetId()); err != nil {
+		return nil, s.adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *MetricsGRPCServer) DeleteMetrics(ctx context.Context, req *pb.MetricFilter) (*pb.DeleteResponse, error) {
+	filter := blModels.ListFilter{Prefix: req.GetPrefix(), Glob: req.GetGlob(), Regex: req.GetRegex()}
+	if req.Mtype != nil {
+		filter.Type = metricType(req.GetMtype())
+	}
+	deleted, err := s.bll.DeleteMetrics(ctx, filter)
+	if err != nil {
+		return nil, s.adminError(err)
+	}
+	return &pb.DeleteResponse{Deleted: deleted}, nil
+}
+
+func (s *MetricsGRPCServer) RenameMetric(ctx context.Context, req *pb.RenameRequest) (*emptypb.Empty, error) {
+	m := req.GetMetric()
+	if err := s.bll.RenameMetric(ctx, metricType(m.GetMtype()), m.GetId(), req.GetNewId()); err != nil {
+		return nil, s.adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *MetricsGRPCServer) ResetCounter(ctx context.Context, req *pb.MetricRef) (*emptypb.Empty, error) {
+	if req.GetMtype() != pb.MetricType_COUNTER {
+		return nil, status.Error(codes.InvalidArgument, "only counter metric can be reset")
+	}
+	if err := s.bll.ResetCounter(ctx, req.GetId()); err != nil {
+		return nil, s.adminError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func metricType(mType pb.MetricType) string {
+	switch mType {
+	case pb.MetricType_GAUGE:
+		return "gauge"
+	case pb.MetricType_COUNTER:
+		return "counter"
+	default:
+		return mType.String()
+	}
+}
+
+func (s *MetricsGRPCServer) adminError(err error) error {
+	switch {
+	case errors.Is(err, customerrors.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, customerrors.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, customerrors.ErrInvalidFilter):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		s.logger.Error(err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
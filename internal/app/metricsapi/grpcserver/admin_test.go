@@ -0,0 +1,94 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/NStegura/metrics/config"
+	pb "github.com/NStegura/metrics/pkg/api"
+)
+
+func startAdminServer(t *testing.T, token string) (*MetricsGRPCServer, pb.MetricsApiClient) {
+	t.Helper()
+	cfg := config.NewSrvConfig()
+	cfg.AdminToken = token
+	s, addr := startTestServer(t, cfg)
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := pb.NewMetricsApiClient(conn)
+
+	_, err = client.UpdateAllMetrics(context.Background(), &pb.MetricsList{Metrics: []*pb.Metric{
+		{Id: "HeapAlloc", Mtype: pb.MetricType_GAUGE, Value: 3},
+		{Id: "HeapSys", Mtype: pb.MetricType_GAUGE, Value: 4},
+		{Id: "PollCount", Mtype: pb.MetricType_COUNTER, Delta: 5},
+	}})
+	require.NoError(t, err)
+	return s, client
+}
+
+func adminContext(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationKey, "Bearer "+token)
+}
+
+func TestAdmin__auth(t *testing.T) {
+	_, client := startAdminServer(t, "secret")
+	ref := &pb.MetricRef{Id: "PollCount", Mtype: pb.MetricType_COUNTER}
+
+	_, err := client.ResetCounter(context.Background(), ref)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.ResetCounter(adminContext("wrong"), ref)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.ResetCounter(adminContext("secret"), ref)
+	assert.NoError(t, err)
+
+	_, client = startAdminServer(t, "")
+	_, err = client.ResetCounter(adminContext(""), ref)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestAdmin__methods(t *testing.T) {
+	s, client := startAdminServer(t, "secret")
+	ctx := adminContext("secret")
+
+	_, err := client.ResetCounter(ctx, &pb.MetricRef{Id: "PollCount", Mtype: pb.MetricType_COUNTER})
+	require.NoError(t, err)
+	value, err := s.bll.GetCounterMetric(context.Background(), "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), value)
+	_, err = client.ResetCounter(ctx, &pb.MetricRef{Id: "HeapSys", Mtype: pb.MetricType_GAUGE})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.RenameMetric(ctx, &pb.RenameRequest{
+		Metric: &pb.MetricRef{Id: "HeapSys", Mtype: pb.MetricType_GAUGE}, NewId: "HeapAlloc"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = client.RenameMetric(ctx, &pb.RenameRequest{
+		Metric: &pb.MetricRef{Id: "HeapSys", Mtype: pb.MetricType_GAUGE}, NewId: "Sys"})
+	require.NoError(t, err)
+	_, err = s.bll.GetGaugeMetric(context.Background(), "Sys")
+	require.NoError(t, err)
+
+	_, err = client.DeleteMetric(ctx, &pb.MetricRef{Id: "HeapSys", Mtype: pb.MetricType_GAUGE})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.DeleteMetric(ctx, &pb.MetricRef{Id: "Sys", Mtype: pb.MetricType_GAUGE})
+	require.NoError(t, err)
+
+	_, err = client.DeleteMetrics(ctx, &pb.MetricFilter{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	counter := pb.MetricType_COUNTER
+	resp, err := client.DeleteMetrics(ctx, &pb.MetricFilter{Mtype: &counter, Glob: "*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.GetDeleted())
+	gms, cms, err := s.bll.GetAllMetrics(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, gms, 1)
+	assert.Empty(t, cms)
+}
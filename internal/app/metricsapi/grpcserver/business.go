@@ -13,6 +13,13 @@ type Bll interface {
 	GetCounterMetric(context.Context, string) (int64, error)
 	UpdateCounterMetric(context.Context, blModels.CounterMetric) error
 	GetAllMetrics(context.Context) ([]blModels.GaugeMetric, []blModels.CounterMetric, error)
+	DeleteMetric(ctx context.Context, mType string, mName string) error
+	DeleteMetrics(context.Context, blModels.ListFilter) (int64, error)
+	RenameMetric(ctx context.Context, mType string, mName string, newName string) error
+	ResetCounter(ctx context.Context, mName string) error
+	UpdateBatch(
+		ctx context.Context, batchID string, mode blModels.BatchMode, items []blModels.BatchItem,
+	) (blModels.BatchResult, error)
 
 	Ping(context.Context) error
 }
@@ -0,0 +1,243 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NStegura/metrics/internal/utils/certs"
+	"github.com/NStegura/metrics/internal/utils/replay"
+	"github.com/NStegura/metrics/internal/utils/signing"
+	pb "github.com/NStegura/metrics/pkg/api"
+)
+
+const (
+	ipKey   = "ip"
+	hashKey = "hashsha256"
+)
+
+// withPeerIdentity сохраняет в контексте CN и SAN сертификата клиента при mTLS.
+func withPeerIdentity(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ctx
+	}
+	return certs.WithIdentity(ctx, certs.IdentityFromCert(tlsInfo.State.PeerCertificates[0]))
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// checkTrustedSubnet проверяет, что ip из метаданных входит в доверенную подсеть.
+func (s *MetricsGRPCServer) checkTrustedSubnet(ctx context.Context) error {
+	if s.trustedSubnet == nil {
+		return nil
+	}
+	realIP := metadataValue(ctx, ipKey)
+	if realIP == "" {
+		return status.Error(codes.InvalidArgument, "ip metadata is missing")
+	}
+	ip := net.ParseIP(realIP)
+	if ip == nil || !s.trustedSubnet.Contains(ip) {
+		return status.Error(codes.PermissionDenied, "ip is not trusted")
+	}
+	return nil
+}
+
+// signedMethods изменяют данные, при заданном каталоге ключей они требуют подписи ключом агента.
+var signedMethods = map[string]bool{
+	pb.MetricsApi_UpdateAllMetrics_FullMethodName: true,
+}
+
+// replayRequired сообщает, что методы на запись принимаются только подписанными
+// с меткой времени и nonce: включена защита от повторов и задан ключ подписи.
+func (s *MetricsGRPCServer) replayRequired() bool {
+	return s.replay != nil && (s.cfg.BodyHashKey != "" || s.signingKeys != nil)
+}
+
+// checkSignature проверяет HMAC сериализованного запроса и подпись ключом агента,
+// если клиент их передал, и при включенной защите отклоняет устаревшие и повторные запросы,
+// а неподписанные запросы к методам на запись - чтобы без подписи нельзя было обойти проверку повторов.
+// Возвращает контекст с идентификатором проверенного ключа.
+func (s *MetricsGRPCServer) checkSignature(ctx context.Context, method string, req any) (context.Context, error) {
+	sign := metadataValue(ctx, hashKey)
+	hmacSigned := s.cfg.BodyHashKey != "" && sign != ""
+	keyID := metadataValue(ctx, signing.KeyIDKey)
+	keySigned := s.signingKeys != nil && keyID != ""
+	if s.signingKeys != nil && signedMethods[method] && !keySigned {
+		return ctx, status.Error(codes.Unauthenticated, "request signature is required")
+	}
+	if s.replayRequired() && signedMethods[method] && !hmacSigned && !keySigned {
+		return ctx, status.Error(codes.Unauthenticated, "signed request with timestamp and nonce is required")
+	}
+	if !hmacSigned && !keySigned {
+		return ctx, nil
+	}
+
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return ctx, status.Error(codes.Internal, "failed to check request hash")
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		s.logger.Errorf("failed to marshal request, err: %s", err)
+		return ctx, status.Error(codes.Internal, "failed to check request hash")
+	}
+	timestamp := metadataValue(ctx, replay.TimestampKey)
+	nonce := metadataValue(ctx, replay.NonceKey)
+	data := replay.SignedData(timestamp, nonce, body)
+
+	if hmacSigned {
+		hashRequest, err := hex.DecodeString(sign)
+		if err != nil {
+			return ctx, status.Error(codes.InvalidArgument, "invalid request hash")
+		}
+		hm := hmac.New(sha256.New, []byte(s.cfg.BodyHashKey))
+		hm.Write(data)
+		if !hmac.Equal(hm.Sum(nil), hashRequest) {
+			return ctx, status.Error(codes.InvalidArgument, "request hash mismatch")
+		}
+	}
+	if keySigned {
+		if err = s.signingKeys.Verify(keyID, data, metadataValue(ctx, signing.SignatureKey)); err != nil {
+			s.logger.Warningf("rejected request signature, err: %s", err)
+			return ctx, status.Error(codes.PermissionDenied, err.Error())
+		}
+		ctx = signing.WithKeyID(ctx, keyID)
+	}
+	if s.replay != nil {
+		if err = s.replay.Check(timestamp, nonce); err != nil {
+			if errors.Is(err, replay.ErrReplayed) {
+				return ctx, status.Error(codes.AlreadyExists, err.Error())
+			}
+			return ctx, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	return ctx, nil
+}
+
+func (s *MetricsGRPCServer) logRequest(ctx context.Context, method string, start time.Time, err error) {
+	fields := logrus.Fields{
+		"uri":      method,
+		"method":   "grpc",
+		"status":   status.Code(err).String(),
+		"duration": time.Since(start),
+	}
+	if id, ok := certs.IdentityFromContext(ctx); ok {
+		fields["peer"] = id.String()
+	}
+	s.logger.WithFields(fields).Info()
+}
+
+func (s *MetricsGRPCServer) recoverPanic(method string, err *error) {
+	if r := recover(); r != nil {
+		s.logger.Errorf("panic in %s: %v\n%s", method, r, debug.Stack())
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+func (s *MetricsGRPCServer) unaryInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp any, err error) {
+	start := time.Now()
+	ctx = withPeerIdentity(ctx)
+	defer func() {
+		s.logRequest(ctx, info.FullMethod, start, err)
+	}()
+	defer s.recoverPanic(info.FullMethod, &err)
+
+	if err = s.checkTrustedSubnet(ctx); err != nil {
+		return nil, err
+	}
+	if err = s.checkAdmin(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	if ctx, err = s.checkSignature(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// securedStream проверяет подпись первого сообщения потока,
+// подпись в метаданных не может покрывать последующие сообщения.
+type securedStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	server   *MetricsGRPCServer
+	method   string
+	received int
+}
+
+func (ss *securedStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (ss *securedStream) RecvMsg(m any) error {
+	if err := ss.ServerStream.RecvMsg(m); err != nil {
+		return err //nolint:wrapcheck // proxy
+	}
+	ss.received++
+	signed := (ss.server.cfg.BodyHashKey != "" && metadataValue(ss.ctx, hashKey) != "") ||
+		(ss.server.signingKeys != nil && metadataValue(ss.ctx, signing.KeyIDKey) != "")
+	if !signed && !(ss.server.replayRequired() && signedMethods[ss.method]) {
+		return nil
+	}
+	if ss.received > 1 {
+		return status.Error(codes.InvalidArgument, "signed stream accepts a single message")
+	}
+	ctx, err := ss.server.checkSignature(ss.ctx, ss.method, m)
+	if err != nil {
+		return err
+	}
+	ss.ctx = ctx
+	return nil
+}
+
+func (s *MetricsGRPCServer) streamInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	start := time.Now()
+	ctx := withPeerIdentity(ss.Context())
+	defer func() {
+		s.logRequest(ctx, info.FullMethod, start, err)
+	}()
+	defer s.recoverPanic(info.FullMethod, &err)
+
+	if err = s.checkTrustedSubnet(ctx); err != nil {
+		return err
+	}
+	return handler(srv, &securedStream{ServerStream: ss, ctx: ctx, server: s, method: info.FullMethod})
+}
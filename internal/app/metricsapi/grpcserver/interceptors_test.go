@@ -0,0 +1,252 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/clients/base"
+	"github.com/NStegura/metrics/internal/clients/metric"
+	"github.com/NStegura/metrics/internal/repo"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
+	"github.com/NStegura/metrics/internal/utils/replay"
+	"github.com/NStegura/metrics/internal/utils/signing"
+	pb "github.com/NStegura/metrics/pkg/api"
+)
+
+func startTestServer(t *testing.T, cfg *config.SrvConfig) (*MetricsGRPCServer, string) {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	s, err := New(cfg, business.New(r, l), l)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(s.ServerOptions()...)
+	pb.RegisterMetricsApiServer(grpcServer, s)
+	go func() {
+		_ = grpcServer.Serve(lis)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	return s, lis.Addr().String()
+}
+
+func testMetrics() []metric.Metrics {
+	value := 1.5
+	return []metric.Metrics{{ID: "signed", MType: "gauge", Value: &value}}
+}
+
+func TestUnaryInterceptor__hash(t *testing.T) {
+	cfg := config.NewSrvConfig()
+	cfg.BodyHashKey = "secret"
+	_, addr := startTestServer(t, cfg)
+
+	cli, err := metric.NewGRPCClient(addr, base.WithBodyHashKey("secret"))
+	require.NoError(t, err)
+	require.NoError(t, cli.UpdateMetrics(context.Background(), testMetrics()))
+
+	cli, err = metric.NewGRPCClient(addr, base.WithBodyHashKey("other"))
+	require.NoError(t, err)
+	err = cli.UpdateMetrics(context.Background(), testMetrics())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), codes.InvalidArgument.String())
+}
+
+func TestUnaryInterceptor__replay(t *testing.T) {
+	cfg := config.NewSrvConfig()
+	cfg.BodyHashKey = "secret"
+	cfg.ReplayWindow = config.Duration(time.Minute)
+	_, addr := startTestServer(t, cfg)
+
+	cli, err := metric.NewGRPCClient(addr, base.WithBodyHashKey("secret"))
+	require.NoError(t, err)
+	require.NoError(t, cli.UpdateMetrics(context.Background(), testMetrics()))
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	rawCli := pb.NewMetricsApiClient(conn)
+
+	req := &pb.MetricsList{Metrics: []*pb.Metric{{Id: "replayed", Mtype: pb.MetricType_COUNTER, Delta: 1}}}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	require.NoError(t, err)
+	sign, _, err := cli.Sign(body)
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		hashKey, sign.Hash, replay.TimestampKey, sign.Timestamp, replay.NonceKey, sign.Nonce)
+
+	_, err = rawCli.UpdateAllMetrics(ctx, req)
+	require.NoError(t, err)
+	_, err = rawCli.UpdateAllMetrics(ctx, req)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), hashKey, sign.Hash)
+	_, err = rawCli.UpdateAllMetrics(ctx, req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "signature without nonce must not match")
+
+	_, err = rawCli.UpdateAllMetrics(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "unsigned request must not skip replay check")
+}
+
+func TestUnaryInterceptor__signingKeys(t *testing.T) {
+	keysDir := t.TempDir()
+	key, err := signing.GenerateKey(signing.AlgECDSA)
+	require.NoError(t, err)
+	private, err := signing.MarshalPrivateKey(key)
+	require.NoError(t, err)
+	public, err := signing.MarshalPublicKey(key.Public())
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "agent.key")
+	require.NoError(t, os.WriteFile(keyPath, private, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, "agent.pem"), public, 0600))
+
+	cfg := config.NewSrvConfig()
+	cfg.SigningKeysDir = keysDir
+	_, addr := startTestServer(t, cfg)
+
+	cli, err := metric.NewGRPCClient(addr, base.WithSigningKey(keyPath, "agent"))
+	require.NoError(t, err)
+	require.NoError(t, cli.UpdateMetrics(context.Background(), testMetrics()))
+
+	cli, err = metric.NewGRPCClient(addr, base.WithSigningKey(keyPath, "other"))
+	require.NoError(t, err)
+	err = cli.UpdateMetrics(context.Background(), testMetrics())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), codes.PermissionDenied.String())
+
+	cli, err = metric.NewGRPCClient(addr)
+	require.NoError(t, err)
+	err = cli.UpdateMetrics(context.Background(), testMetrics())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), codes.Unauthenticated.String())
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = pb.NewMetricsApiClient(conn).GetPing(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err, "ping does not require signature")
+}
+
+func TestUnaryInterceptor__trustedSubnet(t *testing.T) {
+	cfg := config.NewSrvConfig()
+	cfg.TrustedSubnet = "10.0.0.0/8"
+	_, addr := startTestServer(t, cfg)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	cli := pb.NewMetricsApiClient(conn)
+
+	tests := []struct {
+		name string
+		md   []string
+		code codes.Code
+	}{
+		{name: "trusted", md: []string{ipKey, "10.1.2.3"}, code: codes.OK},
+		{name: "untrusted", md: []string{ipKey, "192.168.1.1"}, code: codes.PermissionDenied},
+		{name: "missing ip", md: nil, code: codes.InvalidArgument},
+	}
+	for _, v := range tests {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), v.md...)
+		_, err = cli.UpdateAllMetrics(ctx, &pb.MetricsList{})
+		assert.Equal(t, v.code, status.Code(err), v.name)
+	}
+}
+
+func TestUnaryInterceptor__recover(t *testing.T) {
+	s, _ := startTestServer(t, config.NewSrvConfig())
+
+	_, err := s.unaryInterceptor(
+		context.Background(),
+		&pb.MetricsList{},
+		&grpc.UnaryServerInfo{FullMethod: "/metricsapi.MetricsApi/UpdateAllMetrics"},
+		func(context.Context, any) (any, error) {
+			panic("boom")
+		},
+	)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestUpdateAllMetrics__idempotencyKey(t *testing.T) {
+	s, addr := startTestServer(t, config.NewSrvConfig())
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	client := pb.NewMetricsApiClient(conn)
+
+	req := &pb.MetricsList{Metrics: []*pb.Metric{{Id: "idempotent", Mtype: pb.MetricType_COUNTER, Delta: 2}}}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), idempotency.Key, "batch-1")
+	resp, err := client.UpdateAllMetrics(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Metrics updated successfully", resp.GetMessage())
+
+	resp, err = client.UpdateAllMetrics(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Metrics already applied", resp.GetMessage())
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), idempotency.Key, "bad key")
+	_, err = client.UpdateAllMetrics(ctx, req)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	value, err := s.bll.GetCounterMetric(context.Background(), "idempotent")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+}
+
+func TestUpdateAllMetrics__modes(t *testing.T) {
+	s, addr := startTestServer(t, config.NewSrvConfig())
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+	client := pb.NewMetricsApiClient(conn)
+
+	req := &pb.MetricsList{Metrics: []*pb.Metric{
+		{Id: "ModeCounter", Mtype: pb.MetricType_COUNTER, Delta: 2},
+		{Mtype: pb.MetricType_GAUGE, Value: 1},
+	}}
+	_, err = client.UpdateAllMetrics(context.Background(), req)
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	report, ok := st.Details()[0].(*pb.UpdateResponse)
+	require.True(t, ok)
+	assert.Equal(t, int32(1), report.GetFailed())
+	assert.Equal(t, pb.ItemStatus_SKIPPED, report.GetResults()[0].GetStatus())
+	assert.Equal(t, pb.ItemStatus_FAILED, report.GetResults()[1].GetStatus())
+	_, err = s.bll.GetCounterMetric(context.Background(), "ModeCounter")
+	assert.Error(t, err)
+
+	req.Mode = pb.BatchMode_PARTIAL
+	resp, err := client.UpdateAllMetrics(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Metrics partially updated", resp.GetMessage())
+	assert.Equal(t, int32(1), resp.GetApplied())
+	assert.Equal(t, int32(1), resp.GetFailed())
+	assert.Equal(t, pb.ItemStatus_APPLIED, resp.GetResults()[0].GetStatus())
+	assert.Equal(t, "empty metric name", resp.GetResults()[1].GetError())
+	value, err := s.bll.GetCounterMetric(context.Background(), "ModeCounter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+}
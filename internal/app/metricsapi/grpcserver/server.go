@@ -2,37 +2,103 @@ package grpcserver
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/NStegura/metrics/config"
 	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/utils/certs"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
+	// Регистрирует в gRPC кодеки сжатия, которые использует клиент.
+	_ "github.com/NStegura/metrics/internal/utils/compress"
+	"github.com/NStegura/metrics/internal/utils/replay"
+	"github.com/NStegura/metrics/internal/utils/signing"
 	pb "github.com/NStegura/metrics/pkg/api"
 )
 
 type MetricsGRPCServer struct {
 	pb.UnimplementedMetricsApiServer
 
-	cfg *config.SrvConfig
-	bll Bll
+	cfg           *config.SrvConfig
+	tlsConfig     *tls.Config
+	trustedSubnet *net.IPNet
+	replay        *replay.Guard
+	signingKeys   *signing.KeyDir
+	adminToken    string
+	bll           Bll
 
 	logger *logrus.Logger
 }
 
 func New(cfg *config.SrvConfig, bll Bll, logger *logrus.Logger) (*MetricsGRPCServer, error) {
+	var (
+		tlsConfig *tls.Config
+		subnet    *net.IPNet
+		guard     *replay.Guard
+		keys      *signing.KeyDir
+		err       error
+	)
+	adminToken, err := cfg.AdminCredential()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin token: %w", err)
+	}
+	if cfg.TLSEnabled() {
+		tlsConfig, err = certs.ServerConfig(cfg.TLSCertPath, cfg.TLSKeyPath, cfg.TLSCAPath, cfg.TLSClientAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init tls: %w", err)
+		}
+	}
+	if cfg.TrustedSubnet != "" {
+		_, subnet, err = net.ParseCIDR(cfg.TrustedSubnet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted subnet: %w", err)
+		}
+	}
+	if cfg.ReplayProtection() {
+		guard = replay.NewGuard(time.Duration(cfg.ReplayWindow), cfg.ReplayCacheSize)
+	}
+	if cfg.SigningKeysDir != "" {
+		keys, err = signing.LoadKeyDir(cfg.SigningKeysDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing keys: %w", err)
+		}
+	}
 	return &MetricsGRPCServer{
-		cfg:    cfg,
-		bll:    bll,
-		logger: logger,
+		cfg:           cfg,
+		tlsConfig:     tlsConfig,
+		trustedSubnet: subnet,
+		replay:        guard,
+		signingKeys:   keys,
+		adminToken:    adminToken,
+		bll:           bll,
+		logger:        logger,
 	}, nil
 }
 
+// ServerOptions возвращает опции grpc сервера: TLS и перехватчики
+// с теми же проверками, что и у http сервера.
+func (s *MetricsGRPCServer) ServerOptions() []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	}
+	if s.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+	return opts
+}
+
 func (s *MetricsGRPCServer) Start(opts ...grpc.ServerOption) error {
 	s.logger.Infof("starting GRPCServer %s", s.cfg.GrpcAddr)
 	lis, err := net.Listen("tcp", s.cfg.GrpcAddr)
@@ -44,7 +110,7 @@ func (s *MetricsGRPCServer) Start(opts ...grpc.ServerOption) error {
 	if err != nil {
 		return fmt.Errorf("failed to create network listener: %w", err)
 	}
-	grpcServer := grpc.NewServer(opts...)
+	grpcServer := grpc.NewServer(append(s.ServerOptions(), opts...)...)
 	pb.RegisterMetricsApiServer(grpcServer, s)
 	if err = grpcServer.Serve(lis); err != nil {
 		return fmt.Errorf("failed to start grpc server: %w", err)
@@ -53,31 +119,75 @@ func (s *MetricsGRPCServer) Start(opts ...grpc.ServerOption) error {
 }
 
 func (s *MetricsGRPCServer) UpdateAllMetrics(ctx context.Context, req *pb.MetricsList) (*pb.UpdateResponse, error) {
+	batchID := metadataValue(ctx, idempotency.Key)
+	if batchID != "" {
+		if err := idempotency.Validate(batchID); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	mode := blModels.BatchAtomic
+	if req.Mode == pb.BatchMode_PARTIAL {
+		mode = blModels.BatchPartial
+	}
+	items := make([]blModels.BatchItem, 0, len(req.Metrics))
 	for _, metric := range req.Metrics {
+		item := blModels.BatchItem{Name: metric.Id, Type: metricType(metric.Mtype)}
 		switch metric.Mtype {
 		case pb.MetricType_GAUGE:
-			err := s.bll.UpdateGaugeMetric(ctx, blModels.GaugeMetric{
-				Name:  metric.Id,
-				Type:  "gauge",
-				Value: metric.Value,
-			})
-			if err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to update gauge metric")
-			}
+			item.Value = &metric.Value
 		case pb.MetricType_COUNTER:
-			err := s.bll.UpdateCounterMetric(ctx, blModels.CounterMetric{
-				Name:  metric.Id,
-				Type:  "counter",
-				Value: metric.Delta,
-			})
-			if err != nil {
-				return nil, status.Errorf(codes.Internal, "failed to update counter metric")
-			}
-		default:
-			return nil, status.Errorf(codes.InvalidArgument, "unknown metric type")
+			item.Delta = &metric.Delta
+		}
+		items = append(items, item)
+	}
+
+	result, err := s.bll.UpdateBatch(ctx, batchID, mode, items)
+	resp := updateResponse(result)
+	if err != nil {
+		// отчет по метрикам атомарного пакета передается в деталях ошибки.
+		st := status.New(codes.InvalidArgument, err.Error())
+		if !errors.Is(err, customerrors.ErrInvalidBatch) {
+			s.logger.Errorf("failed to update metrics, err: %s", err)
+			st = status.New(codes.Internal, "failed to update metrics")
+		}
+		if withReport, detailsErr := st.WithDetails(resp); detailsErr == nil {
+			st = withReport
+		}
+		return nil, st.Err() //nolint:wrapcheck // статус gRPC
+	}
+	return resp, nil
+}
+
+// updateResponse переводит результат пакета в ответ UpdateAllMetrics.
+func updateResponse(result blModels.BatchResult) *pb.UpdateResponse {
+	resp := &pb.UpdateResponse{
+		Message:  "Metrics updated successfully",
+		Results:  make([]*pb.ItemResult, 0, len(result.Items)),
+		Applied:  int32(result.Applied),
+		Failed:   int32(result.Failed),
+		Replayed: result.Replayed,
+	}
+	switch {
+	case result.Replayed:
+		resp.Message = "Metrics already applied"
+	case result.Failed > 0 && result.Applied > 0:
+		resp.Message = "Metrics partially updated"
+	case result.Failed > 0:
+		resp.Message = "Metrics not updated"
+	}
+	for _, item := range result.Items {
+		itemResult := &pb.ItemResult{Index: int32(item.Index), Id: item.Name, Error: item.Error}
+		switch item.Status {
+		case blModels.ItemApplied:
+			itemResult.Status = pb.ItemStatus_APPLIED
+		case blModels.ItemFailed:
+			itemResult.Status = pb.ItemStatus_FAILED
+		case blModels.ItemSkipped:
+			itemResult.Status = pb.ItemStatus_SKIPPED
 		}
+		resp.Results = append(resp.Results, itemResult)
 	}
-	return &pb.UpdateResponse{Message: "Metrics updated successfully"}, nil
+	return resp
 }
 
 func (s *MetricsGRPCServer) GetPing(_ context.Context, _ *emptypb.Empty) (*pb.Pong, error) {
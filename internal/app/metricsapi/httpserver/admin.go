@@ -0,0 +1,124 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mailru/easyjson"
+
+	"github.com/NStegura/metrics/internal/app/metricsapi/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+)
+
+const adminPath = "/api/v1/admin"
+
+// requireAdmin пропускает только запросы с токеном админского API в Authorization: Bearer.
+func (s *APIServer) requireAdmin(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// configAdminRouter подключает админский API, без токена он выключен.
+func (s *APIServer) configAdminRouter() {
+	if s.adminToken == "" {
+		return
+	}
+	s.Router.Route(adminPath, func(r chi.Router) {
+		r.Use(s.requireAdmin)
+		r.Delete(`/metrics`, s.deleteMetrics())
+		r.Delete(`/metrics/{mType}/{mName}`, s.deleteMetric())
+		r.Post(`/metrics/{mType}/{mName}/rename`, s.renameMetric())
+		r.Post(`/metrics/counter/{mName}/reset`, s.resetCounter())
+	})
+}
+
+func (s *APIServer) deleteMetric() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		err := s.bll.DeleteMetric(ctx, chi.URLParam(r, "mType"), chi.URLParam(r, string(mName)))
+		if err != nil {
+			s.writeAdminError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// deleteMetrics удаляет метрики по параметрам type, prefix, glob и regex, как в списке метрик.
+func (s *APIServer) deleteMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		filter, err := parseListFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		deleted, err := s.bll.DeleteMetrics(ctx, filter)
+		if err != nil {
+			s.writeAdminError(w, err)
+			return
+		}
+		s.writeJSONResp(models.DeleteResponse{Deleted: deleted}, w)
+	}
+}
+
+func (s *APIServer) renameMetric() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		var req models.RenameRequest
+		if err := easyjson.UnmarshalFromReader(r.Body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err := s.bll.RenameMetric(ctx, chi.URLParam(r, "mType"), chi.URLParam(r, string(mName)), req.Name)
+		if err != nil {
+			s.writeAdminError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *APIServer) resetCounter() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := s.bll.ResetCounter(ctx, chi.URLParam(r, string(mName))); err != nil {
+			s.writeAdminError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *APIServer) writeAdminError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, customerrors.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, customerrors.ErrAlreadyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, customerrors.ErrInvalidFilter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		s.logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
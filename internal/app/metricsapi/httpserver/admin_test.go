@@ -0,0 +1,146 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/repo"
+)
+
+const testAdminToken = "secret"
+
+func initAdminServer(t *testing.T, token string) *testHelper {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	cfg := config.NewSrvConfig()
+	cfg.AdminToken = token
+	server, err := New(cfg, business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	th := &testHelper{ts: httptest.NewServer(server.Router)}
+	t.Cleanup(th.ts.Close)
+
+	body := `[{"type": "gauge", "id": "HeapAlloc", "value": 3},
+		{"type": "gauge", "id": "HeapSys", "value": 4},
+		{"type": "gauge", "id": "Alloc", "value": 1},
+		{"type": "counter", "id": "PollCount", "delta": 5}]`
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body), nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	return th
+}
+
+func adminHeaders() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + testAdminToken}
+}
+
+func TestAdminHandlers__auth(t *testing.T) {
+	th := initAdminServer(t, testAdminToken)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", headers: map[string]string{"Authorization": "Bearer wrong"}, want: http.StatusUnauthorized},
+		{name: "basic auth", headers: map[string]string{"Authorization": "Basic c2VjcmV0"}, want: http.StatusUnauthorized},
+		{name: "valid token", headers: adminHeaders(), want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusCode, _ := th.Request(
+				t, http.MethodPost, adminPath+"/metrics/counter/PollCount/reset", nil, tt.headers)
+			assert.Equal(t, tt.want, statusCode)
+		})
+	}
+}
+
+func TestAdminHandlers__disabledWithoutToken(t *testing.T) {
+	th := initAdminServer(t, "")
+
+	statusCode, _ := th.Request(t, http.MethodDelete, adminPath+"/metrics/gauge/Alloc", nil, adminHeaders())
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, _ = th.Request(t, http.MethodGet, "/value/gauge/Alloc", nil, nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+}
+
+func TestAdminHandlers__deleteMetric(t *testing.T) {
+	th := initAdminServer(t, testAdminToken)
+
+	statusCode, _ := th.Request(t, http.MethodDelete, adminPath+"/metrics/gauge/Alloc", nil, adminHeaders())
+	assert.Equal(t, http.StatusNoContent, statusCode)
+	statusCode, _ = th.Request(t, http.MethodGet, "/value/gauge/Alloc", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+
+	statusCode, _ = th.Request(t, http.MethodDelete, adminPath+"/metrics/gauge/Alloc", nil, adminHeaders())
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, _ = th.Request(t, http.MethodDelete, adminPath+"/metrics/counter/HeapSys", nil, adminHeaders())
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, _ = th.Request(t, http.MethodDelete, adminPath+"/metrics/unknown/HeapSys", nil, adminHeaders())
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func TestAdminHandlers__deleteMetrics(t *testing.T) {
+	th := initAdminServer(t, testAdminToken)
+
+	statusCode, _ := th.Request(t, http.MethodDelete, adminPath+"/metrics", nil, adminHeaders())
+	assert.Equal(t, http.StatusBadRequest, statusCode, "pattern is required")
+
+	statusCode, body := th.Request(t, http.MethodDelete, adminPath+"/metrics?glob=Heap*", nil, adminHeaders())
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(t, `{"deleted": 2}`, body)
+
+	names, _ := listNames(t, th, nil)
+	assert.Equal(t, []string{"Alloc", "PollCount"}, names)
+
+	statusCode, body = th.Request(t, http.MethodDelete, adminPath+"/metrics?type=gauge&prefix=Poll", nil, adminHeaders())
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(t, `{"deleted": 0}`, body)
+}
+
+func TestAdminHandlers__renameMetric(t *testing.T) {
+	th := initAdminServer(t, testAdminToken)
+
+	rename := func(path, name string) int {
+		statusCode, _ := th.Request(t, http.MethodPost, adminPath+"/metrics/"+path+"/rename",
+			bytes.NewBufferString(`{"name": "`+name+`"}`), adminHeaders())
+		return statusCode
+	}
+
+	assert.Equal(t, http.StatusNoContent, rename("gauge/Alloc", "TotalAlloc"))
+	statusCode, body := th.Request(t, http.MethodGet, "/value/gauge/TotalAlloc", nil, nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "1", body)
+	statusCode, _ = th.Request(t, http.MethodGet, "/value/gauge/Alloc", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+
+	assert.Equal(t, http.StatusNoContent, rename("counter/PollCount", "Polls"))
+	assert.Equal(t, http.StatusConflict, rename("gauge/HeapSys", "HeapAlloc"))
+	assert.Equal(t, http.StatusConflict, rename("gauge/HeapSys", "Polls"))
+	assert.Equal(t, http.StatusNotFound, rename("gauge/Alloc", "Other"))
+	assert.Equal(t, http.StatusBadRequest, rename("gauge/HeapSys", ""))
+}
+
+func TestAdminHandlers__resetCounter(t *testing.T) {
+	th := initAdminServer(t, testAdminToken)
+
+	statusCode, _ := th.Request(t, http.MethodPost, adminPath+"/metrics/counter/PollCount/reset", nil, adminHeaders())
+	assert.Equal(t, http.StatusNoContent, statusCode)
+	statusCode, body := th.Request(t, http.MethodGet, "/value/counter/PollCount", nil, nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "0", body)
+
+	statusCode, _ = th.Request(t, http.MethodPost, adminPath+"/metrics/counter/Unknown/reset", nil, adminHeaders())
+	assert.Equal(t, http.StatusNotFound, statusCode)
+}
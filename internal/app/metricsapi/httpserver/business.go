@@ -3,6 +3,7 @@ package httpserver
 import (
 	"context"
 
+	"github.com/NStegura/metrics/internal/business/events"
 	blModels "github.com/NStegura/metrics/internal/business/models"
 )
 
@@ -13,6 +14,17 @@ type Bll interface {
 	GetCounterMetric(context.Context, string) (int64, error)
 	UpdateCounterMetric(context.Context, blModels.CounterMetric) error
 	GetAllMetrics(context.Context) ([]blModels.GaugeMetric, []blModels.CounterMetric, error)
+	ListMetrics(context.Context, blModels.ListFilter) ([]blModels.Metric, *blModels.Cursor, error)
+	DeleteMetric(ctx context.Context, mType string, mName string) error
+	DeleteMetrics(context.Context, blModels.ListFilter) (int64, error)
+	RenameMetric(ctx context.Context, mType string, mName string, newName string) error
+	ResetCounter(ctx context.Context, mName string) error
+	Subscribe(blModels.ListFilter) (*events.Subscription, error)
+	HistoryAvailable() bool
+	MetricHistory(ctx context.Context, mType string, mName string) ([]blModels.HistoryPoint, error)
+	UpdateBatch(
+		ctx context.Context, batchID string, mode blModels.BatchMode, items []blModels.BatchItem,
+	) (blModels.BatchResult, error)
 
 	Ping(context.Context) error
 }
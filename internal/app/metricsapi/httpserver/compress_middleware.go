@@ -0,0 +1,149 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/NStegura/metrics/internal/utils/compress"
+)
+
+// compressWriter сжимает только успешные ответы, поэтому энкодер создается при первой записи.
+type compressWriter struct {
+	http.ResponseWriter
+	codec       compress.Codec
+	zw          io.WriteCloser
+	wroteHeader bool
+	passthrough bool
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.passthrough {
+		return c.ResponseWriter.Write(p) //nolint:wrapcheck // proxy
+	}
+	if c.zw == nil {
+		zw, err := c.codec.Compress(c.ResponseWriter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to init %s writer: %w", c.codec.Name(), err)
+		}
+		c.zw = zw
+	}
+	count, err := c.zw.Write(p)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write comperessed resp: %w", err)
+	}
+	return count, nil
+}
+
+func (c *compressWriter) WriteHeader(statusCode int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	if statusCode < http.StatusMultipleChoices {
+		c.ResponseWriter.Header().Set("Content-Encoding", c.codec.Name())
+		c.ResponseWriter.Header().Del("Content-Length")
+	} else {
+		c.passthrough = true
+	}
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (c *compressWriter) Close() error {
+	if c.zw == nil {
+		return nil
+	}
+	if err := c.zw.Close(); err != nil {
+		return fmt.Errorf("failed to close writer %w", err)
+	}
+	return nil
+}
+
+type compressReader struct {
+	io.ReadCloser
+	zr io.Reader
+}
+
+func newCompressReader(r io.ReadCloser, codec compress.Codec) (*compressReader, error) {
+	zr, err := codec.Decompress(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init %s reader %w", codec.Name(), err)
+	}
+
+	return &compressReader{
+		ReadCloser: r,
+		zr:         zr,
+	}, nil
+}
+
+func (c compressReader) Read(p []byte) (n int, err error) {
+	count, err := c.zr.Read(p)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return count, io.EOF
+		}
+		return 0, fmt.Errorf("failed to read %w", err)
+	}
+	return count, nil
+}
+
+func (c *compressReader) Close() error {
+	if zr, ok := c.zr.(io.Closer); ok {
+		if err := zr.Close(); err != nil {
+			return fmt.Errorf("failed to close compress reader %w", err)
+		}
+	}
+	if err := c.ReadCloser.Close(); err != nil {
+		return fmt.Errorf("failed to close body %w", err)
+	}
+	return nil
+}
+
+// compressMiddleware распаковывает тело по Content-Encoding
+// и сжимает ответ кодеком, выбранным по Accept-Encoding.
+func (s *APIServer) compressMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ow := w
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		// потоки обновлений не сжимаются: сжатие копит данные до сброса энкодера.
+		if codec, ok := compress.Negotiate(r.Header.Get("Accept-Encoding")); ok && !isStreamPath(r.URL.Path) {
+			cw := &compressWriter{ResponseWriter: w, codec: codec}
+			ow = cw
+			defer func() {
+				if err := cw.Close(); err != nil {
+					s.logger.Error(err)
+				}
+			}()
+		}
+
+		contentEncoding := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+		if contentEncoding != "" && contentEncoding != "identity" {
+			codec, err := compress.Get(contentEncoding)
+			if err != nil {
+				s.logger.Warningf("unsupported content encoding %q", contentEncoding)
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			cr, err := newCompressReader(r.Body, codec)
+			if err != nil {
+				s.logger.Errorf("failed to init compress reader for body, err: %s", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.Body = cr
+			r.Header.Del("Content-Encoding")
+			defer func() {
+				if err := cr.Close(); err != nil {
+					s.logger.Error(err)
+				}
+			}()
+		}
+		h.ServeHTTP(ow, r)
+	})
+}
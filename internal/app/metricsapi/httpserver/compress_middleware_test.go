@@ -0,0 +1,102 @@
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/utils/compress"
+)
+
+func readGZIP(s string) string {
+	r := strings.NewReader(s)
+	reader, err := gzip.NewReader(r)
+	if err != nil {
+		return ""
+	}
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func TestGZIPMiddleware(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	th.Request(t, "POST", "/update/gauge/SomeGaugeMetric/1", nil, nil)
+
+	type want struct {
+		statusCode int
+	}
+
+	tests := []struct {
+		method string
+		name   string
+		url    string
+		want   want
+	}{
+		{
+			method: http.MethodGet,
+			name:   "get gauge metric",
+			url:    "/value/gauge/SomeGaugeMetric",
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+
+	for _, v := range tests {
+		statusCode, resp := th.Request(t, v.method, v.url, nil, map[string]string{"Accept-Encoding": "gzip"})
+		assert.Equal(t, v.want.statusCode, statusCode)
+		assert.Equal(t, "1", readGZIP(resp))
+	}
+}
+
+func TestCompressMiddleware__codecs(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	for _, name := range []string{compress.Gzip, compress.Zstd, compress.Snappy} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := compress.Get(name)
+			require.NoError(t, err)
+			body, err := compress.Encode(codec, []byte(`[{"id":"CodecGauge","type":"gauge","value":2.5}]`))
+			require.NoError(t, err)
+
+			statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewReader(body), map[string]string{
+				"Content-Type":     "application/json",
+				"Content-Encoding": name,
+			})
+			require.Equal(t, http.StatusOK, statusCode)
+
+			statusCode, resp := th.Request(t, http.MethodGet, "/value/gauge/CodecGauge", nil, map[string]string{
+				"Accept-Encoding": "br;q=1, " + name + ";q=0.9, gzip;q=0.1",
+			})
+			require.Equal(t, http.StatusOK, statusCode)
+			r, err := codec.Decompress(strings.NewReader(resp))
+			require.NoError(t, err)
+			value, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "2.5", string(value))
+		})
+	}
+}
+
+func TestCompressMiddleware__unsupportedEncoding(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/", strings.NewReader("[]"), map[string]string{
+		"Content-Type":     "application/json",
+		"Content-Encoding": "br",
+	})
+	assert.Equal(t, http.StatusUnsupportedMediaType, statusCode)
+}
@@ -0,0 +1,354 @@
+package httpserver
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+)
+
+const (
+	staticPath = "/static/"
+	metricPath = "/metric"
+
+	textPlain = "text/plain; charset=utf-8"
+
+	sortByValue = "value"
+
+	defaultRefresh = 10
+	maxRefresh     = 3600
+
+	sparklineWidth  = 600
+	sparklineHeight = 120
+	timeLayout      = "2006-01-02 15:04:05"
+)
+
+//go:embed web
+var webFS embed.FS
+
+var (
+	templates = template.Must(template.New("").Funcs(template.FuncMap{
+		"formatTime":  formatTime,
+		"isoTime":     isoTime,
+		"formatFloat": formatFloat,
+		"sortURL":     sortURL,
+		"sortMark":    sortMark,
+	}).ParseFS(webFS, "web/templates/*.html"))
+
+	refreshOptions = []int{0, 5, 10, 30, 60}
+)
+
+// staticHandler отдает встроенные в бинарник стили и скрипты дашборда.
+func staticHandler() http.Handler {
+	static, err := fs.Sub(webFS, "web/static")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix(staticPath, http.FileServer(http.FS(static)))
+}
+
+type dashboardRow struct {
+	UpdatedAt time.Time
+	Name      string
+	Value     string
+	URL       string
+	value     float64
+	Hidden    bool
+}
+
+type dashboardGroup struct {
+	Type string
+	Rows []dashboardRow
+}
+
+type dashboardPage struct {
+	GeneratedAt    time.Time
+	Title          string
+	Query          string
+	Sort           string
+	Groups         []dashboardGroup
+	RefreshOptions []int
+	Refresh        int
+	History        bool
+}
+
+type metricPage struct {
+	GeneratedAt time.Time
+	Title       string
+	Name        string
+	Type        string
+	Value       string
+	Min         string
+	Max         string
+	Sparkline   string
+	Points      []blModels.HistoryPoint
+	Width       int
+	Height      int
+	Refresh     int
+}
+
+// renderDashboard отдает таблицу метрик по типам. Поиск q, сортировка sort
+// (name, value, updated_at, с минусом - по убыванию) и период обновления refresh
+// передаются в запросе, поэтому страница работает и без скриптов.
+func (s *APIServer) renderDashboard(
+	w http.ResponseWriter,
+	r *http.Request,
+	gms []blModels.GaugeMetric,
+	cms []blModels.CounterMetric,
+) {
+	query := r.URL.Query()
+	page := dashboardPage{
+		GeneratedAt:    time.Now(),
+		Title:          "Metrics",
+		Query:          strings.TrimSpace(query.Get("q")),
+		Sort:           sortByName,
+		RefreshOptions: refreshOptions,
+		Refresh:        parseRefresh(query.Get("refresh")),
+		History:        s.bll.HistoryAvailable(),
+	}
+	switch sort := query.Get("sort"); strings.TrimPrefix(sort, "-") {
+	case sortByName, sortByValue, sortByUpdatedAt:
+		page.Sort = sort
+	}
+
+	gaugeRows := make([]dashboardRow, 0, len(gms))
+	for _, m := range gms {
+		gaugeRows = append(gaugeRows, dashboardRow{
+			UpdatedAt: m.UpdatedAt, Name: m.Name, Value: formatFloat(m.Value), value: m.Value,
+		})
+	}
+	counterRows := make([]dashboardRow, 0, len(cms))
+	for _, m := range cms {
+		counterRows = append(counterRows, dashboardRow{
+			UpdatedAt: m.UpdatedAt, Name: m.Name, Value: strconv.FormatInt(m.Value, 10), value: float64(m.Value),
+		})
+	}
+	needle := strings.ToLower(page.Query)
+	groups := []dashboardGroup{{Type: string(gauge), Rows: gaugeRows}, {Type: string(counter), Rows: counterRows}}
+	for _, group := range groups {
+		for i := range group.Rows {
+			row := &group.Rows[i]
+			row.URL = fmt.Sprintf("%s/%s/%s", metricPath, group.Type, url.PathEscape(row.Name))
+			row.Hidden = needle != "" && !strings.Contains(strings.ToLower(row.Name), needle)
+		}
+		sortRows(group.Rows, page.Sort)
+		page.Groups = append(page.Groups, group)
+	}
+
+	s.renderTemplate(w, "dashboard.html", page)
+}
+
+func sortRows(rows []dashboardRow, sort string) {
+	field, desc := strings.CutPrefix(sort, "-")
+	slices.SortStableFunc(rows, func(a, b dashboardRow) int {
+		var c int
+		switch field {
+		case sortByValue:
+			c = cmp.Compare(a.value, b.value)
+		case sortByUpdatedAt:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// getMetricPage отдает страницу метрики с графиком истории, без истории в хранилище страницы нет.
+func (s *APIServer) getMetricPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if !s.bll.HistoryAvailable() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		page := metricPage{
+			GeneratedAt: time.Now(),
+			Name:        chi.URLParam(r, string(mName)),
+			Type:        chi.URLParam(r, "mType"),
+			Width:       sparklineWidth,
+			Height:      sparklineHeight,
+			Refresh:     parseRefresh(r.URL.Query().Get("refresh")),
+		}
+		page.Title = page.Name + " - Metrics"
+
+		var err error
+		switch metricType(page.Type) {
+		case gauge:
+			var value float64
+			value, err = s.bll.GetGaugeMetric(ctx, page.Name)
+			page.Value = formatFloat(value)
+		case counter:
+			var value int64
+			value, err = s.bll.GetCounterMetric(ctx, page.Name)
+			page.Value = strconv.FormatInt(value, 10)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err == nil {
+			page.Points, err = s.bll.MetricHistory(ctx, page.Type, page.Name)
+		}
+		if err != nil {
+			if errors.Is(err, customerrors.ErrNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			s.logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if len(page.Points) > 0 {
+			var low, high float64
+			page.Sparkline, low, high = sparkline(page.Points, sparklineWidth, sparklineHeight)
+			page.Min, page.Max = formatFloat(low), formatFloat(high)
+		}
+		s.renderTemplate(w, "metric.html", page)
+	}
+}
+
+func (s *APIServer) renderTemplate(w http.ResponseWriter, name string, data any) {
+	// шаблон выполняется в буфер, чтобы при ошибке не отдать половину страницы.
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		s.logger.Errorf("failed to render %s: %s", name, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(contType, string(textHTML)+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		s.logger.Error(err)
+	}
+}
+
+// sparkline возвращает точки ломаной для svg polyline размером width x height
+// и границы значений. Постоянное значение рисуется линией посередине.
+func sparkline(points []blModels.HistoryPoint, width, height int) (line string, low, high float64) {
+	low, high = math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		low, high = min(low, p.Value), max(high, p.Value)
+	}
+	if len(points) == 1 {
+		return fmt.Sprintf("0,%d %d,%d", height/2, width, height/2), low, high
+	}
+	step := float64(width) / float64(len(points)-1)
+	coords := make([]string, 0, len(points))
+	for i, p := range points {
+		y := float64(height) / 2
+		if high > low {
+			y = float64(height) - (p.Value-low)/(high-low)*float64(height)
+		}
+		coords = append(coords, strconv.FormatFloat(float64(i)*step, 'f', 1, 64)+","+
+			strconv.FormatFloat(y, 'f', 1, 64))
+	}
+	return strings.Join(coords, " "), low, high
+}
+
+func parseRefresh(value string) int {
+	refresh, err := strconv.Atoi(value)
+	if err != nil || refresh < 0 {
+		return defaultRefresh
+	}
+	return min(refresh, maxRefresh)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(timeLayout)
+}
+
+func isoTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// sortURL возвращает ссылку на дашборд с сортировкой по field,
+// повторный выбор того же поля меняет направление.
+func sortURL(page dashboardPage, field string) string {
+	sort := field
+	if page.Sort == field {
+		sort = "-" + field
+	}
+	query := url.Values{"sort": {sort}, "refresh": {strconv.Itoa(page.Refresh)}}
+	if page.Query != "" {
+		query.Set("q", page.Query)
+	}
+	return "/?" + query.Encode()
+}
+
+func sortMark(sort, field string) string {
+	switch sort {
+	case field:
+		return " ▲"
+	case "-" + field:
+		return " ▼"
+	default:
+		return ""
+	}
+}
+
+// acceptsPlainText проверяет, что клиент предпочитает text/plain странице html.
+// Явный text/html сравнивается с text/plain по весу, иначе text/plain выигрывает у */* при равном весе.
+func acceptsPlainText(accept string) bool {
+	plainQ, htmlQ, anyQ := -1.0, -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case "text/plain":
+			plainQ = max(plainQ, q)
+		case string(textHTML):
+			htmlQ = max(htmlQ, q)
+		case "text/*", "*/*":
+			anyQ = max(anyQ, q)
+		}
+	}
+	if plainQ <= 0 {
+		return false
+	}
+	if htmlQ >= 0 {
+		return plainQ > htmlQ
+	}
+	return plainQ >= anyQ
+}
@@ -0,0 +1,175 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/business"
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/repo"
+)
+
+// historyBll подменяет историю метрик, в памяти ее нет.
+type historyBll struct {
+	Bll
+	points []blModels.HistoryPoint
+}
+
+func (b historyBll) HistoryAvailable() bool {
+	return true
+}
+
+func (b historyBll) MetricHistory(context.Context, string, string) ([]blModels.HistoryPoint, error) {
+	return b.points, nil
+}
+
+func initDashboardServer(t *testing.T, wrap func(Bll) Bll) *testHelper {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	var bll Bll = business.New(r, l)
+	if wrap != nil {
+		bll = wrap(bll)
+	}
+	server, err := New(config.NewSrvConfig(), bll, l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	th := &testHelper{ts: httptest.NewServer(server.Router)}
+	t.Cleanup(th.ts.Close)
+
+	body := `[{"type": "gauge", "id": "HeapAlloc", "value": 3},
+		{"type": "gauge", "id": "Alloc", "value": 10},
+		{"type": "counter", "id": "PollCount", "delta": 5}]`
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body), nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	return th
+}
+
+func TestDashboard__html(t *testing.T) {
+	th := initDashboardServer(t, nil)
+
+	resp, err := th.ts.Client().Get(th.ts.URL + "/?sort=-value&refresh=30")
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get(contType))
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	page := buf.String()
+	assert.Contains(t, page, `<noscript><meta http-equiv="refresh" content="30"></noscript>`)
+	assert.Contains(t, page, `<link rel="stylesheet" href="/static/style.css">`)
+	assert.Less(t, strings.Index(page, "<h2>gauge"), strings.Index(page, "<h2>counter"))
+	assert.Less(t, strings.Index(page, `data-name="Alloc"`), strings.Index(page, `data-name="HeapAlloc"`),
+		"sorted by value desc")
+	assert.NotContains(t, page, `href="/metric/`, "no history in memory")
+}
+
+func TestDashboard__search(t *testing.T) {
+	th := initDashboardServer(t, nil)
+
+	statusCode, page := th.Request(t, http.MethodGet, "/?q=heap", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.Contains(t, page, `<tr data-name="HeapAlloc" data-type="gauge">`)
+	assert.Contains(t, page, `<tr data-name="Alloc" data-type="gauge" hidden>`)
+	assert.Contains(t, page, `<tr data-name="PollCount" data-type="counter" hidden>`)
+	assert.Contains(t, page, `value="heap"`)
+}
+
+func TestDashboard__plainText(t *testing.T) {
+	th := initDashboardServer(t, nil)
+
+	tests := []struct {
+		name   string
+		accept string
+		plain  bool
+	}{
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", plain: false},
+		{name: "no accept", accept: "", plain: false},
+		{name: "any", accept: "*/*", plain: false},
+		{name: "plain", accept: "text/plain", plain: true},
+		{name: "plain over any", accept: "text/plain, */*", plain: true},
+		{name: "plain weighted", accept: "text/html;q=0.5, text/plain", plain: true},
+		{name: "html weighted", accept: "text/html, text/plain;q=0.5", plain: false},
+		{name: "plain disabled", accept: "text/plain;q=0", plain: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.plain, acceptsPlainText(tt.accept))
+		})
+	}
+
+	statusCode, body := th.Request(t, http.MethodGet, "/", nil, map[string]string{"Accept": "text/plain"})
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "Alloc: 10\r\nHeapAlloc: 3\r\nPollCount: 5\r\n", body)
+}
+
+func TestDashboard__static(t *testing.T) {
+	th := initDashboardServer(t, nil)
+
+	for path, ct := range map[string]string{
+		"/static/style.css":    "text/css; charset=utf-8",
+		"/static/dashboard.js": "text/javascript; charset=utf-8",
+	} {
+		resp, err := th.ts.Client().Get(th.ts.URL + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, ct, resp.Header.Get(contType), path)
+	}
+	statusCode, _ := th.Request(t, http.MethodGet, "/static/missing.css", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+}
+
+func TestMetricPage(t *testing.T) {
+	th := initDashboardServer(t, nil)
+	statusCode, _ := th.Request(t, http.MethodGet, "/metric/gauge/Alloc", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode, "no history in memory")
+
+	now := time.Now()
+	th = initDashboardServer(t, func(b Bll) Bll {
+		return historyBll{Bll: b, points: []blModels.HistoryPoint{
+			{CreatedAt: now.Add(-2 * time.Minute), Value: 4},
+			{CreatedAt: now.Add(-time.Minute), Value: 8},
+			{CreatedAt: now, Value: 10},
+		}}
+	})
+
+	statusCode, page := th.Request(t, http.MethodGet, "/", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.Contains(t, page, `<a href="/metric/gauge/Alloc">Alloc</a>`)
+
+	statusCode, page = th.Request(t, http.MethodGet, "/metric/gauge/Alloc", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.Contains(t, page, `<polyline points="0.0,120.0 300.0,40.0 600.0,0.0"/>`)
+	assert.Contains(t, page, "min 4 · max 10 · 3 points")
+
+	statusCode, _ = th.Request(t, http.MethodGet, "/metric/gauge/Missing", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, _ = th.Request(t, http.MethodGet, "/metric/unknown/Alloc", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+}
+
+func TestSparkline(t *testing.T) {
+	line, low, high := sparkline([]blModels.HistoryPoint{{Value: 2}}, 100, 20)
+	assert.Equal(t, "0,10 100,10", line)
+	assert.Equal(t, 2.0, low)
+	assert.Equal(t, 2.0, high)
+
+	line, _, _ = sparkline([]blModels.HistoryPoint{{Value: 1}, {Value: 1}, {Value: 1}}, 100, 20)
+	assert.Equal(t, "0.0,10.0 50.0,10.0 100.0,10.0", line)
+}
@@ -2,24 +2,23 @@ package httpserver
 
 import (
 	"bytes"
-	"crypto/rand"
-	"crypto/sha256"
 	"io"
 	"net/http"
 
 	rsaKeys "github.com/NStegura/metrics/internal/utils/rsa"
 )
 
+// decryptMiddleware расшифровывает тело ключом из заголовка X-Crypto-Key-Id,
+// без заголовка перебираются все ключи сервера.
 func (s *APIServer) decryptMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if s.cryptoKey != nil {
+		if s.keyring != nil {
 			bodyBytes, err := io.ReadAll(r.Body)
 			if err != nil {
 				http.Error(w, "failed to read body", http.StatusBadRequest)
 				return
 			}
-			decryptedMessage, err := rsaKeys.DecryptOAEP(
-				sha256.New(), rand.Reader, s.cryptoKey, bodyBytes, nil)
+			decryptedMessage, err := s.keyring.Decrypt(r.Header.Get(rsaKeys.KeyIDHeader), bodyBytes)
 			if err != nil {
 				http.Error(w, "failed to decrypt body", http.StatusBadRequest)
 				s.logger.Error(err)
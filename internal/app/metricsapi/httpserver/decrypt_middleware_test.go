@@ -0,0 +1,80 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/repo"
+	rsaKeys "github.com/NStegura/metrics/internal/utils/rsa"
+)
+
+func TestDecryptMiddleware(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "private_key.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type:  rsaKeys.PrivateKeyType,
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0600))
+
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	cfg := config.NewSrvConfig()
+	cfg.PrivateCryptoKeyPath = keyPath
+	server, err := New(cfg, business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+	th := &testHelper{ts: ts}
+
+	body := []byte(`{"value": 1.5, "type": "gauge", "id": "encrypted"}`)
+	envelope, err := rsaKeys.EncryptEnvelope(rand.Reader, &key.PublicKey, body)
+	require.NoError(t, err)
+	legacy, err := rsaKeys.EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, body, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		body       []byte
+		statusCode int
+	}{
+		{name: "envelope", body: envelope, statusCode: http.StatusOK},
+		{name: "legacy", body: legacy, statusCode: http.StatusOK},
+		{name: "plain", body: body, statusCode: http.StatusBadRequest},
+	}
+	for _, v := range tests {
+		statusCode, _ := th.Request(t, http.MethodPost, "/update/", bytes.NewReader(v.body), nil)
+		assert.Equal(t, v.statusCode, statusCode, v.name)
+	}
+
+	keyIDs := []struct {
+		keyID      string
+		statusCode int
+	}{
+		{keyID: rsaKeys.KeyID(&key.PublicKey), statusCode: http.StatusOK},
+		{keyID: "unknown", statusCode: http.StatusBadRequest},
+	}
+	for _, v := range keyIDs {
+		statusCode, _ := th.Request(t, http.MethodPost, "/update/", bytes.NewReader(envelope),
+			map[string]string{rsaKeys.KeyIDHeader: v.keyID})
+		assert.Equal(t, v.statusCode, statusCode, v.keyID)
+	}
+}
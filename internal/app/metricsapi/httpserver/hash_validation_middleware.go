@@ -2,17 +2,43 @@ package httpserver
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"io"
 	"net/http"
+
+	"github.com/NStegura/metrics/internal/utils/replay"
+	"github.com/NStegura/metrics/internal/utils/signing"
 )
 
+// replayCheckedKey отмечает в контексте запрос, прошедший проверку метки времени и nonce.
+type replayCheckedKey struct{}
+
+// replayRequired сообщает, что запросы на запись принимаются только подписанными
+// с меткой времени и nonce: включена защита от повторов и задан ключ подписи.
+func (s *APIServer) replayRequired() bool {
+	return s.replay != nil && (s.cfg.BodyHashKey != "" || s.signingKeys != nil)
+}
+
+// replayStatus возвращает 409 для повтора и 400 для устаревшей или неверной метки.
+func replayStatus(err error) int {
+	if errors.Is(err, replay.ErrReplayed) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// hashValidation проверяет HMAC и подпись ключом агента, если они переданы,
+// и при включенной защите отклоняет устаревшие и повторные запросы.
 func (s *APIServer) hashValidation(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if s.cfg.BodyHashKey != "" && r.Header.Get("HashSHA256") != "" {
-			hm := hmac.New(sha256.New, []byte(s.cfg.BodyHashKey))
+		hmacSigned := s.cfg.BodyHashKey != "" && r.Header.Get("HashSHA256") != ""
+		keyID := r.Header.Get(signing.KeyIDHeader)
+		keySigned := s.signingKeys != nil && keyID != ""
+		if hmacSigned || keySigned {
 			b, err := io.ReadAll(r.Body)
 			if err != nil {
 				s.logger.Errorf("failed to read body, err: %s", err)
@@ -28,16 +54,60 @@ func (s *APIServer) hashValidation(h http.Handler) http.Handler {
 			}
 			r.Body = io.NopCloser(bytes.NewBuffer(b))
 
-			hm.Write(b)
-			calcHash := hm.Sum(nil)
-			hashRequest, err := hex.DecodeString(r.Header.Get("HashSHA256"))
-			if err != nil {
-				s.logger.Errorf("failed to DecodeString, err: %s", err)
-				w.WriteHeader(http.StatusInternalServerError)
+			timestamp := r.Header.Get(replay.TimestampHeader)
+			nonce := r.Header.Get(replay.NonceHeader)
+			data := replay.SignedData(timestamp, nonce, b)
+			if hmacSigned {
+				hm := hmac.New(sha256.New, []byte(s.cfg.BodyHashKey))
+				hm.Write(data)
+				calcHash := hm.Sum(nil)
+				hashRequest, err := hex.DecodeString(r.Header.Get("HashSHA256"))
+				if err != nil {
+					s.logger.Errorf("failed to DecodeString, err: %s", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if !hmac.Equal(calcHash, hashRequest) {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+			}
+			if keySigned {
+				err = s.signingKeys.Verify(keyID, data, r.Header.Get(signing.SignatureHeader))
+				if err != nil {
+					s.logger.Warningf("rejected request signature, err: %s", err)
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				r = r.WithContext(signing.WithKeyID(r.Context(), keyID))
+			}
+			if s.replay != nil {
+				if err = s.replay.Check(timestamp, nonce); err != nil {
+					s.logger.Warningf("rejected signed request, err: %s", err)
+					w.WriteHeader(replayStatus(err))
+					return
+				}
+				r = r.WithContext(context.WithValue(r.Context(), replayCheckedKey{}, true))
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requireSignature пропускает запросы на запись только с проверенной подписью ключом агента,
+// если серверу передан каталог доверенных ключей, и только с проверенными меткой времени и nonce,
+// если включена защита от повторов: иначе запрос без подписи обходил бы проверку повторов.
+func (s *APIServer) requireSignature(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.signingKeys != nil {
+			if _, ok := signing.KeyIDFromContext(r.Context()); !ok {
+				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
-			if !hmac.Equal(calcHash, hashRequest) {
-				w.WriteHeader(http.StatusBadRequest)
+		}
+		if s.replayRequired() {
+			if checked, _ := r.Context().Value(replayCheckedKey{}).(bool); !checked {
+				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 		}
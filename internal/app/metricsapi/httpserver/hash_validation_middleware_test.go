@@ -0,0 +1,159 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/clients/base"
+	"github.com/NStegura/metrics/internal/repo"
+	"github.com/NStegura/metrics/internal/utils/replay"
+	"github.com/NStegura/metrics/internal/utils/signing"
+)
+
+func TestHashValidation__replay(t *testing.T) {
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	cfg := config.NewSrvConfig()
+	cfg.BodyHashKey = "secret"
+	cfg.ReplayWindow = config.Duration(time.Minute)
+	server, err := New(cfg, business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+	th := &testHelper{ts: ts}
+
+	bc, err := base.NewBaseClient(base.WithBodyHashKey("secret"))
+	require.NoError(t, err)
+	body := []byte(`[{"delta": 1, "type": "counter", "id": "replayed"}]`)
+	sign, _, err := bc.Sign(body)
+	require.NoError(t, err)
+	stale := sign
+	stale.Timestamp = replay.Timestamp(time.Now().Add(-time.Hour))
+	stale.Hash, _, err = bc.GenerateHMAC(replay.SignedData(stale.Timestamp, stale.Nonce, body))
+	require.NoError(t, err)
+
+	headers := func(s base.Signature) map[string]string {
+		return map[string]string{
+			"Content-Type":         "application/json",
+			"HashSHA256":           s.Hash,
+			replay.TimestampHeader: s.Timestamp,
+			replay.NonceHeader:     s.Nonce,
+		}
+	}
+	legacyHash, _, err := bc.GenerateHMAC(body)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		statusCode int
+	}{
+		{name: "signed", headers: headers(sign), statusCode: http.StatusOK},
+		{name: "replayed", headers: headers(sign), statusCode: http.StatusConflict},
+		{name: "stale", headers: headers(stale), statusCode: http.StatusBadRequest},
+		{
+			name:       "without nonce",
+			headers:    map[string]string{"Content-Type": "application/json", "HashSHA256": legacyHash},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unsigned",
+			headers:    map[string]string{"Content-Type": "application/json"},
+			statusCode: http.StatusUnauthorized,
+		},
+	}
+	for _, v := range tests {
+		statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewReader(body), v.headers)
+		assert.Equal(t, v.statusCode, statusCode, v.name)
+	}
+	statusCode, _ := th.Request(t, http.MethodPost, "/update/counter/replayed/1", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, statusCode, "unsigned update by path")
+
+	statusCode, resp := th.Request(t, http.MethodGet, "/value/counter/replayed", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "1", resp, "replayed delta must not be applied")
+}
+
+// writeSigningKey сохраняет ключ агента в файл, а публичный ключ в каталог доверенных ключей.
+func writeSigningKey(t *testing.T, keysDir, keyID string) string {
+	t.Helper()
+	key, err := signing.GenerateKey(signing.AlgEd25519)
+	require.NoError(t, err)
+	private, err := signing.MarshalPrivateKey(key)
+	require.NoError(t, err)
+	public, err := signing.MarshalPublicKey(key.Public())
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(t.TempDir(), keyID+".key")
+	require.NoError(t, os.WriteFile(privatePath, private, 0600))
+	if keysDir != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(keysDir, keyID+".pem"), public, 0600))
+	}
+	return privatePath
+}
+
+func TestHashValidation__signingKeys(t *testing.T) {
+	keysDir := t.TempDir()
+	trusted := writeSigningKey(t, keysDir, "agent-1")
+	untrusted := writeSigningKey(t, "", "agent-2")
+
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	cfg := config.NewSrvConfig()
+	cfg.SigningKeysDir = keysDir
+	server, err := New(cfg, business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	ts := httptest.NewServer(server.Router)
+	defer ts.Close()
+	th := &testHelper{ts: ts}
+
+	body := []byte(`[{"delta": 1, "type": "counter", "id": "signed"}]`)
+	headers := func(path, keyID string) map[string]string {
+		bc, err := base.NewBaseClient(base.WithSigningKey(path, keyID))
+		require.NoError(t, err)
+		sign, _, err := bc.Sign(body)
+		require.NoError(t, err)
+		return map[string]string{
+			"Content-Type":          "application/json",
+			signing.KeyIDHeader:     sign.KeyID,
+			signing.SignatureHeader: sign.Value,
+			replay.TimestampHeader:  sign.Timestamp,
+			replay.NonceHeader:      sign.Nonce,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		statusCode int
+	}{
+		{name: "trusted key", headers: headers(trusted, "agent-1"), statusCode: http.StatusOK},
+		{name: "unknown key", headers: headers(untrusted, "agent-2"), statusCode: http.StatusForbidden},
+		{name: "forged key id", headers: headers(untrusted, "agent-1"), statusCode: http.StatusForbidden},
+		{name: "unsigned", headers: map[string]string{"Content-Type": "application/json"}, statusCode: http.StatusUnauthorized},
+	}
+	for _, v := range tests {
+		statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewReader(body), v.headers)
+		assert.Equal(t, v.statusCode, statusCode, v.name)
+	}
+
+	statusCode, resp := th.Request(t, http.MethodGet, "/value/counter/signed", nil, nil)
+	require.Equal(t, http.StatusOK, statusCode, "reads do not require signature")
+	assert.Equal(t, "1", resp)
+}
@@ -1,11 +1,15 @@
 package httpserver
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/NStegura/metrics/internal/utils/certs"
 )
 
 type responseData struct {
@@ -32,6 +36,24 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
+// Flush и Hijack нужны потокам обновлений, которые пишут через логирующий writer.
+func (r *loggingResponseWriter) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hijack connection: %w", err)
+	}
+	r.responseData.status = http.StatusSwitchingProtocols
+	return conn, rw, nil
+}
+
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func (s *APIServer) requestLogger(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -46,12 +68,16 @@ func (s *APIServer) requestLogger(h http.Handler) http.Handler {
 
 		h.ServeHTTP(&lw, r)
 		duration := time.Since(start)
-		s.logger.WithFields(logrus.Fields{
+		fields := logrus.Fields{
 			"uri":      r.URL.Path,
 			"method":   r.Method,
 			"status":   responseData.status,
 			"duration": duration,
 			"size":     responseData.size,
-		}).Info()
+		}
+		if id, ok := certs.IdentityFromContext(r.Context()); ok {
+			fields["peer"] = id.String()
+		}
+		s.logger.WithFields(fields).Info()
 	})
 }
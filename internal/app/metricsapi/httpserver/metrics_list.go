@@ -0,0 +1,134 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NStegura/metrics/internal/app/metricsapi/models"
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+)
+
+const (
+	listPath = "/api/v1/metrics"
+	// NextCursorHeader передает курсор следующей страницы, на последней странице его нет.
+	NextCursorHeader = "X-Next-Cursor"
+
+	sortByName      = "name"
+	sortByUpdatedAt = "updated_at"
+)
+
+var errBadCursor = errors.New("invalid cursor")
+
+// listCursor - курсор в запросе. Порядок сортировки входит в курсор,
+// чтобы курсор не применился к списку в другом порядке.
+type listCursor struct {
+	Sort      string `json:"s"`
+	Name      string `json:"n"`
+	Type      string `json:"t"`
+	UpdatedAt int64  `json:"u,omitempty"`
+	Desc      bool   `json:"d,omitempty"`
+}
+
+func encodeCursor(filter blModels.ListFilter, c *blModels.Cursor) string {
+	lc := listCursor{Sort: filter.Sort, Desc: filter.Desc, Name: c.Name, Type: c.Type}
+	if filter.Sort == sortByUpdatedAt {
+		lc.UpdatedAt = c.UpdatedAt.UnixNano()
+	}
+	data, _ := json.Marshal(lc) //nolint:errchkjson // структура из простых полей
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(filter blModels.ListFilter, value string) (*blModels.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	var lc listCursor
+	if err = json.Unmarshal(data, &lc); err != nil {
+		return nil, errBadCursor
+	}
+	if lc.Sort != filter.Sort || lc.Desc != filter.Desc {
+		return nil, fmt.Errorf("%w: cursor belongs to another sort order", errBadCursor)
+	}
+	return &blModels.Cursor{Name: lc.Name, Type: lc.Type, UpdatedAt: time.Unix(0, lc.UpdatedAt).UTC()}, nil
+}
+
+// parseListFilter читает параметры списка: type, prefix, glob, regex,
+// sort (name, updated_at, с минусом - по убыванию), limit и cursor.
+func parseListFilter(query url.Values) (blModels.ListFilter, error) {
+	filter := blModels.ListFilter{
+		Type:   query.Get("type"),
+		Prefix: query.Get("prefix"),
+		Glob:   query.Get("glob"),
+		Regex:  query.Get("regex"),
+		Sort:   sortByName,
+	}
+	if sort := query.Get("sort"); sort != "" {
+		filter.Sort, filter.Desc = strings.CutPrefix(sort, "-")
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return filter, fmt.Errorf("invalid limit %q", limit)
+		}
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		var err error
+		if filter.After, err = decodeCursor(filter, cursor); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// listMetrics отдает страницу метрик в JSON, ссылка на следующую страницу - в Link и X-Next-Cursor.
+func (s *APIServer) listMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		filter, err := parseListFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		metrics, next, err := s.bll.ListMetrics(ctx, filter)
+		if err != nil {
+			if errors.Is(err, customerrors.ErrInvalidFilter) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp := make(models.MetricsList, 0, len(metrics))
+		for _, m := range metrics {
+			metric := models.Metrics{ID: m.Name, MType: m.Type}
+			if m.Type == string(counter) {
+				metric.Delta = &m.Delta
+			} else {
+				metric.Value = &m.Value
+			}
+			resp = append(resp, metric)
+		}
+		if next != nil {
+			cursor := encodeCursor(filter, next)
+			query := r.URL.Query()
+			query.Set("cursor", cursor)
+			w.Header().Set(NextCursorHeader, cursor)
+			w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, listPath, query.Encode()))
+		}
+		s.writeJSONResp(resp, w)
+	}
+}
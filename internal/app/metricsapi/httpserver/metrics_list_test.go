@@ -0,0 +1,155 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mailru/easyjson"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/app/metricsapi/models"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/repo"
+)
+
+func initListServer(t *testing.T) *testHelper {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	server, err := New(config.NewSrvConfig(), business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	th := &testHelper{ts: httptest.NewServer(server.Router)}
+	t.Cleanup(th.ts.Close)
+
+	// метрики обновляются по одной, чтобы время обновления различалось.
+	for _, body := range []string{
+		`[{"type": "gauge", "id": "HeapAlloc", "value": 3}]`,
+		`[{"type": "counter", "id": "PollCount", "delta": 5}]`,
+		`[{"type": "gauge", "id": "Alloc", "value": 1}]`,
+		`[{"type": "gauge", "id": "HeapSys", "value": 4}]`,
+		`[{"type": "gauge", "id": "cpu.1", "value": 0.5}]`,
+	} {
+		statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body), nil)
+		require.Equal(t, http.StatusOK, statusCode)
+		time.Sleep(time.Millisecond)
+	}
+	return th
+}
+
+func listNames(t *testing.T, th *testHelper, query url.Values) ([]string, http.Header) {
+	t.Helper()
+	resp, err := th.ts.Client().Get(th.ts.URL + listPath + "?" + query.Encode())
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get(contType))
+
+	var list models.MetricsList
+	require.NoError(t, easyjson.UnmarshalFromReader(resp.Body, &list))
+	names := make([]string, 0, len(list))
+	for _, m := range list {
+		names = append(names, m.ID)
+	}
+	return names, resp.Header
+}
+
+func TestListMetricsHandler__filters(t *testing.T) {
+	th := initListServer(t)
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{name: "all", query: url.Values{}, want: []string{"Alloc", "HeapAlloc", "HeapSys", "PollCount", "cpu.1"}},
+		{name: "type", query: url.Values{"type": {"counter"}}, want: []string{"PollCount"}},
+		{name: "prefix", query: url.Values{"prefix": {"Heap"}}, want: []string{"HeapAlloc", "HeapSys"}},
+		{name: "glob", query: url.Values{"glob": {"*Alloc"}}, want: []string{"Alloc", "HeapAlloc"}},
+		{name: "glob dot is literal", query: url.Values{"glob": {"cpu.?"}}, want: []string{"cpu.1"}},
+		{name: "regex", query: url.Values{"regex": {"^[A-H].*c$"}}, want: []string{"Alloc", "HeapAlloc"}},
+		{
+			name:  "combined",
+			query: url.Values{"type": {"gauge"}, "prefix": {"Heap"}, "glob": {"*Sys"}},
+			want:  []string{"HeapSys"},
+		},
+		{name: "desc", query: url.Values{"sort": {"-name"}, "prefix": {"Heap"}}, want: []string{"HeapSys", "HeapAlloc"}},
+		{
+			name:  "updated_at",
+			query: url.Values{"sort": {"updated_at"}},
+			want:  []string{"HeapAlloc", "PollCount", "Alloc", "HeapSys", "cpu.1"},
+		},
+		{name: "empty", query: url.Values{"prefix": {"Missing"}}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, _ := listNames(t, th, tt.query)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestListMetricsHandler__pagination(t *testing.T) {
+	th := initListServer(t)
+	// counter с именем gauge: курсор по одному имени пропустил бы одну из метрик.
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/",
+		bytes.NewBufferString(`[{"type": "counter", "id": "Alloc", "delta": 1}]`), nil)
+	require.Equal(t, http.StatusOK, statusCode)
+
+	for _, sort := range []string{"name", "-name", "updated_at", "-updated_at"} {
+		t.Run(sort, func(t *testing.T) {
+			all, headers := listNames(t, th, url.Values{"sort": {sort}})
+			require.Len(t, all, 6)
+			assert.Empty(t, headers.Get(NextCursorHeader))
+
+			var paged []string
+			query := url.Values{"sort": {sort}, "limit": {"2"}}
+			for range 5 {
+				names, headers := listNames(t, th, query)
+				paged = append(paged, names...)
+				cursor := headers.Get(NextCursorHeader)
+				if cursor == "" {
+					break
+				}
+				assert.Contains(t, headers.Get("Link"), `rel="next"`)
+				query.Set("cursor", cursor)
+			}
+			assert.Equal(t, all, paged)
+		})
+	}
+}
+
+func TestListMetricsHandler__badRequest(t *testing.T) {
+	th := initListServer(t)
+
+	_, headers := listNames(t, th, url.Values{"limit": {"1"}})
+	cursor := headers.Get(NextCursorHeader)
+	require.NotEmpty(t, cursor)
+
+	for _, query := range []url.Values{
+		{"type": {"histogram"}},
+		{"sort": {"value"}},
+		{"limit": {"0"}},
+		{"limit": {"100000"}},
+		{"glob": {"[a"}},
+		{"regex": {"(a"}},
+		{"regex": {strings.Repeat("a", 300)}},
+		{"cursor": {"%%%"}},
+		{"cursor": {cursor}, "sort": {"-name"}},
+	} {
+		statusCode, _ := th.Request(t, http.MethodGet, listPath+"?"+query.Encode(), nil, nil)
+		assert.Equal(t, http.StatusBadRequest, statusCode, query.Encode())
+	}
+}
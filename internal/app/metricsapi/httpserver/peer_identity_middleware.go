@@ -0,0 +1,18 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/NStegura/metrics/internal/utils/certs"
+)
+
+// peerIdentity сохраняет в контексте запроса CN и SAN сертификата клиента при mTLS.
+func (s *APIServer) peerIdentity(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			id := certs.IdentityFromCert(r.TLS.PeerCertificates[0])
+			r = r.WithContext(certs.WithIdentity(r.Context(), id))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,189 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	blModels "github.com/NStegura/metrics/internal/business/models"
+)
+
+const (
+	// prometheusPath - путь, который опрашивает Prometheus.
+	prometheusPath = "/metrics"
+
+	textFormat        = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsFormat = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	openMetricsType   = "application/openmetrics-text"
+	counterSuffix     = "_total"
+)
+
+// promSample - метрика, подготовленная к выводу.
+type promSample struct {
+	name  string
+	mtype metricType
+	value float64
+}
+
+// getPrometheusMetrics отдает все метрики в текстовом формате Prometheus
+// или в OpenMetrics, если клиент предпочитает его в Accept.
+func (s *APIServer) getPrometheusMetrics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		gms, cms, err := s.bll.GetAllMetrics(ctx)
+		if err != nil {
+			s.logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		samples := s.promSamples(gms, cms)
+		openMetrics := acceptsOpenMetrics(r.Header.Get("Accept"))
+		var sb strings.Builder
+		if openMetrics {
+			w.Header().Set(contType, openMetricsFormat)
+			writeOpenMetrics(&sb, samples)
+		} else {
+			w.Header().Set(contType, textFormat)
+			writePrometheusText(&sb, samples)
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err = io.WriteString(w, sb.String()); err != nil {
+			s.logger.Error(err)
+		}
+	}
+}
+
+// promSamples приводит имена к формату Prometheus и сортирует метрики.
+// Метрика, у которой имя семейства или выводимое имя значения (у counter с суффиксом _total)
+// совпало с уже выведенным, отбрасывается, кроме первой по алфавиту:
+// повтор имени делает весь ответ невалидным для Prometheus.
+func (s *APIServer) promSamples(gms []blModels.GaugeMetric, cms []blModels.CounterMetric) []promSample {
+	samples := make([]promSample, 0, len(gms)+len(cms))
+	for _, m := range gms {
+		samples = append(samples, promSample{name: m.Name, mtype: gauge, value: m.Value})
+	}
+	for _, m := range cms {
+		samples = append(samples, promSample{name: m.Name, mtype: counter, value: float64(m.Value)})
+	}
+	slices.SortFunc(samples, func(a, b promSample) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	seen := make(map[string]string, len(samples))
+	result := samples[:0]
+	for _, sample := range samples {
+		name := promName(sample.name)
+		if sample.mtype == counter {
+			name = strings.TrimSuffix(name, counterSuffix)
+		}
+		exposed := name
+		if sample.mtype == counter {
+			exposed += counterSuffix
+		}
+		first, ok := seen[name]
+		if !ok {
+			first, ok = seen[exposed]
+		}
+		if ok {
+			s.logger.Warningf("metric %q skipped in %s: name clashes with %q", sample.name, prometheusPath, first)
+			continue
+		}
+		seen[name], seen[exposed] = sample.name, sample.name
+		sample.name = name
+		result = append(result, sample)
+	}
+	return result
+}
+
+// writePrometheusText пишет метрики в текстовом формате 0.0.4.
+func writePrometheusText(sb *strings.Builder, samples []promSample) {
+	for _, sample := range samples {
+		name := sample.name
+		if sample.mtype == counter {
+			name += counterSuffix
+		}
+		fmt.Fprintf(sb, "# TYPE %s %s\n%s %s\n", name, sample.mtype, name, promValue(sample.value))
+	}
+}
+
+// writeOpenMetrics пишет метрики в OpenMetrics 1.0: у счетчика семейство без суффикса _total,
+// а у значения суффикс обязателен. Ответ заканчивается маркером # EOF.
+func writeOpenMetrics(sb *strings.Builder, samples []promSample) {
+	for _, sample := range samples {
+		name := sample.name
+		if sample.mtype == counter {
+			name += counterSuffix
+		}
+		fmt.Fprintf(sb, "# TYPE %s %s\n%s %s\n", sample.name, sample.mtype, name, promValue(sample.value))
+	}
+	sb.WriteString("# EOF\n")
+}
+
+func promValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// promName приводит имя к [a-zA-Z_:][a-zA-Z0-9_:]*, недопустимые символы заменяются на _.
+func promName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	var sb strings.Builder
+	sb.Grow(len(name))
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// acceptsOpenMetrics проверяет, что OpenMetrics в Accept имеет вес не меньше текстового формата.
+func acceptsOpenMetrics(accept string) bool {
+	openMetricsQ, textQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case openMetricsType:
+			openMetricsQ = max(openMetricsQ, q)
+		case "text/plain", "text/*", "*/*":
+			textQ = max(textQ, q)
+		}
+	}
+	return openMetricsQ > 0 && openMetricsQ >= textQ
+}
@@ -0,0 +1,148 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/business"
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/repo"
+)
+
+func initPrometheusServer(t *testing.T, cfg *config.SrvConfig) *testHelper {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	server, err := New(cfg, business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	th := &testHelper{ts: httptest.NewServer(server.Router)}
+	t.Cleanup(th.ts.Close)
+
+	body := `[{"type": "gauge", "id": "Alloc", "value": 1.5},` +
+		`{"type": "gauge", "id": "cpu.usage-1", "value": 0.25},` +
+		`{"type": "gauge", "id": "9lives", "value": 9},` +
+		`{"type": "counter", "id": "PollCount", "delta": 3},` +
+		`{"type": "counter", "id": "requests_total", "delta": 7}]`
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body),
+		map[string]string{"X-Real-IP": "10.0.0.1"})
+	require.Equal(t, http.StatusOK, statusCode)
+	return th
+}
+
+func TestPrometheusHandler__text(t *testing.T) {
+	th := initPrometheusServer(t, config.NewSrvConfig())
+
+	resp, err := th.ts.Client().Get(th.ts.URL + prometheusPath)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var body bytes.Buffer
+	_, err = body.ReadFrom(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, textFormat, resp.Header.Get(contType))
+	assert.Equal(t, "# TYPE _9lives gauge\n_9lives 9\n"+
+		"# TYPE Alloc gauge\nAlloc 1.5\n"+
+		"# TYPE PollCount_total counter\nPollCount_total 3\n"+
+		"# TYPE cpu_usage_1 gauge\ncpu_usage_1 0.25\n"+
+		"# TYPE requests_total counter\nrequests_total 7\n", body.String())
+}
+
+func TestPrometheusHandler__openMetrics(t *testing.T) {
+	th := initPrometheusServer(t, config.NewSrvConfig())
+
+	statusCode, body := th.Request(t, http.MethodGet, prometheusPath, nil, map[string]string{
+		"Accept": "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+	})
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "# TYPE _9lives gauge\n_9lives 9\n"+
+		"# TYPE Alloc gauge\nAlloc 1.5\n"+
+		"# TYPE PollCount counter\nPollCount_total 3\n"+
+		"# TYPE cpu_usage_1 gauge\ncpu_usage_1 0.25\n"+
+		"# TYPE requests counter\nrequests_total 7\n"+
+		"# EOF\n", body)
+}
+
+func TestPrometheusHandler__trustedSubnet(t *testing.T) {
+	tests := []struct {
+		name   string
+		public bool
+		ip     string
+		want   int
+	}{
+		{name: "trusted", ip: "10.0.0.1", want: http.StatusOK},
+		{name: "untrusted", ip: "192.168.0.1", want: http.StatusForbidden},
+		{name: "untrusted public", public: true, ip: "192.168.0.1", want: http.StatusOK},
+		{name: "no ip public", public: true, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewSrvConfig()
+			cfg.TrustedSubnet = "10.0.0.0/24"
+			cfg.PrometheusPublic = tt.public
+			th := initPrometheusServer(t, cfg)
+
+			headers := map[string]string{}
+			if tt.ip != "" {
+				headers["X-Real-IP"] = tt.ip
+			}
+			statusCode, _ := th.Request(t, http.MethodGet, prometheusPath, nil, headers)
+			assert.Equal(t, tt.want, statusCode)
+
+			// исключение касается только /metrics.
+			statusCode, _ = th.Request(t, http.MethodGet, "/", nil, headers)
+			assert.Equal(t, tt.ip == "10.0.0.1", statusCode == http.StatusOK)
+		})
+	}
+}
+
+func TestAcceptsOpenMetrics(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: false},
+		{accept: "*/*", want: false},
+		{accept: "text/plain;version=0.0.4", want: false},
+		{accept: "application/openmetrics-text;version=1.0.0", want: true},
+		{accept: "application/openmetrics-text;q=0.3,text/plain;q=0.5", want: false},
+		{accept: "application/openmetrics-text;q=0,*/*", want: false},
+		{accept: "text/plain;q=0.5, application/openmetrics-text; version=0.0.1", want: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, acceptsOpenMetrics(tt.accept), tt.accept)
+	}
+}
+
+func TestPromSamples__nameClash(t *testing.T) {
+	s := &APIServer{logger: logrus.New()}
+	samples := s.promSamples(
+		[]blModels.GaugeMetric{{Name: "cpu.usage", Value: 1}, {Name: "cpu_usage", Value: 2}},
+		[]blModels.CounterMetric{{Name: "hits", Value: 3}, {Name: "hits_total", Value: 4}},
+	)
+	assert.Equal(t, []promSample{
+		{name: "cpu_usage", mtype: gauge, value: 1},
+		{name: "hits", mtype: counter, value: 3},
+	}, samples)
+}
+
+func TestPromSamples__exposedNameClash(t *testing.T) {
+	s := &APIServer{logger: logrus.New()}
+	samples := s.promSamples(
+		[]blModels.GaugeMetric{{Name: "foo_total", Value: 1}},
+		[]blModels.CounterMetric{{Name: "foo", Value: 2}},
+	)
+	assert.Equal(t, []promSample{{name: "foo", mtype: counter, value: 2}}, samples)
+}
@@ -2,7 +2,7 @@ package httpserver
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -11,7 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/NStegura/metrics/internal/utils/certs"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
+	"github.com/NStegura/metrics/internal/utils/replay"
 	rsaKey "github.com/NStegura/metrics/internal/utils/rsa"
+	"github.com/NStegura/metrics/internal/utils/signing"
 
 	"github.com/NStegura/metrics/config"
 
@@ -45,8 +49,12 @@ const (
 // APIServer хранит сущности для работы сервера.
 type APIServer struct {
 	cfg           *config.SrvConfig
-	cryptoKey     *rsa.PrivateKey
+	keyring       *rsaKey.Keyring
+	tlsConfig     *tls.Config
 	trustedSubnet *net.IPNet
+	replay        *replay.Guard
+	signingKeys   *signing.KeyDir
+	adminToken    string
 	bll           Bll
 	Router        *chi.Mux
 
@@ -55,26 +63,57 @@ type APIServer struct {
 
 func New(config *config.SrvConfig, bll Bll, logger *logrus.Logger) (*APIServer, error) {
 	var (
-		cryptoKey *rsa.PrivateKey
+		keyring   *rsaKey.Keyring
+		tlsConfig *tls.Config
 		subnet    *net.IPNet
+		guard     *replay.Guard
+		keys      *signing.KeyDir
 		err       error
 	)
-	if config.PrivateCryptoKeyPath != "" {
-		cryptoKey, err = rsaKey.ReadPrivateKey(config.PrivateCryptoKeyPath)
+	adminToken, err := config.AdminCredential()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load admin token: %w", err)
+	}
+	if config.DecryptionEnabled() {
+		var passphrase []byte
+		if passphrase, err = config.CryptoPassphrase(); err != nil {
+			return nil, fmt.Errorf("failed to load crypto key passphrase: %w", err)
+		}
+		keyring, err = rsaKey.NewKeyring(config.PrivateCryptoKeyPath, config.CryptoKeysDir, passphrase)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load private key: %w", err)
 		}
 	}
+	if config.TLSEnabled() {
+		tlsConfig, err = certs.ServerConfig(
+			config.TLSCertPath, config.TLSKeyPath, config.TLSCAPath, config.TLSClientAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init tls: %w", err)
+		}
+	}
 	if config.TrustedSubnet != "" {
 		_, subnet, err = net.ParseCIDR(config.TrustedSubnet)
 		if err != nil {
 			return nil, fmt.Errorf("invalid trusted subnet: %w", err)
 		}
 	}
+	if config.ReplayProtection() {
+		guard = replay.NewGuard(time.Duration(config.ReplayWindow), config.ReplayCacheSize)
+	}
+	if config.SigningKeysDir != "" {
+		keys, err = signing.LoadKeyDir(config.SigningKeysDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing keys: %w", err)
+		}
+	}
 	return &APIServer{
 		cfg:           config,
-		cryptoKey:     cryptoKey,
+		keyring:       keyring,
+		tlsConfig:     tlsConfig,
 		trustedSubnet: subnet,
+		replay:        guard,
+		signingKeys:   keys,
+		adminToken:    adminToken,
 		bll:           bll,
 		Router:        chi.NewRouter(),
 		logger:        logger,
@@ -85,22 +124,39 @@ func New(config *config.SrvConfig, bll Bll, logger *logrus.Logger) (*APIServer,
 func (s *APIServer) Start() error {
 	s.ConfigRouter()
 
-	s.logger.Infof("starting APIServer %s", s.cfg.BindAddr)
-	if err := http.ListenAndServe(s.cfg.BindAddr, s.Router); err != nil {
+	srv := &http.Server{
+		Addr:              s.cfg.BindAddr,
+		Handler:           s.Router,
+		TLSConfig:         s.tlsConfig,
+		ReadHeaderTimeout: timeout,
+	}
+
+	var err error
+	if s.tlsConfig != nil {
+		s.logger.Infof("starting APIServer with TLS %s", s.cfg.BindAddr)
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		s.logger.Infof("starting APIServer %s", s.cfg.BindAddr)
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
 	return nil
 }
 
 func (s *APIServer) ConfigRouter() {
+	s.Router.Use(s.peerIdentity)
 	s.Router.Use(s.requestLogger)
 	s.Router.Use(s.trustedSubnetMiddleware)
-	s.Router.Use(s.gzipMiddleware)
+	s.Router.Use(s.compressMiddleware)
 	s.Router.Use(s.decryptMiddleware)
 	s.Router.Use(s.hashValidation)
 
 	s.Router.Get(`/`, s.getAllMetrics())
-	s.Router.Post(`/updates/`, s.updateAllMetrics())
+	s.Router.Get(metricPath+`/{mType}/{mName}`, s.getMetricPage())
+	s.Router.Handle(staticPath+`*`, staticHandler())
+	s.Router.With(s.requireSignature).Post(`/updates/`, s.updateAllMetrics())
 
 	s.Router.Route(`/value`, func(r chi.Router) {
 		r.Post(`/`, s.getMetric())
@@ -113,6 +169,7 @@ func (s *APIServer) ConfigRouter() {
 	})
 
 	s.Router.Route(`/update`, func(r chi.Router) {
+		r.Use(s.requireSignature)
 		r.Post(`/`, s.updateMetric())
 		r.Post(`/{mType}/{mName}/{mValue}`, func(w http.ResponseWriter, _ *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
@@ -126,6 +183,11 @@ func (s *APIServer) ConfigRouter() {
 	})
 
 	s.Router.Get(`/ping`, s.ping())
+	s.Router.Get(prometheusPath, s.getPrometheusMetrics())
+	s.Router.Get(listPath, s.listMetrics())
+	s.Router.Get(streamPath, s.streamSSE())
+	s.Router.Get(wsPath, s.streamWebSocket())
+	s.configAdminRouter()
 }
 
 func (s *APIServer) getAllMetrics() http.HandlerFunc {
@@ -143,19 +205,28 @@ func (s *APIServer) getAllMetrics() http.HandlerFunc {
 			return
 		}
 
-		var sb strings.Builder
-
-		for _, m := range gms {
-			sb.WriteString(fmt.Sprintf("%s: %v\r\n", m.Name, m.Value))
-		}
-		for _, m := range cms {
-			sb.WriteString(fmt.Sprintf("%s: %v\r\n", m.Name, m.Value))
-		}
-		w.Header().Set(contType, string(textHTML))
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte(sb.String())); err != nil {
-			s.logger.Error(err)
+		if acceptsPlainText(r.Header.Get("Accept")) {
+			s.writePlainMetrics(w, gms, cms)
+			return
 		}
+		s.renderDashboard(w, r, gms, cms)
+	}
+}
+
+// writePlainMetrics отдает метрики строками "имя: значение".
+func (s *APIServer) writePlainMetrics(w http.ResponseWriter, gms []blModels.GaugeMetric, cms []blModels.CounterMetric) {
+	var sb strings.Builder
+
+	for _, m := range gms {
+		sb.WriteString(fmt.Sprintf("%s: %v\r\n", m.Name, m.Value))
+	}
+	for _, m := range cms {
+		sb.WriteString(fmt.Sprintf("%s: %v\r\n", m.Name, m.Value))
+	}
+	w.Header().Set(contType, textPlain)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(sb.String())); err != nil {
+		s.logger.Error(err)
 	}
 }
 
@@ -164,6 +235,14 @@ func (s *APIServer) updateAllMetrics() http.HandlerFunc {
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 
+		batchID := r.Header.Get(idempotency.Header)
+		if batchID != "" {
+			if err := idempotency.Validate(batchID); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		var metrics models.MetricsList
 
 		if err := easyjson.UnmarshalFromReader(r.Body, &metrics); err != nil {
@@ -171,43 +250,53 @@ func (s *APIServer) updateAllMetrics() http.HandlerFunc {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		mode := blModels.BatchMode(r.URL.Query().Get("mode"))
+		if mode == "" {
+			mode = blModels.BatchAtomic
+		}
+		items := make([]blModels.BatchItem, 0, len(metrics))
 		for _, metric := range metrics {
-			switch metric.MType {
-			case string(gauge):
-				if metric.Value == nil {
-					http.Error(w, "gauge metric value null", http.StatusBadRequest)
-					return
-				}
-				err := s.bll.UpdateGaugeMetric(
-					ctx,
-					blModels.GaugeMetric{
-						Name: metric.ID, Type: metric.MType, Value: *metric.Value,
-					})
-				if err != nil {
-					w.WriteHeader(http.StatusUnprocessableEntity)
-					return
-				}
-			case string(counter):
-				if metric.Delta == nil {
-					http.Error(w, "counter metric value null", http.StatusBadRequest)
-					return
-				}
-				err := s.bll.UpdateCounterMetric(
-					ctx,
-					blModels.CounterMetric{
-						Name: metric.ID, Type: metric.MType, Value: *metric.Delta,
-					})
-				if err != nil {
-					w.WriteHeader(http.StatusUnprocessableEntity)
-					return
-				}
-			default:
-				http.Error(w, "unknown metric type", http.StatusBadRequest)
-				return
+			items = append(items, blModels.BatchItem{
+				Value: metric.Value, Delta: metric.Delta, Name: metric.ID, Type: metric.MType,
+			})
+		}
+
+		result, err := s.bll.UpdateBatch(ctx, batchID, mode, items)
+		code := http.StatusOK
+		if err != nil {
+			if errors.Is(err, customerrors.ErrInvalidBatch) {
+				code = http.StatusBadRequest
+			} else {
+				s.logger.Error(err)
+				code = http.StatusUnprocessableEntity
 			}
 		}
-		w.WriteHeader(http.StatusOK)
+		if result.Replayed {
+			w.Header().Set(idempotency.ReplayedHeader, "true")
+		}
+		s.writeJSONStatus(batchReport(result), code, w)
+	}
+}
+
+// batchReport переводит результат пакета в отчет для клиента.
+func batchReport(result blModels.BatchResult) models.BatchReport {
+	report := models.BatchReport{
+		Mode:     string(result.Mode),
+		Results:  make([]models.BatchItemResult, 0, len(result.Items)),
+		Applied:  result.Applied,
+		Failed:   result.Failed,
+		Replayed: result.Replayed,
+	}
+	for _, item := range result.Items {
+		report.Results = append(report.Results, models.BatchItemResult{
+			ID:     item.Name,
+			MType:  item.Type,
+			Status: string(item.Status),
+			Error:  item.Error,
+			Index:  item.Index,
+		})
 	}
+	return report
 }
 
 func (s *APIServer) getCounterMetric() http.HandlerFunc {
@@ -257,7 +346,7 @@ func (s *APIServer) updateCounterMetric() http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		err = s.bll.UpdateCounterMetric(ctx, blModels.CounterMetric(cm))
+		err = s.bll.UpdateCounterMetric(ctx, blModels.CounterMetric{Name: cm.Name, Type: cm.Type, Value: cm.Value})
 		if err != nil {
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			return
@@ -313,7 +402,7 @@ func (s *APIServer) updateGaugeMetric() http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		err = s.bll.UpdateGaugeMetric(ctx, blModels.GaugeMetric(gm))
+		err = s.bll.UpdateGaugeMetric(ctx, blModels.GaugeMetric{Name: gm.Name, Type: gm.Type, Value: gm.Value})
 		if err != nil {
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			return
@@ -431,6 +520,10 @@ func (s *APIServer) ping() http.HandlerFunc {
 }
 
 func (s *APIServer) writeJSONResp(resp easyjson.Marshaler, w http.ResponseWriter) {
+	s.writeJSONStatus(resp, http.StatusOK, w)
+}
+
+func (s *APIServer) writeJSONStatus(resp easyjson.Marshaler, code int, w http.ResponseWriter) {
 	w.Header().Set(contType, "application/json")
 
 	jsonResp, err := easyjson.Marshal(resp)
@@ -438,7 +531,7 @@ func (s *APIServer) writeJSONResp(resp easyjson.Marshaler, w http.ResponseWriter
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(code)
 	if _, err := w.Write(jsonResp); err != nil {
 		s.logger.Error(err)
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/mailru/easyjson"
 
 	"github.com/NStegura/metrics/internal/app/metricsapi/models"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
 
 	"github.com/golang/mock/gomock"
 
@@ -506,6 +507,88 @@ func TestUpdateAllMetricsHandler(t *testing.T) {
 	}
 }
 
+func TestUpdateAllMetricsHandler__idempotencyKey(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	body := `[{"type": "counter", "id": "IdempotentCounter", "delta": 2}]`
+	send := func(key string) (int, http.Header) {
+		req, err := http.NewRequest(http.MethodPost, th.ts.URL+"/updates/", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set(idempotency.Header, key)
+		resp, err := th.ts.Client().Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode, resp.Header
+	}
+
+	statusCode, headers := send("batch-1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Empty(t, headers.Get(idempotency.ReplayedHeader))
+
+	statusCode, headers = send("batch-1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "true", headers.Get(idempotency.ReplayedHeader))
+
+	statusCode, _ = send("batch-2")
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	statusCode, _ = send("bad key")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	statusCode, resp := th.Request(t, http.MethodGet, "/value/counter/IdempotentCounter", nil, nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "4", resp)
+}
+
+func TestUpdateAllMetricsHandler__invalidBatchNotApplied(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	body := `[{"type": "counter", "id": "PartialCounter", "delta": 2},{"type": "gauge", "id": "PartialGauge"}]`
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body), nil)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	statusCode, _ = th.Request(t, http.MethodGet, "/value/counter/PartialCounter", nil, nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+}
+
+func TestUpdateAllMetricsHandler__modes(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	body := `[{"type": "counter", "id": "ModeCounter", "delta": 2},{"type": "gauge", "id": "ModeGauge"}]`
+	statusCode, resp := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body), nil)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	var report models.BatchReport
+	require.NoError(t, easyjson.Unmarshal([]byte(resp), &report))
+	assert.Equal(t, "atomic", report.Mode)
+	assert.Equal(t, 0, report.Applied)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Results, 2)
+	assert.Equal(t, "skipped", report.Results[0].Status)
+	assert.Equal(t, "failed", report.Results[1].Status)
+	assert.Equal(t, "gauge metric value null", report.Results[1].Error)
+
+	statusCode, resp = th.Request(t, http.MethodPost, "/updates/?mode=partial", bytes.NewBufferString(body), nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+	report = models.BatchReport{}
+	require.NoError(t, easyjson.Unmarshal([]byte(resp), &report))
+	assert.Equal(t, "partial", report.Mode)
+	assert.Equal(t, 1, report.Applied)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Results, 2)
+	assert.Equal(t, models.BatchItemResult{ID: "ModeCounter", MType: "counter", Status: "applied"}, report.Results[0])
+	assert.Equal(t, "failed", report.Results[1].Status)
+
+	statusCode, resp = th.Request(t, http.MethodGet, "/value/counter/ModeCounter", nil, nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "2", resp)
+
+	statusCode, _ = th.Request(t, http.MethodPost, "/updates/?mode=all", bytes.NewBufferString(body), nil)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
 func TestPingHandler__ok(t *testing.T) {
 	th := initTestHelper(t)
 	defer th.finish()
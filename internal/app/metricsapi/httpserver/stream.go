@@ -0,0 +1,208 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mailru/easyjson"
+
+	"github.com/NStegura/metrics/internal/app/metricsapi/models"
+	"github.com/NStegura/metrics/internal/business/events"
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+)
+
+const (
+	// streamPath - поток обновлений в формате Server-Sent Events.
+	streamPath = "/api/v1/stream"
+	// wsPath - поток обновлений через WebSocket.
+	wsPath = "/api/v1/ws"
+
+	eventStream = "text/event-stream"
+
+	metricEvent  = "metric"
+	droppedEvent = "dropped"
+
+	streamKeepAlive = 15 * time.Second
+)
+
+// isStreamPath проверяет, что запрос держит соединение открытым для потока обновлений.
+func isStreamPath(path string) bool {
+	return path == streamPath || path == wsPath
+}
+
+// subscribe подписывает на обновления метрик по параметрам type, prefix, glob и regex.
+func (s *APIServer) subscribe(w http.ResponseWriter, r *http.Request) (*events.Subscription, bool) {
+	query := r.URL.Query()
+	sub, err := s.bll.Subscribe(blModels.ListFilter{
+		Type:   query.Get("type"),
+		Prefix: query.Get("prefix"),
+		Glob:   query.Get("glob"),
+		Regex:  query.Get("regex"),
+	})
+	if err != nil {
+		if errors.Is(err, customerrors.ErrInvalidFilter) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		s.logger.Error(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	return sub, true
+}
+
+// streamEvents переводит накопленные события подписки в сообщения потока,
+// отброшенные события идут первыми.
+func streamEvents(sub *events.Subscription) []models.StreamEvent {
+	evs, dropped := sub.Next()
+	messages := make([]models.StreamEvent, 0, len(evs)+1)
+	if dropped > 0 {
+		messages = append(messages, models.StreamEvent{Event: droppedEvent, Dropped: dropped})
+	}
+	for _, e := range evs {
+		metric := &models.Metrics{ID: e.Name, MType: e.Type}
+		if e.Type == string(counter) {
+			metric.Delta = &e.Delta
+		} else {
+			metric.Value = &e.Value
+		}
+		messages = append(messages, models.StreamEvent{Event: metricEvent, Metric: metric})
+	}
+	return messages
+}
+
+// streamSSE отправляет обновления метрик как Server-Sent Events.
+// Медленный клиент не тормозит прием метрик: пока он читает,
+// значения метрики схлопываются до последнего, а лишние события отбрасываются.
+func (s *APIServer) streamSSE() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sub, ok := s.subscribe(w, r)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		rc := http.NewResponseController(w)
+		w.Header().Set(contType, eventStream)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if err := writeSSE(w, rc, ": connected\n\n"); err != nil {
+			s.logger.Warningf("failed to start event stream: %s", err)
+			return
+		}
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			var (
+				sb  strings.Builder
+				err error
+			)
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				sb.WriteString(": ping\n\n")
+			case <-sub.Ready():
+				for _, msg := range streamEvents(sub) {
+					data, err := easyjson.Marshal(msg)
+					if err != nil {
+						s.logger.Errorf("failed to marshal stream event: %s", err)
+						continue
+					}
+					fmt.Fprintf(&sb, "event: %s\ndata: %s\n\n", msg.Event, data)
+				}
+			}
+			if err = writeSSE(w, rc, sb.String()); err != nil {
+				s.logger.Debugf("event stream closed: %s", err)
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(w io.Writer, rc *http.ResponseController, data string) error {
+	if _, err := io.WriteString(w, data); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	if err := rc.Flush(); err != nil {
+		return fmt.Errorf("failed to flush event: %w", err)
+	}
+	return nil
+}
+
+// streamWebSocket отправляет обновления метрик текстовыми сообщениями WebSocket,
+// сообщения клиента, кроме закрытия, игнорируются.
+func (s *APIServer) streamWebSocket() http.HandlerFunc {
+	upgrader := websocket.Upgrader{}
+	return func(w http.ResponseWriter, r *http.Request) {
+		sub, ok := s.subscribe(w, r)
+		if !ok {
+			return
+		}
+		defer sub.Close()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade сам отвечает клиенту ошибкой.
+			s.logger.Warningf("failed to upgrade to websocket: %s", err)
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-closed:
+				return
+			case <-keepAlive.C:
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout))
+			case <-sub.Ready():
+				for _, msg := range streamEvents(sub) {
+					if err = s.writeWebSocket(conn, msg); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				s.logger.Debugf("websocket stream closed: %s", err)
+				return
+			}
+		}
+	}
+}
+
+// writeWebSocket пишет сообщение, клиент, который не читает дольше timeout, отключается.
+func (s *APIServer) writeWebSocket(conn *websocket.Conn, msg models.StreamEvent) error {
+	data, err := easyjson.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream event: %w", err)
+	}
+	if err = conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+	if err = conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
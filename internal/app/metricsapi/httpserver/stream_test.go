@@ -0,0 +1,121 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mailru/easyjson"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/app/metricsapi/models"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/repo"
+)
+
+func initStreamServer(t *testing.T) *testHelper {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	server, err := New(config.NewSrvConfig(), business.New(r, l), l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	th := &testHelper{ts: httptest.NewServer(server.Router)}
+	t.Cleanup(th.ts.Close)
+	return th
+}
+
+func postMetrics(t *testing.T, th *testHelper, body string) {
+	t.Helper()
+	statusCode, _ := th.Request(t, http.MethodPost, "/updates/", bytes.NewBufferString(body), nil)
+	require.Equal(t, http.StatusOK, statusCode)
+}
+
+func TestStreamSSE(t *testing.T) {
+	th := initStreamServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, th.ts.URL+streamPath+"?glob=Heap*", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := th.ts.Client().Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, eventStream, resp.Header.Get(contType))
+	assert.Empty(t, resp.Header.Get("Content-Encoding"), "stream is not compressed")
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+
+	postMetrics(t, th, `[{"type": "gauge", "id": "Alloc", "value": 1},
+		{"type": "gauge", "id": "HeapAlloc", "value": 2}]`)
+
+	var lines []string
+	for len(lines) < 2 {
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "event: metric", lines[0])
+	var msg models.StreamEvent
+	require.NoError(t, easyjson.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &msg))
+	require.NotNil(t, msg.Metric)
+	assert.Equal(t, "HeapAlloc", msg.Metric.ID)
+	assert.Equal(t, 2.0, *msg.Metric.Value)
+}
+
+func TestStreamSSE__invalidFilter(t *testing.T) {
+	th := initStreamServer(t)
+
+	statusCode, _ := th.Request(t, http.MethodGet, streamPath+"?type=histogram", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _ = th.Request(t, http.MethodGet, wsPath+"?regex=(", nil, nil)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	th := initStreamServer(t)
+
+	url := "ws" + strings.TrimPrefix(th.ts.URL, "http") + wsPath + "?type=counter"
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// подписка создается до ответа на upgrade, поэтому событие не потеряется.
+	postMetrics(t, th, `[{"type": "gauge", "id": "Alloc", "value": 1},
+		{"type": "counter", "id": "PollCount", "delta": 2},
+		{"type": "counter", "id": "PollCount", "delta": 3}]`)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var total int64
+	for total != 5 {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		var msg models.StreamEvent
+		require.NoError(t, easyjson.Unmarshal(data, &msg))
+		require.Equal(t, metricEvent, msg.Event)
+		assert.Equal(t, "PollCount", msg.Metric.ID)
+		total = *msg.Metric.Delta
+	}
+}
@@ -7,7 +7,8 @@ import (
 
 func (s *APIServer) trustedSubnetMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if s.cfg.TrustedSubnet == "" {
+		// Prometheus опрашивает сервер не из подсети агентов, исключение включается явно.
+		if s.cfg.TrustedSubnet == "" || (s.cfg.PrometheusPublic && r.URL.Path == prometheusPath) {
 			h.ServeHTTP(w, r)
 			return
 		}
@@ -0,0 +1,90 @@
+// Дашборд получает новые значения из потока /api/v1/stream. Без EventSource
+// или при обрыве потока страница перезагружается раз в data-refresh секунд.
+// Фильтр таблицы работает без перезагрузки и хранится в адресе страницы.
+(function () {
+  "use strict";
+
+  var refresh = Number(document.body.getAttribute("data-refresh")) || 0;
+  var reloadTimer = null;
+
+  function scheduleReload() {
+    if (refresh > 0 && reloadTimer === null) {
+      reloadTimer = window.setTimeout(function () {
+        window.location.reload();
+      }, refresh * 1000);
+    }
+  }
+
+  function pad(n) {
+    return String(n).padStart(2, "0");
+  }
+
+  function formatTime(d) {
+    return d.getFullYear() + "-" + pad(d.getMonth() + 1) + "-" + pad(d.getDate()) + " " +
+      pad(d.getHours()) + ":" + pad(d.getMinutes()) + ":" + pad(d.getSeconds());
+  }
+
+  function findRow(type, name) {
+    var rows = document.querySelectorAll("tr[data-name]");
+    for (var i = 0; i < rows.length; i++) {
+      if (rows[i].getAttribute("data-type") === type && rows[i].getAttribute("data-name") === name) {
+        return rows[i];
+      }
+    }
+    return null;
+  }
+
+  function onMetric(e) {
+    var metric = JSON.parse(e.data).metric;
+    var row = findRow(metric.type, metric.id);
+    if (row === null) {
+      // новую метрику покажет следующая загрузка страницы.
+      scheduleReload();
+      return;
+    }
+    row.querySelector("[data-value]").textContent = metric.type === "counter" ? metric.delta : metric.value;
+    var now = new Date();
+    var time = row.querySelector("time");
+    time.setAttribute("datetime", now.toISOString());
+    time.textContent = formatTime(now);
+  }
+
+  function live() {
+    if (!window.EventSource || document.querySelector("tr[data-name]") === null) {
+      scheduleReload();
+      return;
+    }
+    var source = new EventSource("/api/v1/stream");
+    source.addEventListener("metric", onMetric);
+    // отброшенные сервером события восстанавливаются перезагрузкой.
+    source.addEventListener("dropped", scheduleReload);
+    source.onerror = function () {
+      source.close();
+      scheduleReload();
+    };
+  }
+
+  function filter(input) {
+    var query = input.value.trim().toLowerCase();
+    document.querySelectorAll("tr[data-name]").forEach(function (row) {
+      var name = row.getAttribute("data-name").toLowerCase();
+      row.hidden = query !== "" && name.indexOf(query) === -1;
+    });
+
+    var url = new URL(window.location.href);
+    if (query === "") {
+      url.searchParams.delete("q");
+    } else {
+      url.searchParams.set("q", input.value.trim());
+    }
+    window.history.replaceState(null, "", url);
+  }
+
+  var input = document.querySelector("[data-filter]");
+  if (input) {
+    input.addEventListener("input", function () {
+      filter(input);
+    });
+  }
+  live();
+})();
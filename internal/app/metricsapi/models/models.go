@@ -33,6 +33,42 @@ type Metrics struct {
 //easyjson:json
 type MetricsList []Metrics
 
+// StreamEvent сообщение потока обновлений: Event - "metric" с новым значением в Metric
+// или "dropped" с количеством отброшенных для медленного подписчика событий.
+type StreamEvent struct {
+	Metric  *Metrics `json:"metric,omitempty"`
+	Event   string   `json:"event"`
+	Dropped int      `json:"dropped,omitempty"`
+}
+
+// RenameRequest новое имя метрики в админском API.
+type RenameRequest struct {
+	Name string `json:"name"`
+}
+
+// DeleteResponse количество удаленных метрик в админском API.
+type DeleteResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// BatchItemResult результат метрики пакета с индексом index в запросе.
+type BatchItemResult struct {
+	ID     string `json:"id"`
+	MType  string `json:"type"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Index  int    `json:"index"`
+}
+
+// BatchReport отчет о применении пакета метрик.
+type BatchReport struct {
+	Mode     string            `json:"mode"`
+	Results  []BatchItemResult `json:"results"`
+	Applied  int               `json:"applied"`
+	Failed   int               `json:"failed"`
+	Replayed bool              `json:"replayed"`
+}
+
 func CastToGauge(m Metric) (GaugeMetric, error) {
 	value, err := strconv.ParseFloat(m.Value, 64)
 	if err != nil {
@@ -18,7 +18,167 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(in *jlexer.Lexer, out *MetricsList) {
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(in *jlexer.Lexer, out *StreamEvent) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "metric":
+			if in.IsNull() {
+				in.Skip()
+				out.Metric = nil
+			} else {
+				if out.Metric == nil {
+					out.Metric = new(Metrics)
+				}
+				(*out.Metric).UnmarshalEasyJSON(in)
+			}
+		case "event":
+			out.Event = string(in.String())
+		case "dropped":
+			out.Dropped = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(out *jwriter.Writer, in StreamEvent) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Metric != nil {
+		const prefix string = ",\"metric\":"
+		first = false
+		out.RawString(prefix[1:])
+		(*in.Metric).MarshalEasyJSON(out)
+	}
+	{
+		const prefix string = ",\"event\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Event))
+	}
+	if in.Dropped != 0 {
+		const prefix string = ",\"dropped\":"
+		out.RawString(prefix)
+		out.Int(int(in.Dropped))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v StreamEvent) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v StreamEvent) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *StreamEvent) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *StreamEvent) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(in *jlexer.Lexer, out *RenameRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "name":
+			out.Name = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(out *jwriter.Writer, in RenameRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"name\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Name))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v RenameRequest) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RenameRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *RenameRequest) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RenameRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(in *jlexer.Lexer, out *MetricsList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -46,7 +206,7 @@ func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(i
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(out *jwriter.Writer, in MetricsList) {
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(out *jwriter.Writer, in MetricsList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
@@ -64,27 +224,27 @@ func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(o
 // MarshalJSON supports json.Marshaler interface
 func (v MetricsList) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(&w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v MetricsList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels(w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *MetricsList) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(&r, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *MetricsList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels(l, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(l, v)
 }
-func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(in *jlexer.Lexer, out *Metrics) {
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(in *jlexer.Lexer, out *Metrics) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -137,7 +297,7 @@ func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(out *jwriter.Writer, in Metrics) {
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(out *jwriter.Writer, in Metrics) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -178,27 +338,27 @@ func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(
 // MarshalJSON supports json.Marshaler interface
 func (v Metrics) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(&w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Metrics) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Metrics) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(&r, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Metrics) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels1(l, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(l, v)
 }
-func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(in *jlexer.Lexer, out *Metric) {
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(in *jlexer.Lexer, out *Metric) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -233,7 +393,7 @@ func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(out *jwriter.Writer, in Metric) {
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(out *jwriter.Writer, in Metric) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -258,27 +418,27 @@ func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(
 // MarshalJSON supports json.Marshaler interface
 func (v Metric) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(&w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Metric) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Metric) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(&r, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Metric) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels2(l, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(l, v)
 }
-func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(in *jlexer.Lexer, out *GaugeMetric) {
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels5(in *jlexer.Lexer, out *GaugeMetric) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -313,7 +473,7 @@ func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(out *jwriter.Writer, in GaugeMetric) {
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels5(out *jwriter.Writer, in GaugeMetric) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -338,27 +498,93 @@ func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(
 // MarshalJSON supports json.Marshaler interface
 func (v GaugeMetric) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(&w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels5(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v GaugeMetric) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels5(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *GaugeMetric) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(&r, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels5(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *GaugeMetric) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels3(l, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels5(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels6(in *jlexer.Lexer, out *DeleteResponse) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "deleted":
+			out.Deleted = int64(in.Int64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
 }
-func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(in *jlexer.Lexer, out *CounterMetric) {
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels6(out *jwriter.Writer, in DeleteResponse) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"deleted\":"
+		out.RawString(prefix[1:])
+		out.Int64(int64(in.Deleted))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v DeleteResponse) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v DeleteResponse) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *DeleteResponse) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *DeleteResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels6(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels7(in *jlexer.Lexer, out *CounterMetric) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -393,7 +619,7 @@ func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(
 		in.Consumed()
 	}
 }
-func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(out *jwriter.Writer, in CounterMetric) {
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels7(out *jwriter.Writer, in CounterMetric) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -418,23 +644,243 @@ func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(
 // MarshalJSON supports json.Marshaler interface
 func (v CounterMetric) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(&w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels7(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v CounterMetric) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(w, v)
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels7(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *CounterMetric) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(&r, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels7(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *CounterMetric) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels4(l, v)
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels7(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels8(in *jlexer.Lexer, out *BatchReport) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "mode":
+			out.Mode = string(in.String())
+		case "results":
+			if in.IsNull() {
+				in.Skip()
+				out.Results = nil
+			} else {
+				in.Delim('[')
+				if out.Results == nil {
+					if !in.IsDelim(']') {
+						out.Results = make([]BatchItemResult, 0, 0)
+					} else {
+						out.Results = []BatchItemResult{}
+					}
+				} else {
+					out.Results = (out.Results)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 BatchItemResult
+					(v4).UnmarshalEasyJSON(in)
+					out.Results = append(out.Results, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "applied":
+			out.Applied = int(in.Int())
+		case "failed":
+			out.Failed = int(in.Int())
+		case "replayed":
+			out.Replayed = bool(in.Bool())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels8(out *jwriter.Writer, in BatchReport) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"mode\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Mode))
+	}
+	{
+		const prefix string = ",\"results\":"
+		out.RawString(prefix)
+		if in.Results == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Results {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				(v6).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"applied\":"
+		out.RawString(prefix)
+		out.Int(int(in.Applied))
+	}
+	{
+		const prefix string = ",\"failed\":"
+		out.RawString(prefix)
+		out.Int(int(in.Failed))
+	}
+	{
+		const prefix string = ",\"replayed\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Replayed))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v BatchReport) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels8(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchReport) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels8(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *BatchReport) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels8(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchReport) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels8(l, v)
+}
+func easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels9(in *jlexer.Lexer, out *BatchItemResult) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = string(in.String())
+		case "type":
+			out.MType = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "error":
+			out.Error = string(in.String())
+		case "index":
+			out.Index = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels9(out *jwriter.Writer, in BatchItemResult) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.ID))
+	}
+	{
+		const prefix string = ",\"type\":"
+		out.RawString(prefix)
+		out.String(string(in.MType))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	if in.Error != "" {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		out.String(string(in.Error))
+	}
+	{
+		const prefix string = ",\"index\":"
+		out.RawString(prefix)
+		out.Int(int(in.Index))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v BatchItemResult) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchItemResult) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonD2b7633eEncodeGithubComNSteguraMetricsInternalAppMetricsapiModels9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *BatchItemResult) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchItemResult) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonD2b7633eDecodeGithubComNSteguraMetricsInternalAppMetricsapiModels9(l, v)
 }
@@ -0,0 +1,91 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+)
+
+// DeleteMetric удаляет метрику, история в Postgres сохраняется.
+func (bll *bll) DeleteMetric(ctx context.Context, mType string, mName string) error {
+	if err := validateMetricType(mType); err != nil {
+		return err
+	}
+	if err := bll.repo.DeleteMetric(ctx, mType, mName); err != nil {
+		if errors.Is(err, customerrors.ErrNotFound) {
+			return fmt.Errorf("metric not found: %w", err)
+		}
+		return fmt.Errorf("failed to delete metric, %w", err)
+	}
+	bll.logger.Infof("metric %s %s deleted", mType, mName)
+	return nil
+}
+
+// DeleteMetrics удаляет метрики по шаблону и возвращает их количество.
+// Нужен хотя бы один из prefix, glob или regex, чтобы случайно не удалить все метрики.
+func (bll *bll) DeleteMetrics(ctx context.Context, filter blModels.ListFilter) (int64, error) {
+	if filter.Prefix == "" && filter.Glob == "" && filter.Regex == "" {
+		return 0, fmt.Errorf("%w: prefix, glob or regex required", customerrors.ErrInvalidFilter)
+	}
+	repoFilter, err := toRepoFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := bll.repo.DeleteMetrics(ctx, repoFilter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete metrics, %w", err)
+	}
+	bll.logger.Infof("%d metrics deleted", deleted)
+	return deleted, nil
+}
+
+// RenameMetric переименовывает метрику, история в Postgres переходит к новому имени.
+func (bll *bll) RenameMetric(ctx context.Context, mType string, mName string, newName string) error {
+	if err := validateMetricType(mType); err != nil {
+		return err
+	}
+	if newName == "" {
+		return fmt.Errorf("%w: empty metric name", customerrors.ErrInvalidFilter)
+	}
+	if newName == mName {
+		return nil
+	}
+	if err := bll.repo.RenameMetric(ctx, mType, mName, newName); err != nil {
+		switch {
+		case errors.Is(err, customerrors.ErrNotFound):
+			return fmt.Errorf("metric not found: %w", err)
+		case errors.Is(err, customerrors.ErrAlreadyExists):
+			return fmt.Errorf("metric %s already exists: %w", newName, err)
+		}
+		return fmt.Errorf("failed to rename metric, %w", err)
+	}
+	bll.logger.Infof("metric %s %s renamed to %s", mType, mName, newName)
+	return nil
+}
+
+// ResetCounter обнуляет counter метрику.
+func (bll *bll) ResetCounter(ctx context.Context, mName string) error {
+	if _, err := bll.repo.GetCounterMetric(ctx, mName); err != nil {
+		if errors.Is(err, customerrors.ErrNotFound) {
+			return fmt.Errorf("counter metric not found: %w", err)
+		}
+		return fmt.Errorf("failed to get counter metric, %w", err)
+	}
+	if err := bll.repo.ResetCounter(ctx, mName); err != nil {
+		return fmt.Errorf("failed to reset counter metric, %w", err)
+	}
+	bll.logger.Infof("counter metric %s reset", mName)
+	return nil
+}
+
+func validateMetricType(mType string) error {
+	switch mType {
+	case "gauge", "counter":
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown metric type %q", customerrors.ErrInvalidFilter, mType)
+	}
+}
@@ -0,0 +1,152 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NStegura/metrics/internal/business/events"
+	blModels "github.com/NStegura/metrics/internal/business/models"
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+type pendingEventsKey struct{}
+
+// errNothingApplied освобождает ключ пакета partial, в котором не применилась ни одна метрика.
+var errNothingApplied = errors.New("nothing applied")
+
+// UpdateBatch применяет пакет метрик не больше одного раза для batchID и возвращает отчет по каждой метрике.
+// В режиме atomic пакет применяется одним вызовом ApplyBatch: при ошибке не применяется ни одна метрика,
+// а ошибка возвращается вместе с отчетом, некорректные метрики отклоняют пакет с customerrors.ErrInvalidBatch.
+// В режиме partial применяются все корректные метрики, а ошибки остальных есть только в отчете.
+// Подписчики получают обновления после применения пакета.
+func (bll *bll) UpdateBatch(
+	ctx context.Context,
+	batchID string,
+	mode blModels.BatchMode,
+	items []blModels.BatchItem,
+) (blModels.BatchResult, error) {
+	result := blModels.BatchResult{Mode: mode, Items: make([]blModels.ItemResult, len(items))}
+	for i, item := range items {
+		result.Items[i] = blModels.ItemResult{Index: i, Name: item.Name, Type: item.Type, Status: blModels.ItemSkipped}
+	}
+	if mode != blModels.BatchAtomic && mode != blModels.BatchPartial {
+		return result, fmt.Errorf("%w: unknown mode %q", customerrors.ErrInvalidBatch, mode)
+	}
+
+	valid := make([]bool, len(items))
+	for i, item := range items {
+		if err := validateBatchItem(item); err != nil {
+			failItem(&result, i, err)
+			continue
+		}
+		valid[i] = true
+	}
+	if mode == blModels.BatchAtomic && result.Failed > 0 {
+		return result, fmt.Errorf("%w: %d invalid metrics", customerrors.ErrInvalidBatch, result.Failed)
+	}
+
+	var pending []events.Event
+	applied, err := bll.UpdateOnce(ctx, batchID, func(ctx context.Context) error {
+		ctx = context.WithValue(ctx, pendingEventsKey{}, &pending)
+		if mode == blModels.BatchPartial {
+			for i, item := range items {
+				if !valid[i] {
+					continue
+				}
+				// отдельная вложенная транзакция: ошибка метрики не откатывает остальные.
+				err := bll.repo.InTx(ctx, func(ctx context.Context) error {
+					return bll.applyBatchItem(ctx, item)
+				})
+				if err != nil {
+					failItem(&result, i, err)
+					continue
+				}
+				applyItem(&result, i)
+			}
+			if result.Applied == 0 && len(items) > 0 {
+				return errNothingApplied
+			}
+			return nil
+		}
+
+		updates := make([]models.Update, 0, len(items))
+		for _, item := range items {
+			if item.Type == "gauge" {
+				updates = append(updates, models.Update{Name: item.Name, Type: item.Type, Value: *item.Value})
+			} else {
+				updates = append(updates, models.Update{Name: item.Name, Type: item.Type, Delta: *item.Delta})
+			}
+		}
+		metrics, err := bll.repo.ApplyBatch(ctx, updates)
+		if err != nil {
+			var updateErr *models.UpdateError
+			if errors.As(err, &updateErr) {
+				failItem(&result, updateErr.Index, updateErr.Err)
+			}
+			return fmt.Errorf("failed to apply batch, %w", err)
+		}
+		for i, m := range metrics {
+			applyItem(&result, i)
+			bll.publish(ctx, m.Name, m.Type, m.Value, m.Delta)
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errNothingApplied):
+		return result, nil
+	case err != nil:
+		// транзакция пакета откатилась вместе с метриками, отмеченными примененными.
+		skipApplied(&result)
+		return result, err
+	case !applied:
+		result.Replayed = true
+		return result, nil
+	}
+	for _, e := range pending {
+		bll.events.Publish(e)
+	}
+	return result, nil
+}
+
+func failItem(result *blModels.BatchResult, i int, err error) {
+	result.Items[i].Status = blModels.ItemFailed
+	result.Items[i].Error = err.Error()
+	result.Failed++
+}
+
+func applyItem(result *blModels.BatchResult, i int) {
+	result.Items[i].Status = blModels.ItemApplied
+	result.Applied++
+}
+
+func skipApplied(result *blModels.BatchResult) {
+	for i := range result.Items {
+		if result.Items[i].Status == blModels.ItemApplied {
+			result.Items[i].Status = blModels.ItemSkipped
+		}
+	}
+	result.Applied = 0
+}
+
+func (bll *bll) applyBatchItem(ctx context.Context, item blModels.BatchItem) error {
+	if item.Type == "gauge" {
+		return bll.UpdateGaugeMetric(ctx, blModels.GaugeMetric{Name: item.Name, Type: item.Type, Value: *item.Value})
+	}
+	return bll.UpdateCounterMetric(ctx, blModels.CounterMetric{Name: item.Name, Type: item.Type, Value: *item.Delta})
+}
+
+func validateBatchItem(item blModels.BatchItem) error {
+	switch {
+	case item.Name == "":
+		return errors.New("empty metric name")
+	case item.Type == "gauge" && item.Value == nil:
+		return errors.New("gauge metric value null")
+	case item.Type == "counter" && item.Delta == nil:
+		return errors.New("counter metric value null")
+	case item.Type != "gauge" && item.Type != "counter":
+		return fmt.Errorf("unknown metric type %q", item.Type)
+	}
+	return nil
+}
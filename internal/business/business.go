@@ -4,27 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/NStegura/metrics/internal/business/events"
 	blModels "github.com/NStegura/metrics/internal/business/models"
 	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
 )
 
 const (
 	countGaugeMetrics   int = 27
 	countCounterMetrics int = 1
+
+	defaultListLimit int = 100
+	maxListLimit     int = 1000
+	maxListRegexLen  int = 256
+
+	historyLimit int = 100
 )
 
 // bll бизнес слой.
 type bll struct {
 	repo   Repository
+	events *events.Hub
 	logger *logrus.Logger
 }
 
 func New(repo Repository, logger *logrus.Logger) *bll {
-	return &bll{repo: repo, logger: logger}
+	return &bll{repo: repo, events: events.NewHub(events.DefaultPending), logger: logger}
 }
 
 // GetGaugeMetric получает gauge метрику по имени.
@@ -40,21 +51,13 @@ func (bll *bll) GetGaugeMetric(ctx context.Context, mName string) (float64, erro
 	return gm.Value, nil
 }
 
-// UpdateGaugeMetric обновляет gauge метрику.
-func (bll *bll) UpdateGaugeMetric(ctx context.Context, gmReq blModels.GaugeMetric) (err error) {
-	_, err = bll.repo.GetGaugeMetric(ctx, gmReq.Name)
-	if err != nil {
-		if errors.Is(err, customerrors.ErrNotFound) {
-			err = bll.repo.CreateGaugeMetric(ctx, gmReq.Name, gmReq.Type, gmReq.Value)
-			if err != nil {
-				return fmt.Errorf("create gauge metric failed, %w", err)
-			}
-			return nil
-		}
-		return fmt.Errorf("failed to get gauge metric, %w", err)
+// UpdateGaugeMetric сохраняет значение gauge метрики, создавая ее.
+func (bll *bll) UpdateGaugeMetric(ctx context.Context, gmReq blModels.GaugeMetric) error {
+	if err := bll.repo.UpsertGauge(ctx, gmReq.Name, gmReq.Value); err != nil {
+		return fmt.Errorf("failed to upsert gauge metric, %w", err)
 	}
-	err = bll.repo.UpdateGaugeMetric(ctx, gmReq.Name, gmReq.Value)
-	return
+	bll.publish(ctx, gmReq.Name, "gauge", gmReq.Value, 0)
+	return nil
 }
 
 // GetCounterMetric получает counter метрику по имени.
@@ -70,23 +73,73 @@ func (bll *bll) GetCounterMetric(ctx context.Context, mName string) (int64, erro
 	return cm.Value, nil
 }
 
-// UpdateCounterMetric обновляет counter метрику.
-func (bll *bll) UpdateCounterMetric(ctx context.Context, cmReq blModels.CounterMetric) (err error) {
-	cm, err := bll.repo.GetCounterMetric(ctx, cmReq.Name)
+// UpdateCounterMetric прибавляет значение к counter метрике, создавая ее.
+// Прибавка атомарна в хранилище, поэтому одновременные обновления не теряются.
+func (bll *bll) UpdateCounterMetric(ctx context.Context, cmReq blModels.CounterMetric) error {
+	value, err := bll.repo.IncrementCounter(ctx, cmReq.Name, cmReq.Value)
 	if err != nil {
-		if errors.Is(err, customerrors.ErrNotFound) {
-			err = bll.repo.CreateCounterMetric(ctx, cmReq.Name, cmReq.Type, cmReq.Value)
-			if err != nil {
-				return fmt.Errorf("create counter metric failed, %w", err)
-			}
+		return fmt.Errorf("failed to increment counter metric, %w", err)
+	}
+	bll.publish(ctx, cmReq.Name, "counter", 0, value)
+	return nil
+}
+
+// publish отправляет событие подписчикам, внутри UpdateBatch событие ждет конца пакета.
+func (bll *bll) publish(ctx context.Context, name string, mType string, value float64, delta int64) {
+	e := events.Event{Time: time.Now(), Name: name, Type: mType, Value: value, Delta: delta}
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*[]events.Event); ok {
+		*pending = append(*pending, e)
+		return
+	}
+	bll.events.Publish(e)
+}
+
+// Subscribe подписывает на обновления метрик, подходящих под Type, Prefix, Glob и Regex фильтра.
+// Ошибки в фильтре оборачивают customerrors.ErrInvalidFilter.
+func (bll *bll) Subscribe(filter blModels.ListFilter) (*events.Subscription, error) {
+	repoFilter, err := toRepoFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	match, err := repoFilter.NameMatcher()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", customerrors.ErrInvalidFilter, err)
+	}
+	return bll.events.Subscribe(func(e events.Event) bool {
+		return (filter.Type == "" || e.Type == filter.Type) && match(e.Name)
+	}), nil
+}
+
+// UpdateOnce применяет пакет обновлений update не больше одного раза для batchID.
+// Ключ пакета сохраняется в одной транзакции с обновлениями: если update вернул ошибку
+// или транзакция не зафиксирована, ключа нет, и пакет можно повторить с тем же batchID.
+// Повтор уже примененного пакета ничего не меняет и возвращает applied = false.
+// Без batchID пакет применяется всегда, как у старых клиентов.
+func (bll *bll) UpdateOnce(
+	ctx context.Context,
+	batchID string,
+	update func(context.Context) error,
+) (applied bool, err error) {
+	if batchID == "" {
+		return true, update(ctx)
+	}
+
+	err = bll.repo.InTx(ctx, func(ctx context.Context) error {
+		reserved, err := bll.repo.ReserveBatch(ctx, batchID)
+		if err != nil {
+			return fmt.Errorf("failed to reserve batch, %w", err)
+		}
+		if !reserved {
+			bll.logger.Infof("batch %s already applied", batchID)
 			return nil
 		}
-		return fmt.Errorf("failed to get counter metric, %w", err)
+		applied = true
+		return update(ctx)
+	})
+	if err != nil {
+		return false, err
 	}
-
-	newVal := cm.Value + cmReq.Value
-	err = bll.repo.UpdateCounterMetric(ctx, cmReq.Name, newVal)
-	return
+	return applied, nil
 }
 
 // GetAllMetrics получает все метрики.
@@ -101,16 +154,18 @@ func (bll *bll) GetAllMetrics(ctx context.Context) ([]blModels.GaugeMetric, []bl
 
 	for _, gMetric := range gms {
 		gaugeMetrics = append(gaugeMetrics, blModels.GaugeMetric{
-			Name:  gMetric.Name,
-			Type:  gMetric.Type,
-			Value: gMetric.Value,
+			UpdatedAt: gMetric.UpdatedAt,
+			Name:      gMetric.Name,
+			Type:      gMetric.Type,
+			Value:     gMetric.Value,
 		})
 	}
 	for _, cMetric := range cms {
 		counterMetrics = append(counterMetrics, blModels.CounterMetric{
-			Name:  cMetric.Name,
-			Type:  cMetric.Type,
-			Value: cMetric.Value,
+			UpdatedAt: cMetric.UpdatedAt,
+			Name:      cMetric.Name,
+			Type:      cMetric.Type,
+			Value:     cMetric.Value,
 		})
 	}
 	if len(gaugeMetrics) > 1 {
@@ -120,6 +175,115 @@ func (bll *bll) GetAllMetrics(ctx context.Context) ([]blModels.GaugeMetric, []bl
 	return gaugeMetrics, counterMetrics, nil
 }
 
+// ListMetrics возвращает страницу метрик по фильтру и курсор следующей страницы,
+// nil - страница последняя. Ошибки в фильтре оборачивают customerrors.ErrInvalidFilter.
+func (bll *bll) ListMetrics(
+	ctx context.Context,
+	filter blModels.ListFilter,
+) ([]blModels.Metric, *blModels.Cursor, error) {
+	repoFilter, err := toRepoFilter(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	// лишняя метрика показывает, есть ли следующая страница.
+	limit := repoFilter.Limit
+	repoFilter.Limit++
+
+	ms, err := bll.repo.ListMetrics(ctx, repoFilter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list metrics, %w", err)
+	}
+
+	var next *blModels.Cursor
+	if len(ms) > limit {
+		ms = ms[:limit]
+		last := ms[limit-1]
+		next = &blModels.Cursor{Name: last.Name, Type: last.Type, UpdatedAt: last.UpdatedAt}
+	}
+	metrics := make([]blModels.Metric, 0, len(ms))
+	for _, m := range ms {
+		metrics = append(metrics, blModels.Metric{
+			UpdatedAt: m.UpdatedAt,
+			Name:      m.Name,
+			Type:      m.Type,
+			Value:     m.Value,
+			Delta:     m.Delta,
+		})
+	}
+	return metrics, next, nil
+}
+
+func toRepoFilter(filter blModels.ListFilter) (models.ListFilter, error) {
+	repoFilter := models.ListFilter{
+		Type:   filter.Type,
+		Prefix: filter.Prefix,
+		Glob:   filter.Glob,
+		Regex:  filter.Regex,
+		Sort:   models.SortField(filter.Sort),
+		Desc:   filter.Desc,
+		Limit:  filter.Limit,
+	}
+	switch filter.Type {
+	case "", "gauge", "counter":
+	default:
+		return repoFilter, fmt.Errorf("%w: unknown metric type %q", customerrors.ErrInvalidFilter, filter.Type)
+	}
+	switch repoFilter.Sort {
+	case "":
+		repoFilter.Sort = models.SortByName
+	case models.SortByName, models.SortByUpdatedAt:
+	default:
+		return repoFilter, fmt.Errorf("%w: unknown sort %q", customerrors.ErrInvalidFilter, filter.Sort)
+	}
+	if filter.Glob != "" {
+		if _, err := models.GlobToRegexp(filter.Glob); err != nil {
+			return repoFilter, fmt.Errorf("%w: %w", customerrors.ErrInvalidFilter, err)
+		}
+	}
+	if filter.Regex != "" {
+		if len(filter.Regex) > maxListRegexLen {
+			return repoFilter, fmt.Errorf("%w: regex is longer than %d", customerrors.ErrInvalidFilter, maxListRegexLen)
+		}
+		if _, err := regexp.Compile(filter.Regex); err != nil {
+			return repoFilter, fmt.Errorf("%w: %w", customerrors.ErrInvalidFilter, err)
+		}
+	}
+	switch {
+	case filter.Limit == 0:
+		repoFilter.Limit = defaultListLimit
+	case filter.Limit < 0 || filter.Limit > maxListLimit:
+		return repoFilter, fmt.Errorf("%w: limit must be in 1..%d", customerrors.ErrInvalidFilter, maxListLimit)
+	}
+	if filter.After != nil {
+		repoFilter.After = &models.Cursor{
+			Name: filter.After.Name, Type: filter.After.Type, UpdatedAt: filter.After.UpdatedAt,
+		}
+	}
+	return repoFilter, nil
+}
+
+// HistoryAvailable сообщает, что хранилище ведет историю метрик.
+func (bll *bll) HistoryAvailable() bool {
+	return bll.repo.HistoryAvailable()
+}
+
+// MetricHistory возвращает последние значения метрики по возрастанию времени.
+// Без истории в хранилище возвращает customerrors.ErrNoHistory.
+func (bll *bll) MetricHistory(ctx context.Context, mType string, mName string) ([]blModels.HistoryPoint, error) {
+	if err := validateMetricType(mType); err != nil {
+		return nil, err
+	}
+	points, err := bll.repo.GetMetricHistory(ctx, mType, mName, historyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metric history, %w", err)
+	}
+	history := make([]blModels.HistoryPoint, 0, len(points))
+	for _, p := range points {
+		history = append(history, blModels.HistoryPoint{CreatedAt: p.CreatedAt, Value: p.Value})
+	}
+	return history, nil
+}
+
 // Ping проверяет работу сервера.
 func (bll *bll) Ping(ctx context.Context) error {
 	err := bll.repo.Ping(ctx)
@@ -0,0 +1,130 @@
+// Package events рассылает подписчикам изменения метрик.
+// Публикация никогда не ждет подписчиков: пока подписчик не забрал события,
+// новые значения той же метрики заменяют старые, а события новых метрик
+// сверх лимита отбрасываются с подсчетом.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultPending - сколько разных метрик ждут подписчика, пока он не заберет события.
+const DefaultPending = 1024
+
+// Event - новое значение метрики: у gauge заполнено Value, у counter - Delta с итоговым значением.
+type Event struct {
+	Time  time.Time
+	Name  string
+	Type  string
+	Value float64
+	Delta int64
+}
+
+type eventKey struct {
+	name  string
+	mType string
+}
+
+// Hub рассылает события подписчикам.
+type Hub struct {
+	subs    map[*Subscription]struct{}
+	mu      sync.RWMutex
+	pending int
+}
+
+// NewHub создает рассылку, pending - лимит ожидающих метрик у подписчика.
+func NewHub(pending int) *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{}), pending: pending}
+}
+
+// Publish отправляет событие подписчикам, чей фильтр его пропускает.
+func (h *Hub) Publish(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs {
+		if sub.match(e) {
+			sub.push(e)
+		}
+	}
+}
+
+// Subscribe подписывает на события, match nil - на все события.
+// Подписку нужно закрыть через Close.
+func (h *Hub) Subscribe(match func(Event) bool) *Subscription {
+	if match == nil {
+		match = func(Event) bool { return true }
+	}
+	sub := &Subscription{
+		hub:     h,
+		match:   match,
+		limit:   h.pending,
+		pending: make(map[eventKey]int),
+		ready:   make(chan struct{}, 1),
+	}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Subscribers возвращает количество подписчиков.
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+// Subscription - подписка на события.
+type Subscription struct {
+	hub     *Hub
+	match   func(Event) bool
+	pending map[eventKey]int
+	ready   chan struct{}
+	events  []Event
+	limit   int
+	dropped int
+	mu      sync.Mutex
+}
+
+// Ready сигналит, что появились события для Next.
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Next забирает накопленные события в порядке первого появления метрики
+// и количество отброшенных с прошлого вызова событий.
+func (s *Subscription) Next() (events []Event, dropped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events, dropped = s.events, s.dropped
+	s.events, s.dropped = nil, 0
+	clear(s.pending)
+	return events, dropped
+}
+
+// Close отписывает от событий.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+}
+
+func (s *Subscription) push(e Event) {
+	key := eventKey{name: e.Name, mType: e.Type}
+	s.mu.Lock()
+	if i, ok := s.pending[key]; ok {
+		s.events[i] = e
+	} else if len(s.events) < s.limit {
+		s.pending[key] = len(s.events)
+		s.events = append(s.events, e)
+	} else {
+		s.dropped++
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub__filter(t *testing.T) {
+	h := NewHub(DefaultPending)
+	sub := h.Subscribe(func(e Event) bool { return e.Type == "counter" })
+	defer sub.Close()
+
+	h.Publish(Event{Name: "Alloc", Type: "gauge", Value: 1})
+	h.Publish(Event{Name: "PollCount", Type: "counter", Delta: 2})
+
+	<-sub.Ready()
+	events, dropped := sub.Next()
+	assert.Equal(t, []Event{{Name: "PollCount", Type: "counter", Delta: 2}}, events)
+	assert.Zero(t, dropped)
+}
+
+func TestHub__coalesceAndDrop(t *testing.T) {
+	h := NewHub(2)
+	sub := h.Subscribe(nil)
+	defer sub.Close()
+
+	h.Publish(Event{Name: "a", Type: "gauge", Value: 1})
+	h.Publish(Event{Name: "b", Type: "gauge", Value: 1})
+	h.Publish(Event{Name: "a", Type: "gauge", Value: 2})
+	h.Publish(Event{Name: "c", Type: "gauge", Value: 1})
+
+	events, dropped := sub.Next()
+	assert.Equal(t, []Event{{Name: "a", Type: "gauge", Value: 2}, {Name: "b", Type: "gauge", Value: 1}}, events)
+	assert.Equal(t, 1, dropped)
+
+	h.Publish(Event{Name: "c", Type: "gauge", Value: 3})
+	events, dropped = sub.Next()
+	assert.Equal(t, []Event{{Name: "c", Type: "gauge", Value: 3}}, events)
+	assert.Zero(t, dropped)
+}
+
+func TestHub__slowSubscriberDoesNotBlock(t *testing.T) {
+	h := NewHub(DefaultPending)
+	slow := h.Subscribe(nil)
+	defer slow.Close()
+	fast := h.Subscribe(nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range fast.Ready() {
+			events, _ := fast.Next()
+			for _, e := range events {
+				if e.Value == 9999 {
+					return
+				}
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 10000 {
+			h.Publish(Event{Name: strconv.Itoa(i % 100), Type: "gauge", Value: float64(i)})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "publish blocked by slow subscriber")
+	}
+	wg.Wait()
+	fast.Close()
+	assert.Equal(t, 1, h.Subscribers())
+
+	events, dropped := slow.Next()
+	assert.Len(t, events, 100, "slow subscriber keeps the latest value per metric")
+	assert.Zero(t, dropped)
+}
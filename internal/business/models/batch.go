@@ -0,0 +1,47 @@
+package models
+
+// BatchMode - режим применения пакета метрик.
+type BatchMode string
+
+const (
+	// BatchAtomic - пакет применяется целиком или не применяется совсем.
+	BatchAtomic BatchMode = "atomic"
+	// BatchPartial - применяются все корректные метрики пакета.
+	BatchPartial BatchMode = "partial"
+)
+
+// ItemStatus - результат применения метрики пакета.
+type ItemStatus string
+
+const (
+	ItemApplied ItemStatus = "applied"
+	ItemFailed  ItemStatus = "failed"
+	// ItemSkipped - метрика не применена из-за ошибки в другой метрике атомарного пакета.
+	ItemSkipped ItemStatus = "skipped"
+)
+
+// BatchItem - метрика пакета: у gauge заполнено Value, у counter - Delta.
+type BatchItem struct {
+	Value *float64
+	Delta *int64
+	Name  string
+	Type  string
+}
+
+// ItemResult - результат метрики пакета с индексом Index.
+type ItemResult struct {
+	Name   string
+	Type   string
+	Status ItemStatus
+	Error  string
+	Index  int
+}
+
+// BatchResult - отчет о применении пакета, Replayed - пакет уже применялся с тем же ключом.
+type BatchResult struct {
+	Mode     BatchMode
+	Items    []ItemResult
+	Applied  int
+	Failed   int
+	Replayed bool
+}
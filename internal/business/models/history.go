@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// HistoryPoint - значение метрики из истории.
+type HistoryPoint struct {
+	CreatedAt time.Time
+	Value     float64
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Metric - метрика любого типа в списке: у gauge заполнено Value, у counter - Delta.
+type Metric struct {
+	UpdatedAt time.Time
+	Name      string
+	Type      string
+	Value     float64
+	Delta     int64
+}
+
+// Cursor указывает на последнюю метрику страницы.
+type Cursor struct {
+	UpdatedAt time.Time
+	Name      string
+	Type      string
+}
+
+// ListFilter - параметры списка метрик: Sort - "name" или "updated_at",
+// Glob и Regex проверяются по имени целиком, Limit 0 - размер страницы по умолчанию.
+type ListFilter struct {
+	After  *Cursor
+	Type   string
+	Prefix string
+	Glob   string
+	Regex  string
+	Sort   string
+	Desc   bool
+	Limit  int
+}
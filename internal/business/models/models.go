@@ -1,15 +1,19 @@
 package models
 
+import "time"
+
 type GaugeMetric struct {
-	Name  string
-	Type  string
-	Value float64
+	UpdatedAt time.Time
+	Name      string
+	Type      string
+	Value     float64
 }
 
 type CounterMetric struct {
-	Name  string
-	Type  string
-	Value int64
+	UpdatedAt time.Time
+	Name      string
+	Type      string
+	Value     int64
 }
 
 type ByName []GaugeMetric
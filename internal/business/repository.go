@@ -14,7 +14,19 @@ type Repository interface {
 	GetGaugeMetric(context.Context, string) (models.GaugeMetric, error)
 	CreateGaugeMetric(ctx context.Context, name string, mType string, value float64) error
 	UpdateGaugeMetric(ctx context.Context, name string, value float64) error
+	IncrementCounter(ctx context.Context, name string, delta int64) (int64, error)
+	UpsertGauge(ctx context.Context, name string, value float64) error
+	ApplyBatch(ctx context.Context, updates []models.Update) ([]models.Metric, error)
 	GetAllMetrics(ctx context.Context) ([]models.GaugeMetric, []models.CounterMetric, error)
+	ListMetrics(ctx context.Context, filter models.ListFilter) ([]models.Metric, error)
+	DeleteMetric(ctx context.Context, mType string, name string) error
+	DeleteMetrics(ctx context.Context, filter models.ListFilter) (int64, error)
+	RenameMetric(ctx context.Context, mType string, name string, newName string) error
+	ResetCounter(ctx context.Context, name string) error
+	HistoryAvailable() bool
+	GetMetricHistory(ctx context.Context, mType string, name string, limit int) ([]models.HistoryPoint, error)
+	ReserveBatch(ctx context.Context, batchID string) (bool, error)
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 
 	Ping(ctx context.Context) error
 }
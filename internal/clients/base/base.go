@@ -1,36 +1,57 @@
 package base
 
 import (
-	"bytes"
-	"compress/gzip"
+	"context"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/NStegura/metrics/internal/utils/compress"
+	"github.com/NStegura/metrics/internal/utils/replay"
 	rsaKeys "github.com/NStegura/metrics/internal/utils/rsa"
+	"github.com/NStegura/metrics/internal/utils/signing"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// batchOverhead и itemOverhead учитывают скобки и разделители при сериализации батча.
+	batchOverhead = 2
+	itemOverhead  = 1
+)
+
 //nolint:govet // unexpected?
 type BaseClient struct {
-	BodyHashKey  string
-	CompressType string
-	retryPolicy  []time.Duration
-	isRetryable  func(result any, err error) bool
-	CryptoKey    *rsa.PublicKey
-	Logger       *logrus.Logger
+	BodyHashKey     string
+	CompressType    string
+	retryPolicy     []time.Duration
+	isRetryable     func(result any, err error) bool
+	budget          *retryBudget
+	breaker         *circuitBreaker
+	maxRetryAfter   time.Duration
+	jitter          bool
+	CryptoKey       *rsa.PublicKey
+	CryptoKeyID     string
+	Signer          *signing.Signer
+	TLSConfig       *tls.Config
+	Logger          *logrus.Logger
+	MaxBatchBytes   int
+	MaxBatchMetrics int
 }
 
 func NewBaseClient(options ...Option) (*BaseClient, error) {
 	c := &BaseClient{
-		Logger:       logrus.New(),
-		CompressType: "gzip",
-		retryPolicy:  nil,
+		Logger:        logrus.New(),
+		CompressType:  compress.Gzip,
+		retryPolicy:   nil,
+		maxRetryAfter: defaultMaxRetryAfter,
+		jitter:        true,
 	}
 	for _, opt := range options {
 		err := opt(c)
@@ -46,17 +67,15 @@ func (c *BaseClient) Compress(data []byte) ([]byte, bool, error) {
 		return nil, false, nil
 	}
 
-	var b bytes.Buffer
-	w := gzip.NewWriter(&b)
-	_, err := w.Write(data)
+	codec, err := compress.Get(c.CompressType)
 	if err != nil {
-		return nil, true, fmt.Errorf("failed to write data to compress buffer: %w", err)
+		return nil, true, fmt.Errorf("failed to get codec: %w", err)
 	}
-	err = w.Close()
+	data, err = compress.Encode(codec, data)
 	if err != nil {
 		return nil, true, fmt.Errorf("failed to compress data: %w", err)
 	}
-	return b.Bytes(), true, nil
+	return data, true, nil
 }
 
 func (c *BaseClient) GenerateHMAC(body []byte) (string, bool, error) {
@@ -72,27 +91,170 @@ func (c *BaseClient) GenerateHMAC(body []byte) (string, bool, error) {
 	return hex.EncodeToString(h.Sum(nil)), true, nil
 }
 
+// Signature подпись запроса с меткой времени и nonce для защиты от повтора:
+// HMAC общим ключом и подпись ключом агента, если они настроены.
+type Signature struct {
+	Hash      string
+	Timestamp string
+	Nonce     string
+	KeyID     string
+	Value     string
+}
+
+// Sign подписывает тело вместе с новой меткой времени и nonce.
+// Вызывается на каждую попытку, чтобы ретрай не отклонялся как повтор.
+func (c *BaseClient) Sign(body []byte) (Signature, bool, error) {
+	if c.BodyHashKey == "" && c.Signer == nil {
+		return Signature{}, false, nil
+	}
+
+	nonce, err := replay.NewNonce()
+	if err != nil {
+		return Signature{}, true, fmt.Errorf("failed to sign request: %w", err)
+	}
+	sign := Signature{Timestamp: replay.Timestamp(time.Now()), Nonce: nonce}
+	data := replay.SignedData(sign.Timestamp, sign.Nonce, body)
+	sign.Hash, _, err = c.GenerateHMAC(data)
+	if err != nil {
+		return Signature{}, true, err
+	}
+	if c.Signer != nil {
+		sign.KeyID = c.Signer.KeyID()
+		sign.Value, err = c.Signer.Sign(data)
+		if err != nil {
+			return Signature{}, true, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+	return sign, true, nil
+}
+
 func (c *BaseClient) Encrypt(body []byte) ([]byte, bool, error) {
 	if c.CryptoKey == nil {
 		return nil, false, nil
 	}
 
-	body, err := rsaKeys.EncryptOAEP(sha256.New(), rand.Reader, c.CryptoKey, body, nil)
+	body, err := rsaKeys.EncryptEnvelope(rand.Reader, c.CryptoKey, body)
 	if err != nil {
 		return nil, true, fmt.Errorf("failed to encrypt key, %w", err)
 	}
 	return body, true, nil
 }
 
+// PlainBatchBytes возвращает лимит на размер батча до шифрования,
+// чтобы после шифрования запрос не превышал MaxBatchBytes.
+func (c *BaseClient) PlainBatchBytes() int {
+	if c.MaxBatchBytes <= 0 || c.CryptoKey == nil {
+		return c.MaxBatchBytes
+	}
+	return max(1, c.MaxBatchBytes-rsaKeys.EnvelopeOverhead(c.CryptoKey))
+}
+
+// Chunk делит батч на части не больше MaxBatchMetrics элементов
+// и не больше PlainBatchBytes байт, size возвращает размер элемента.
+// Элемент, превышающий лимит по размеру, отправляется отдельной частью.
+func Chunk[T any](c *BaseClient, items []T, size func(T) int) [][]T {
+	maxBytes := c.PlainBatchBytes()
+	if c.MaxBatchMetrics <= 0 && maxBytes <= 0 {
+		return [][]T{items}
+	}
+
+	var (
+		chunks    [][]T
+		start     int
+		chunkSize = batchOverhead
+	)
+	for i, item := range items {
+		itemSize := size(item) + itemOverhead
+		count := i - start
+		overflow := (c.MaxBatchMetrics > 0 && count >= c.MaxBatchMetrics) ||
+			(maxBytes > 0 && chunkSize+itemSize > maxBytes)
+		if overflow && count > 0 {
+			chunks = append(chunks, items[start:i])
+			start = i
+			chunkSize = batchOverhead
+		}
+		chunkSize += itemSize
+	}
+	if start < len(items) {
+		chunks = append(chunks, items[start:])
+	}
+	return chunks
+}
+
+// Execute выполняет запрос с учетом политики ретраев, бюджета и circuit breaker.
+// Ожидание между попытками прерывается по ctx.
 func (c *BaseClient) Execute(
+	ctx context.Context,
+	doFunc func() (any, error),
+	path string,
+	method string,
+) (result any, err error) {
+	result, err = c.attempt(ctx, doFunc, path, method)
+	for _, backoff := range c.retryPolicy {
+		if !c.shouldRetry(ctx, result, err) {
+			return result, err
+		}
+		delay, ok := c.retryDelay(backoff, result, err)
+		if !ok {
+			c.Logger.Warningf("Server refused retry, error: %+v", err)
+			break
+		}
+		if c.budget != nil && !c.budget.allow() {
+			c.Logger.Warning(ErrRetryBudgetExhausted)
+			break
+		}
+		c.Logger.Warningf("Retrying in %v, error: %+v", delay, err)
+		c.discard(result)
+		if err = sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("retry interrupted: %w", err)
+		}
+		result, err = c.attempt(ctx, doFunc, path, method)
+	}
+	if err != nil && len(c.retryPolicy) > 0 {
+		return result, fmt.Errorf("failed after retries: %w", err)
+	}
+	return result, err
+}
+
+func (c *BaseClient) shouldRetry(ctx context.Context, result any, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) || c.isRetryable == nil {
+		return false
+	}
+	return c.isRetryable(result, err)
+}
+
+// failed проверяет, что попытка говорит о недоступности сервера.
+func (c *BaseClient) failed(result any, err error) bool {
+	if c.isRetryable != nil {
+		return c.isRetryable(result, err)
+	}
+	return err != nil
+}
+
+func (c *BaseClient) attempt(
+	ctx context.Context,
 	doFunc func() (any, error),
 	path string,
 	method string,
 ) (any, error) {
-	if c.retryPolicy != nil {
-		return c.executeWithRetry(doFunc, path, method)
+	if c.breaker != nil && !c.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+	result, err := c.execute(doFunc, path, method)
+	if ctx.Err() != nil {
+		if c.breaker != nil {
+			c.breaker.cancel()
+		}
+		return result, err
+	}
+	failed := c.failed(result, err)
+	if c.breaker != nil {
+		c.breaker.done(failed)
 	}
-	return c.execute(doFunc, path, method)
+	if c.budget != nil {
+		c.budget.done(failed)
+	}
+	return result, err
 }
 
 func (c *BaseClient) execute(
@@ -120,19 +282,3 @@ func (c *BaseClient) execute(
 	}).Info()
 	return
 }
-
-func (c *BaseClient) executeWithRetry(
-	doFunc func() (any, error),
-	path string,
-	method string,
-) (result any, err error) {
-	for _, backoff := range c.retryPolicy {
-		result, err = c.execute(doFunc, path, method)
-		if err == nil || !c.isRetryable(result, err) {
-			return
-		}
-		c.Logger.Warningf("Retrying in %v, error: %+v", backoff, err)
-		time.Sleep(backoff)
-	}
-	return result, fmt.Errorf("failed after retries: %w", err)
-}
@@ -0,0 +1,86 @@
+package base
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается без запроса к серверу, пока цепь разомкнута.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker размыкается после threshold неудачных попыток подряд
+// и через cooldown пропускает одну пробную попытку.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	openedAt  time.Time
+	cooldown  time.Duration
+	threshold int
+	failures  int
+	state     breakerState
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		now:       time.Now,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// cancel освобождает пробную попытку, прерванную контекстом, не меняя состояние.
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
@@ -2,11 +2,15 @@ package base
 
 import (
 	"crypto/rsa"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/NStegura/metrics/internal/utils/compress"
 	rsaKeys "github.com/NStegura/metrics/internal/utils/rsa"
+	"github.com/NStegura/metrics/internal/utils/signing"
 
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
@@ -53,6 +57,7 @@ func WithCryptoKey(key string) Option {
 
 		if key == "" {
 			c.CryptoKey = nil
+			c.CryptoKeyID = ""
 			return nil
 		}
 
@@ -61,31 +66,137 @@ func WithCryptoKey(key string) Option {
 			return fmt.Errorf("failed to read public key: %w", err)
 		}
 		c.CryptoKey = cryptoKey
+		c.CryptoKeyID = rsaKeys.KeyID(cryptoKey)
 		return nil
 	}
 }
 
-// WithCompressType Опция для настройки типа сжатия.
+// WithSigningKey Опция для подписи запросов собственным ключом агента Ed25519 или ECDSA.
+// Пустой keyID заменяется отпечатком публичного ключа.
+func WithSigningKey(path, keyID string) Option {
+	return func(c *BaseClient) error {
+		if path == "" {
+			c.Signer = nil
+			return nil
+		}
+		signer, err := signing.LoadSigner(path, keyID)
+		if err != nil {
+			return fmt.Errorf("failed to load signing key: %w", err)
+		}
+		c.Signer = signer
+		return nil
+	}
+}
+
+// WithTLSConfig Опция для подключения к серверу по TLS.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(c *BaseClient) error {
+		c.TLSConfig = tlsConfig
+		return nil
+	}
+}
+
+// WithCompressType Опция для настройки типа сжатия: gzip, zstd, snappy или пустая строка без сжатия.
 func WithCompressType(compressType string) Option {
 	return func(c *BaseClient) error {
+		if compressType != "" {
+			if _, err := compress.Get(compressType); err != nil {
+				return fmt.Errorf("invalid compress type: %w", err)
+			}
+		}
 		c.CompressType = compressType
 		return nil
 	}
 }
 
-func IsRetryableHTTPRequest(result any, _ error) bool {
+// WithMaxBatchBytes Опция для ограничения размера одного запроса с батчем метрик.
+func WithMaxBatchBytes(maxBytes int) Option {
+	return func(c *BaseClient) error {
+		if maxBytes < 0 {
+			return fmt.Errorf("invalid max batch bytes: %v", maxBytes)
+		}
+		c.MaxBatchBytes = maxBytes
+		return nil
+	}
+}
+
+// WithMaxBatchMetrics Опция для ограничения количества метрик в одном запросе.
+func WithMaxBatchMetrics(maxMetrics int) Option {
+	return func(c *BaseClient) error {
+		if maxMetrics < 0 {
+			return fmt.Errorf("invalid max batch metrics: %v", maxMetrics)
+		}
+		c.MaxBatchMetrics = maxMetrics
+		return nil
+	}
+}
+
+// WithJitter Опция для включения full jitter: задержка выбирается случайно от нуля до backoff.
+func WithJitter(enabled bool) Option {
+	return func(c *BaseClient) error {
+		c.jitter = enabled
+		return nil
+	}
+}
+
+// WithMaxRetryAfter Опция для ограничения задержки, запрошенной сервером через
+// Retry-After или gRPC pushback. При большей задержке ретрай не выполняется, 0 - без ограничения.
+func WithMaxRetryAfter(maxDelay time.Duration) Option {
+	return func(c *BaseClient) error {
+		if maxDelay < 0 {
+			return fmt.Errorf("invalid max retry after: %v", maxDelay)
+		}
+		c.maxRetryAfter = maxDelay
+		return nil
+	}
+}
+
+// WithRetryBudget Опция для ограничения доли ретраев: неудачная попытка тратит токен,
+// успешная возвращает ratio токена, ретраи разрешены, пока токенов больше половины maxTokens.
+func WithRetryBudget(maxTokens int, ratio float64) Option {
+	return func(c *BaseClient) error {
+		if maxTokens <= 0 || ratio <= 0 {
+			return fmt.Errorf("invalid retry budget: tokens=%v, ratio=%v", maxTokens, ratio)
+		}
+		c.budget = newRetryBudget(maxTokens, ratio)
+		return nil
+	}
+}
+
+// WithCircuitBreaker Опция для размыкания цепи после threshold неудачных попыток подряд.
+// Пока цепь разомкнута, запросы завершаются ErrCircuitOpen, через cooldown пропускается пробный запрос.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *BaseClient) error {
+		if threshold <= 0 || cooldown <= 0 {
+			return fmt.Errorf("invalid circuit breaker: threshold=%v, cooldown=%v", threshold, cooldown)
+		}
+		c.breaker = newCircuitBreaker(threshold, cooldown)
+		return nil
+	}
+}
+
+// IsRetryableHTTPRequest повторяет сетевые ошибки, 429 и ответы 5xx.
+func IsRetryableHTTPRequest(result any, err error) bool {
+	if err != nil {
+		return true
+	}
 	resp, ok := result.(*http.Response)
 	if !ok {
 		logrus.Errorf("failed to check type for %v", result)
 		return false
 	}
-	if resp.StatusCode >= http.StatusInternalServerError {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
 		return true
 	}
 	return false
 }
 
+// IsRetryableGRPCRequest повторяет Internal, Unavailable и ошибки с pushback от сервера.
 func IsRetryableGRPCRequest(_ any, err error) bool {
+	var retryErr *RetryAfterError
+	if errors.As(err, &retryErr) {
+		return retryErr.Delay >= 0
+	}
 	if e, ok := status.FromError(err); ok {
 		if e.Code() == codes.Internal || e.Code() == codes.Unavailable {
 			return true
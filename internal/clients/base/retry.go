@@ -0,0 +1,161 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	defaultMaxRetryAfter = time.Minute
+	grpcPushbackKey      = "grpc-retry-pushback-ms"
+)
+
+// ErrRetryBudgetExhausted возвращается, когда бюджет ретраев клиента исчерпан.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryAfterError передает движку ретраев задержку, запрошенную сервером.
+// Отрицательная задержка означает, что сервер просит не повторять запрос.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCPushbackError дополняет ошибку gRPC задержкой из трейлера grpc-retry-pushback-ms.
+func GRPCPushbackError(err error, trailer metadata.MD) error {
+	if err == nil {
+		return nil
+	}
+	values := trailer.Get(grpcPushbackKey)
+	if len(values) == 0 {
+		return err
+	}
+	ms, parseErr := strconv.Atoi(values[0])
+	if parseErr != nil || ms < 0 {
+		return &RetryAfterError{Err: err, Delay: -1}
+	}
+	return &RetryAfterError{Err: err, Delay: time.Duration(ms) * time.Millisecond}
+}
+
+// retryBudget ограничивает долю ретраев среди всех запросов клиента.
+// Каждая неудачная попытка забирает токен, успешная возвращает ratio токена,
+// ретрай разрешен, пока токенов больше половины.
+type retryBudget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+func newRetryBudget(maxTokens int, ratio float64) *retryBudget {
+	return &retryBudget{
+		tokens:    float64(maxTokens),
+		maxTokens: float64(maxTokens),
+		ratio:     ratio,
+	}
+}
+
+func (b *retryBudget) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+func (b *retryBudget) done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if failed {
+		b.tokens = max(0, b.tokens-1)
+		return
+	}
+	b.tokens = min(b.maxTokens, b.tokens+b.ratio)
+}
+
+// retryDelay возвращает задержку перед следующей попыткой.
+// false означает, что сервер запретил ретрай или попросил ждать дольше допустимого.
+func (c *BaseClient) retryDelay(backoff time.Duration, result any, err error) (time.Duration, bool) {
+	delay := backoff
+	if c.jitter && backoff > 0 {
+		delay = time.Duration(rand.Int64N(int64(backoff) + 1)) //nolint:gosec // для джиттера не нужен crypto/rand
+	}
+
+	serverDelay, ok := retryAfter(result, err)
+	if !ok {
+		return delay, true
+	}
+	if serverDelay < 0 || (c.maxRetryAfter > 0 && serverDelay > c.maxRetryAfter) {
+		return 0, false
+	}
+	return serverDelay, true
+}
+
+// retryAfter достает задержку из заголовка Retry-After или gRPC pushback.
+func retryAfter(result any, err error) (time.Duration, bool) {
+	var retryErr *RetryAfterError
+	if errors.As(err, &retryErr) {
+		return retryErr.Delay, true
+	}
+	resp, ok := result.(*http.Response)
+	if !ok || resp == nil {
+		return 0, false
+	}
+	return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в виде HTTP даты.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(0, date.Sub(now)), true
+}
+
+// sleep ждет d или завершения контекста.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err() //nolint:wrapcheck // proxy
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // proxy
+	case <-timer.C:
+		return nil
+	}
+}
+
+// discard закрывает тело ответа, который не будет возвращен вызывающему.
+func (c *BaseClient) discard(result any) {
+	resp, ok := result.(*http.Response)
+	if !ok || resp == nil || resp.Body == nil {
+		return
+	}
+	if err := resp.Body.Close(); err != nil {
+		c.Logger.Error(err)
+	}
+}
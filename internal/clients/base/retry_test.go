@@ -0,0 +1,198 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+func newTestClient(t *testing.T, options ...Option) *BaseClient {
+	t.Helper()
+	c, err := NewBaseClient(options...)
+	require.NoError(t, err)
+	return c
+}
+
+func isRetryable(_ any, err error) bool {
+	return err != nil
+}
+
+func TestExecute__retriesUntilSuccess(t *testing.T) {
+	c := newTestClient(t, WithRetryPolicy(
+		[]time.Duration{time.Millisecond, time.Millisecond},
+		isRetryable,
+	))
+
+	calls := 0
+	result, err := c.Execute(context.Background(), func() (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, errUnavailable
+		}
+		return "ok", nil
+	}, "/", "test")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, 3, calls)
+}
+
+func TestExecute__contextCancelsBackoff(t *testing.T) {
+	c := newTestClient(t, WithJitter(false), WithRetryPolicy(
+		[]time.Duration{time.Hour},
+		isRetryable,
+	))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.Execute(ctx, func() (any, error) {
+		return nil, errUnavailable
+	}, "/", "test")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestExecute__retryAfter(t *testing.T) {
+	c := newTestClient(t,
+		WithJitter(false),
+		WithMaxRetryAfter(time.Second),
+		WithRetryPolicy([]time.Duration{time.Hour}, IsRetryableHTTPRequest),
+	)
+
+	calls := 0
+	result, err := c.Execute(context.Background(), func() (any, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{"0"}},
+			}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}, "/", "test")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.(*http.Response).StatusCode)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	result, err = c.Execute(context.Background(), func() (any, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"120"}},
+		}, nil
+	}, "/", "test")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, result.(*http.Response).StatusCode)
+	assert.Equal(t, 1, calls, "retry after above the limit must not be awaited")
+}
+
+func TestExecute__grpcPushback(t *testing.T) {
+	c := newTestClient(t,
+		WithJitter(false),
+		WithRetryPolicy([]time.Duration{time.Hour}, IsRetryableGRPCRequest),
+	)
+	unavailable := status.Error(codes.Unavailable, "overloaded")
+
+	calls := 0
+	_, err := c.Execute(context.Background(), func() (any, error) {
+		calls++
+		if calls == 1 {
+			return nil, GRPCPushbackError(unavailable, metadata.Pairs(grpcPushbackKey, "1"))
+		}
+		return nil, nil
+	}, "/", "grpc")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	_, err = c.Execute(context.Background(), func() (any, error) {
+		calls++
+		return nil, GRPCPushbackError(unavailable, metadata.Pairs(grpcPushbackKey, "-1"))
+	}, "/", "grpc")
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestExecute__retryBudget(t *testing.T) {
+	c := newTestClient(t,
+		WithRetryBudget(4, 0.5),
+		WithRetryPolicy([]time.Duration{0, 0, 0, 0, 0}, isRetryable),
+	)
+
+	calls := 0
+	_, err := c.Execute(context.Background(), func() (any, error) {
+		calls++
+		return nil, errUnavailable
+	}, "/", "test")
+	require.ErrorIs(t, err, errUnavailable)
+	// Токены: 4 -> 3 -> 2, после второй неудачи ретраи запрещены.
+	assert.Equal(t, 2, calls)
+}
+
+func TestExecute__circuitBreaker(t *testing.T) {
+	c := newTestClient(t, WithCircuitBreaker(2, time.Minute))
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+
+	calls := 0
+	fail := func() (any, error) {
+		calls++
+		return nil, errUnavailable
+	}
+	for range 2 {
+		_, err := c.Execute(context.Background(), fail, "/", "test")
+		require.ErrorIs(t, err, errUnavailable)
+	}
+	_, err := c.Execute(context.Background(), fail, "/", "test")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, calls)
+
+	now = now.Add(time.Minute)
+	_, err = c.Execute(context.Background(), fail, "/", "test")
+	require.ErrorIs(t, err, errUnavailable, "half-open probe must reach the server")
+	_, err = c.Execute(context.Background(), fail, "/", "test")
+	require.ErrorIs(t, err, ErrCircuitOpen, "failed probe must open the circuit again")
+
+	now = now.Add(time.Minute)
+	_, err = c.Execute(context.Background(), func() (any, error) { return "ok", nil }, "/", "test")
+	require.NoError(t, err)
+	_, err = c.Execute(context.Background(), fail, "/", "test")
+	require.ErrorIs(t, err, errUnavailable)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"empty", "", 0, false},
+		{"seconds", "3", 3 * time.Second, true},
+		{"negative", "-1", 0, false},
+		{"date", now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, true},
+		{"date in past", now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"garbage", "soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,14 +2,21 @@ package metric
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/NStegura/metrics/internal/utils/idempotency"
 	"github.com/NStegura/metrics/internal/utils/ip"
+	"github.com/NStegura/metrics/internal/utils/replay"
+	"github.com/NStegura/metrics/internal/utils/signing"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/NStegura/metrics/internal/clients/base"
 	"github.com/NStegura/metrics/pkg/api"
@@ -22,16 +29,26 @@ type GRPCClient struct {
 }
 
 func NewGRPCClient(addr string, options ...base.Option) (*GRPCClient, error) {
-	conn, err := grpc.NewClient(addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	bc, err := base.NewBaseClient(options...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return nil, fmt.Errorf("failed to init client: %w", err)
 	}
 
-	bc, err := base.NewBaseClient(options...)
+	creds := insecure.NewCredentials()
+	if bc.TLSConfig != nil {
+		creds = credentials.NewTLS(bc.TLSConfig)
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(signInterceptor(bc)),
+	}
+	if bc.CompressType != "" {
+		// Кодеки из пакета compress зарегистрированы в gRPC под теми же именами.
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(bc.CompressType)))
+	}
+	conn, err := grpc.NewClient(addr, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init client: %w", err)
+		return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
 
 	return &GRPCClient{
@@ -41,7 +58,16 @@ func NewGRPCClient(addr string, options ...base.Option) (*GRPCClient, error) {
 	}, nil
 }
 
-// UpdateMetrics обновляет набор метрик.
+// Close закрывает подключение к серверу.
+func (c *GRPCClient) Close() error {
+	if err := c.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close gRPC connection: %w", err)
+	}
+	return nil
+}
+
+// UpdateMetrics обновляет набор метрик, разбивая его на части по лимитам клиента.
+// С ключом пакета из idempotency.WithKey повтор того же набора сервер не применит второй раз.
 func (c *GRPCClient) UpdateMetrics(ctx context.Context, metrics []Metrics) error {
 	ml := c.convert(metrics)
 
@@ -49,18 +75,69 @@ func (c *GRPCClient) UpdateMetrics(ctx context.Context, metrics []Metrics) error
 	if err != nil {
 		return fmt.Errorf("failed to prepare ctx: %w", err)
 	}
-	_, err = c.Execute(
-		func() (any, error) {
-			return c.client.UpdateAllMetrics(ctx, &api.MetricsList{Metrics: ml}) //nolint:wrapcheck // proxy
-		},
-		c.conn.Target(),
-		"grpc",
-	)
-	if err != nil {
-		return fmt.Errorf("failed to send metrics via gRPC: %w", err)
+
+	var errs []error
+	for i, chunk := range base.Chunk(c.BaseClient, ml, protoSize) {
+		// ключ общий для всех повторов части, чтобы сервер не применил ее дважды.
+		batchID, err := idempotency.ChunkKey(ctx, i)
+		if err != nil {
+			return err //nolint:wrapcheck // ошибка уже с контекстом
+		}
+		chunkCtx := metadata.AppendToOutgoingContext(ctx, idempotency.Key, batchID)
+		_, err = c.Execute(
+			chunkCtx,
+			func() (any, error) {
+				var trailer metadata.MD
+				resp, err := c.client.UpdateAllMetrics(
+					chunkCtx, &api.MetricsList{Metrics: chunk}, grpc.Trailer(&trailer))
+				return resp, base.GRPCPushbackError(err, trailer)
+			},
+			c.conn.Target(),
+			"grpc",
+		)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to send metrics via gRPC: %w", err))
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	return nil
+// protoSize возвращает размер метрики в protobuf с учетом тега и длины поля.
+func protoSize(m *api.Metric) int {
+	return protowire.SizeBytes(proto.Size(m)) + 1
+}
+
+// signInterceptor подписывает сериализованный запрос ключом BodyHashKey и ключом агента
+// вместе с меткой времени и nonce, перехватчик вызывается на каждую попытку.
+func signInterceptor(bc *base.BaseClient) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if msg, ok := req.(proto.Message); ok && (bc.BodyHashKey != "" || bc.Signer != nil) {
+			body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+			if err != nil {
+				return fmt.Errorf("failed to marshal request: %w", err)
+			}
+			sign, _, err := bc.Sign(body)
+			if err != nil {
+				return fmt.Errorf("failed to Sign: %w", err)
+			}
+			kv := []string{replay.TimestampKey, sign.Timestamp, replay.NonceKey, sign.Nonce}
+			if sign.Hash != "" {
+				kv = append(kv, "hashsha256", sign.Hash)
+			}
+			if sign.KeyID != "" {
+				kv = append(kv, signing.KeyIDKey, sign.KeyID, signing.SignatureKey, sign.Value)
+			}
+			ctx = metadata.AppendToOutgoingContext(ctx, kv...)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
 }
 
 func (c *GRPCClient) prepareCtx(ctx context.Context) (context.Context, error) {
@@ -4,13 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/NStegura/metrics/internal/utils/idempotency"
 	"github.com/NStegura/metrics/internal/utils/ip"
+	"github.com/NStegura/metrics/internal/utils/replay"
+	rsaKeys "github.com/NStegura/metrics/internal/utils/rsa"
+	"github.com/NStegura/metrics/internal/utils/signing"
 
 	"github.com/NStegura/metrics/internal/clients/base"
 )
@@ -23,24 +28,36 @@ type Client struct {
 }
 
 func NewHTTPClient(addr string, options ...base.Option) (*Client, error) {
-	var err error
+	bc, err := base.NewBaseClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init client: %w", err)
+	}
+
+	client := &http.Client{}
+	scheme := "http:"
+	if bc.TLSConfig != nil {
+		client.Transport = &http.Transport{TLSClientConfig: bc.TLSConfig}
+		scheme = "https:"
+	}
 	if !strings.HasPrefix(addr, "http") {
-		addr, err = url.JoinPath("http:", addr)
+		addr, err = url.JoinPath(scheme, addr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to init client, %w", err)
 		}
 	}
-	bc, err := base.NewBaseClient(options...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to init client: %w", err)
-	}
 	return &Client{
 		BaseClient: bc,
-		client:     &http.Client{},
+		client:     client,
 		URL:        addr,
 	}, nil
 }
 
+// Close закрывает простаивающие соединения с сервером.
+func (c *Client) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
 type RequestError struct {
 	URL        *url.URL
 	Body       []byte
@@ -63,12 +80,14 @@ func NewRequestError(response *http.Response) error {
 }
 
 // UpdateGaugeMetric обновляет gauge метрику.
-func (c *Client) UpdateGaugeMetric(name string, value float64) error {
+func (c *Client) UpdateGaugeMetric(ctx context.Context, name string, value float64) error {
 	resp, err := c.do(
+		ctx,
 		fmt.Sprintf("%s/update/gauge/%s/%v", c.URL, name, value),
 		http.MethodPost,
 		"text/plain",
 		nil,
+		nil,
 	)
 	if err != nil {
 		return err
@@ -87,12 +106,14 @@ func (c *Client) UpdateGaugeMetric(name string, value float64) error {
 }
 
 // UpdateCounterMetric обновляет counter метрику.
-func (c *Client) UpdateCounterMetric(name string, value int64) error {
+func (c *Client) UpdateCounterMetric(ctx context.Context, name string, value int64) error {
 	resp, err := c.do(
+		ctx,
 		fmt.Sprintf("%s/update/counter/%s/%v", c.URL, name, value),
 		http.MethodPost,
 		"text/plain",
 		nil,
+		nil,
 	)
 	if err != nil {
 		return err
@@ -111,12 +132,14 @@ func (c *Client) UpdateCounterMetric(name string, value int64) error {
 }
 
 // UpdateMetric обновляет метрику.
-func (c *Client) UpdateMetric(jsonBody []byte) error {
+func (c *Client) UpdateMetric(ctx context.Context, jsonBody []byte) error {
 	resp, err := c.do(
+		ctx,
 		fmt.Sprintf("%s/update/", c.URL),
 		http.MethodPost,
 		"application/json",
 		jsonBody,
+		nil,
 	)
 	if err != nil {
 		return err
@@ -134,23 +157,41 @@ func (c *Client) UpdateMetric(jsonBody []byte) error {
 	return nil
 }
 
-// UpdateMetrics обновляет набор метрик.
-func (c *Client) UpdateMetrics(_ context.Context, metrics []Metrics) error {
+// UpdateMetrics обновляет набор метрик, разбивая его на части по лимитам клиента.
+// С ключом пакета из idempotency.WithKey повтор того же набора сервер не применит второй раз.
+func (c *Client) UpdateMetrics(ctx context.Context, metrics []Metrics) error {
 	if len(metrics) == 0 {
 		c.Logger.Info("Empty metric result")
 		return nil
 	}
 
+	var errs []error
+	for i, chunk := range base.Chunk(c.BaseClient, metrics, Metrics.Size) {
+		if err := c.updateMetrics(ctx, i, chunk); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) updateMetrics(ctx context.Context, chunk int, metrics []Metrics) error {
 	jsonBody, err := json.Marshal(metrics)
 	if err != nil {
 		return fmt.Errorf("failed to decode metrics, err %w", err)
 	}
+	// ключ общий для всех повторов части, чтобы сервер не применил ее дважды.
+	batchID, err := idempotency.ChunkKey(ctx, chunk)
+	if err != nil {
+		return err //nolint:wrapcheck // ошибка уже с контекстом
+	}
 
 	resp, err := c.do(
+		ctx,
 		fmt.Sprintf("%s/updates/", c.URL),
 		http.MethodPost,
 		"application/json",
 		jsonBody,
+		map[string]string{idempotency.Header: batchID},
 	)
 	if err != nil {
 		return err
@@ -169,29 +210,61 @@ func (c *Client) UpdateMetrics(_ context.Context, metrics []Metrics) error {
 }
 
 func (c *Client) do(
+	ctx context.Context,
 	url string,
 	method string, //nolint:unparam // потом не только post
 	contentType string,
 	body []byte,
+	extraHeaders map[string]string,
 ) (resp *http.Response, err error) {
+	plainBody := body
 	body, headers, err := c.prepareRequest(contentType, body)
 	if err != nil {
 		return nil, err
 	}
-
-	bodyReader := bytes.NewReader(body)
-	req, err := http.NewRequest(method, url, bodyReader)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	for h, v := range extraHeaders {
+		headers[h] = v
 	}
 
-	for h, v := range headers {
-		req.Header.Set(h, v)
+	// Запрос собирается и подписывается на каждую попытку,
+	// чтобы тело читалось заново, а nonce не повторялся.
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		for h, v := range headers {
+			req.Header.Set(h, v)
+		}
+		sign, included, err := c.Sign(plainBody)
+		if included {
+			if err != nil {
+				return nil, fmt.Errorf("failed to Sign: %w", err)
+			}
+			if sign.Hash != "" {
+				req.Header.Set("HashSHA256", sign.Hash)
+			}
+			if sign.KeyID != "" {
+				req.Header.Set(signing.KeyIDHeader, sign.KeyID)
+				req.Header.Set(signing.SignatureHeader, sign.Value)
+			}
+			req.Header.Set(replay.TimestampHeader, sign.Timestamp)
+			req.Header.Set(replay.NonceHeader, sign.Nonce)
+		}
+		return req, nil
+	}
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
 	}
 
 	execute, err := c.Execute(
+		ctx,
 		func() (any, error) {
+			req, err := newRequest()
+			if err != nil {
+				return nil, err
+			}
 			return c.client.Do(req) //nolint // закрытие тела происходит в другом месте
 		},
 		req.URL.Path,
@@ -212,33 +285,28 @@ func (c *Client) prepareRequest(
 	body []byte,
 ) ([]byte, map[string]string, error) {
 	var (
-		included      bool
-		hash          string
-		encryptedBody []byte
-		err           error
+		included       bool
+		encryptedBody  []byte
+		compressedBody []byte
+		err            error
 	)
 
 	headers := make(map[string]string)
 	headers["Content-Type"] = contentType
 
-	if hash, included, err = c.GenerateHMAC(body); included {
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to GenerateHMAC: %w", err)
-		}
-		headers["HashSHA256"] = hash
-	}
-
 	if encryptedBody, included, err = c.Encrypt(body); included {
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to Encrypt: %w", err)
 		}
 		body = encryptedBody
+		headers[rsaKeys.KeyIDHeader] = c.CryptoKeyID
 	}
 
-	if body, included, err = c.Compress(body); included {
+	if compressedBody, included, err = c.Compress(body); included {
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to Compress: %w", err)
 		}
+		body = compressedBody
 		headers["Accept-Encoding"] = c.CompressType
 		headers["Content-Encoding"] = c.CompressType
 	}
@@ -2,12 +2,15 @@ package metric
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/NStegura/metrics/internal/app/metricsapi/httpserver"
 	"github.com/NStegura/metrics/internal/clients/base"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
 
 	"github.com/NStegura/metrics/config"
 
@@ -68,7 +71,7 @@ func TestUpdateCounterMetric(t *testing.T) {
 	th := initTestHelper(t)
 	defer th.finish()
 
-	err := th.cli.UpdateCounterMetric("test_counter_metric", 1)
+	err := th.cli.UpdateCounterMetric(context.TODO(), "test_counter_metric", 1)
 	require.NoError(t, err)
 }
 
@@ -76,7 +79,7 @@ func TestUpdateGaugeMetric(t *testing.T) {
 	th := initTestHelper(t)
 	defer th.finish()
 
-	err := th.cli.UpdateGaugeMetric("test_gauge_metric", 1)
+	err := th.cli.UpdateGaugeMetric(context.TODO(), "test_gauge_metric", 1)
 	require.NoError(t, err)
 }
 
@@ -111,6 +114,109 @@ func TestUpdateMetrics(t *testing.T) {
 	}
 }
 
+func TestChunkMetrics(t *testing.T) {
+	delta := int64(1)
+	metrics := make([]Metrics, 0, 5)
+	for i := 0; i < 5; i++ {
+		metrics = append(metrics, Metrics{ID: fmt.Sprintf("m%v", i), MType: "counter", Delta: &delta})
+	}
+	itemSize := metrics[0].Size()
+
+	tests := []struct {
+		name    string
+		options []base.Option
+		want    []int
+	}{
+		{name: "unlimited", want: []int{5}},
+		{name: "by count", options: []base.Option{base.WithMaxBatchMetrics(2)}, want: []int{2, 2, 1}},
+		{name: "by bytes", options: []base.Option{base.WithMaxBatchBytes(3*itemSize + 5)}, want: []int{3, 2}},
+		{name: "item larger than limit", options: []base.Option{base.WithMaxBatchBytes(1)}, want: []int{1, 1, 1, 1, 1}},
+	}
+	for _, v := range tests {
+		bc, err := base.NewBaseClient(v.options...)
+		require.NoError(t, err)
+
+		chunks := base.Chunk(bc, metrics, Metrics.Size)
+		sizes := make([]int, 0, len(chunks))
+		for _, chunk := range chunks {
+			sizes = append(sizes, len(chunk))
+		}
+		assert.Equal(t, v.want, sizes, v.name)
+	}
+}
+
+func TestUpdateMetrics__chunked(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+	th.cli.MaxBatchMetrics = 1
+
+	delta := int64(1)
+	value := 1.1
+	err := th.cli.UpdateMetrics(context.Background(), []Metrics{
+		{ID: "chunked_counter", MType: "counter", Delta: &delta},
+		{ID: "chunked_gauge", MType: "gauge", Value: &value},
+	})
+	require.NoError(t, err)
+}
+
+func TestUpdateMetrics__compressTypes(t *testing.T) {
+	th := initTestHelper(t)
+	defer th.finish()
+
+	delta := int64(1)
+	for _, compressType := range []string{"", "gzip", "zstd", "snappy"} {
+		th.cli.CompressType = compressType
+		err := th.cli.UpdateMetrics(context.Background(), []Metrics{
+			{ID: "compressed_counter", MType: "counter", Delta: &delta},
+		})
+		require.NoError(t, err, compressType)
+	}
+
+	_, err := NewHTTPClient(th.ts.URL, base.WithCompressType("br"))
+	require.Error(t, err)
+}
+
+func TestUpdateMetrics__idempotentRetry(t *testing.T) {
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	businessLayer := business.New(r, l)
+	server, err := httpserver.New(config.NewSrvConfig(), businessLayer, l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+
+	// первый ответ теряется после применения пакета, агент повторяет запрос.
+	var batchIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batchIDs = append(batchIDs, r.Header.Get(idempotency.Header))
+		if len(batchIDs) == 1 {
+			server.Router.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		server.Router.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	cli, err := NewHTTPClient(ts.URL,
+		base.WithLogger(l),
+		base.WithRetryPolicy([]time.Duration{time.Millisecond}, base.IsRetryableHTTPRequest),
+	)
+	require.NoError(t, err)
+
+	delta := int64(3)
+	require.NoError(t, cli.UpdateMetrics(context.Background(), []Metrics{
+		{ID: "idempotent_counter", MType: "counter", Delta: &delta},
+	}))
+
+	require.Len(t, batchIDs, 2)
+	assert.NotEmpty(t, batchIDs[0])
+	assert.Equal(t, batchIDs[0], batchIDs[1])
+	value, err := businessLayer.GetCounterMetric(context.Background(), "idempotent_counter")
+	require.NoError(t, err)
+	assert.Equal(t, delta, value)
+}
+
 func TestUpdateMetric(t *testing.T) {
 	th := initTestHelper(t)
 	defer th.finish()
@@ -126,7 +232,7 @@ func TestUpdateMetric(t *testing.T) {
 	}
 
 	for _, v := range tests {
-		err := th.cli.UpdateMetric([]byte(v.body))
+		err := th.cli.UpdateMetric(context.TODO(), []byte(v.body))
 		require.NoError(t, err)
 	}
 }
@@ -1,6 +1,10 @@
 package metric
 
-import "github.com/NStegura/metrics/internal/app/agent/models"
+import (
+	"encoding/json"
+
+	"github.com/NStegura/metrics/internal/app/agent/models"
+)
 
 type Metrics struct {
 	Delta *int64   `json:"delta,omitempty"`
@@ -26,3 +30,12 @@ func CastToMetrics(m models.Metrics) (metrics []Metrics) {
 	}
 	return metrics
 }
+
+// Size возвращает размер метрики в JSON.
+func (m Metrics) Size() int {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
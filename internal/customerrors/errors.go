@@ -5,7 +5,13 @@ import (
 	"fmt"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrNoHistory     = errors.New("metric history unavailable")
+	ErrInvalidBatch  = errors.New("invalid batch")
+)
 
 type ParseURLError struct {
 	URL string
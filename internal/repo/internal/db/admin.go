@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// uniqueViolation - код ошибки Postgres при нарушении уникальности.
+const uniqueViolation = "23505"
+
+// DeleteMetric удаляет метрику, история метрики сохраняется.
+func (db *DB) DeleteMetric(ctx context.Context, mType string, name string) error {
+	db.logger.Debugf("DeleteMetric name %s, mtype %s", name, mType)
+	const query = `
+		DELETE FROM "metric_actual" ma
+		USING "metric_type" mt
+		WHERE mt.id = ma.type_id AND ma.name = $1 AND mt.name::text = $2;
+	`
+	cmd, err := db.conn(ctx).Exec(ctx, query, name, mType)
+	if err != nil {
+		return fmt.Errorf("DeleteMetric failed, %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return customerrors.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteMetrics удаляет метрики по условиям фильтра и возвращает их количество,
+// курсор, сортировка и лимит фильтра не учитываются.
+func (db *DB) DeleteMetrics(ctx context.Context, filter models.ListFilter) (int64, error) {
+	db.logger.Debugf("DeleteMetrics filter %+v", filter)
+	var args queryArgs
+	conds, err := filterConditions(filter, &args)
+	if err != nil {
+		return 0, err
+	}
+	query := `
+		DELETE FROM "metric_actual" ma
+		USING "metric_type" mt
+		WHERE ` + strings.Join(append([]string{"mt.id = ma.type_id"}, conds...), " AND ")
+
+	cmd, err := db.conn(ctx).Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteMetrics failed, %w", err)
+	}
+	return cmd.RowsAffected(), nil
+}
+
+// RenameMetric переименовывает метрику вместе с ее историей.
+func (db *DB) RenameMetric(ctx context.Context, mType string, name string, newName string) error {
+	db.logger.Debugf("RenameMetric name %s, mtype %s, new name %s", name, mType, newName)
+
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("BeginTx RenameMetric failed, %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const query = `
+		UPDATE "metric_actual" ma
+		SET name = $2, updated_at = $4
+		FROM "metric_type" mt
+		WHERE mt.id = ma.type_id AND ma.name = $1 AND mt.name::text = $3;
+	`
+	cmd, err := tx.Exec(ctx, query, name, newName, mType, time.Now())
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return customerrors.ErrAlreadyExists
+		}
+		return fmt.Errorf("RenameMetric failed, %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return customerrors.ErrNotFound
+	}
+
+	const historyQuery = `
+		UPDATE "metric_history" mh
+		SET name = $2
+		FROM "metric_type" mt
+		WHERE mt.id = mh.type_id AND mh.name = $1 AND mt.name::text = $3;
+	`
+	if _, err = tx.Exec(ctx, historyQuery, name, newName, mType); err != nil {
+		return fmt.Errorf("rename metric history failed, %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit RenameMetric failed, %w", err)
+	}
+	return nil
+}
+
+// ResetCounter обнуляет counter метрику, сброс попадает в историю.
+func (db *DB) ResetCounter(ctx context.Context, name string) error {
+	db.logger.Debugf("ResetCounter name %s", name)
+
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("BeginTx ResetCounter failed, %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	const query = `
+		UPDATE "metric_actual" ma
+		SET value = 0, updated_at = $2
+		FROM "metric_type" mt
+		WHERE mt.id = ma.type_id AND ma.name = $1 AND mt.name = 'counter';
+	`
+	cmd, err := tx.Exec(ctx, query, name, time.Now())
+	if err != nil {
+		return fmt.Errorf("ResetCounter failed, %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return customerrors.ErrNotFound
+	}
+	db.createHistoryMetric(ctx, tx, "counter", name, int64(0))
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit ResetCounter failed, %w", err)
+	}
+	return nil
+}
@@ -17,6 +17,9 @@ import (
 	"github.com/NStegura/metrics/internal/repo/models"
 )
 
+// batchTTL - сколько хранятся ключи примененных пакетов, с запасом покрывает время повторов агента.
+const batchTTL = 24 * time.Hour
+
 type DB struct {
 	pool *pgxpool.Pool
 
@@ -78,7 +81,7 @@ func (db *DB) GetCounterMetric(ctx context.Context, name string) (cm models.Coun
 		WHERE ma.name = $1; 
 	`
 
-	err = db.pool.QueryRow(ctx, query, name).Scan(
+	err = db.conn(ctx).QueryRow(ctx, query, name).Scan(
 		&cm.Name,
 		&cm.Type,
 		&cm.Value,
@@ -98,10 +101,13 @@ func (db *DB) GetCounterMetric(ctx context.Context, name string) (cm models.Coun
 func (db *DB) CreateCounterMetric(ctx context.Context, name string, mType string, value int64) error {
 	db.logger.Debugf("CreateCounterMetric name %s, mtype %s, value %v", name, mType, value)
 
-	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("BeginTx CreateCounterMetric failed, %w", err)
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	var ID int64
 
@@ -133,10 +139,13 @@ func (db *DB) CreateCounterMetric(ctx context.Context, name string, mType string
 func (db *DB) UpdateCounterMetric(ctx context.Context, name string, value int64) error {
 	db.logger.Debugf("UpdateCounterMetric name %s, value %v", name, value)
 
-	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("BeginTx UpdateCounterMetric failed, %w", err)
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	const query = `
 		UPDATE "metric_actual"
@@ -157,7 +166,7 @@ func (db *DB) UpdateCounterMetric(ctx context.Context, name string, value int64)
 		return err
 	}
 
-	db.createHistoryMetric(ctx, tx, "gauge", name, value)
+	db.createHistoryMetric(ctx, tx, "counter", name, value)
 	err = tx.Commit(ctx)
 	if err != nil {
 		return fmt.Errorf("commit UpdateCounterMetric failed, %w", err)
@@ -175,7 +184,7 @@ func (db *DB) GetGaugeMetric(ctx context.Context, name string) (gm models.GaugeM
 		WHERE ma.name = $1; 
 	`
 
-	err = db.pool.QueryRow(ctx, query, name).Scan(
+	err = db.conn(ctx).QueryRow(ctx, query, name).Scan(
 		&gm.Name,
 		&gm.Type,
 		&gm.Value,
@@ -196,10 +205,13 @@ func (db *DB) GetGaugeMetric(ctx context.Context, name string) (gm models.GaugeM
 func (db *DB) CreateGaugeMetric(ctx context.Context, name string, mType string, value float64) error {
 	db.logger.Debugf("CreateGaugeMetric name %s, mtype %s, value %v", name, mType, value)
 
-	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("BeginTx CreateGaugeMetric failed, %w", err)
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	var ID int64
 
@@ -231,10 +243,13 @@ func (db *DB) CreateGaugeMetric(ctx context.Context, name string, mType string,
 func (db *DB) UpdateGaugeMetric(ctx context.Context, name string, value float64) error {
 	db.logger.Debugf("UpdateGaugeMetric name %s, value %v", name, value)
 
-	tx, err := db.pool.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := db.conn(ctx).Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("BeginTx UpdateGaugeMetric failed, %w", err)
 	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
 
 	const query = `
 		UPDATE "metric_actual"
@@ -267,20 +282,21 @@ func (db *DB) GetAllMetrics(ctx context.Context) (gms []models.GaugeMetric, cms
 	db.logger.Debug("GetAllMetrics")
 
 	type metric struct {
-		Name  string
-		Type  string
-		Value float64
+		UpdatedAt time.Time
+		Name      string
+		Type      string
+		Value     float64
 	}
 
 	allMetrics := make([]metric, 0)
 
 	const query = `
-		SELECT ma.name, mt.name, ma.value
+		SELECT ma.name, mt.name, ma.value, ma.updated_at
 		FROM "metric_actual" ma
 		INNER JOIN metric_type mt on mt.id = ma.type_id;
 	`
 
-	rows, err := db.pool.Query(ctx, query)
+	rows, err := db.conn(ctx).Query(ctx, query)
 	if err != nil {
 		err = fmt.Errorf("get all metric failed, %w", err)
 		return
@@ -294,6 +310,7 @@ func (db *DB) GetAllMetrics(ctx context.Context) (gms []models.GaugeMetric, cms
 			&m.Name,
 			&m.Type,
 			&m.Value,
+			&m.UpdatedAt,
 		)
 		allMetrics = append(allMetrics, m)
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -305,14 +322,43 @@ func (db *DB) GetAllMetrics(ctx context.Context) (gms []models.GaugeMetric, cms
 	for _, m := range allMetrics {
 		switch m.Type {
 		case "gauge":
-			gms = append(gms, models.GaugeMetric{Name: m.Name, Type: m.Type, Value: m.Value})
+			gms = append(gms, models.GaugeMetric{Name: m.Name, Type: m.Type, Value: m.Value, UpdatedAt: m.UpdatedAt})
 		case "counter":
-			cms = append(cms, models.CounterMetric{Name: m.Name, Type: m.Type, Value: int64(m.Value)})
+			cms = append(cms, models.CounterMetric{
+				Name: m.Name, Type: m.Type, Value: int64(m.Value), UpdatedAt: m.UpdatedAt,
+			})
 		}
 	}
 	return
 }
 
+// ReserveBatch сохраняет ключ пакета, false - пакет с этим ключом уже применялся.
+// Внутри InTx ключ сохраняется в той же транзакции, что и обновления пакета:
+// при откате он исчезает вместе с ними, а повтор с тем же ключом ждет ее завершения.
+// Заодно удаляет ключи старше batchTTL, чтобы таблица не росла.
+func (db *DB) ReserveBatch(ctx context.Context, batchID string) (bool, error) {
+	db.logger.Debugf("ReserveBatch id %s", batchID)
+
+	const cleanupQuery = `
+		DELETE FROM "applied_batch"
+		WHERE created_at < $1;
+	`
+	if _, err := db.pool.Exec(ctx, cleanupQuery, time.Now().Add(-batchTTL)); err != nil {
+		return false, fmt.Errorf("cleanup applied batches failed, %w", err)
+	}
+
+	const query = `
+		INSERT INTO "applied_batch" (id)
+		VALUES ($1)
+		ON CONFLICT (id) DO NOTHING;
+	`
+	cmd, err := db.conn(ctx).Exec(ctx, query, batchID)
+	if err != nil {
+		return false, fmt.Errorf("ReserveBatch failed, %w", err)
+	}
+	return cmd.RowsAffected() == 1, nil
+}
+
 func (db *DB) createHistoryMetric(ctx context.Context, tx pgx.Tx, mType string, name string, value interface{}) {
 	db.logger.Debugf("createHistoryMetric name %s, mtype %s, value %v", name, mType, value)
 	switch value.(type) {
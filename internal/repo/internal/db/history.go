@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// HistoryAvailable сообщает, что репозиторий хранит историю метрик.
+func (db *DB) HistoryAvailable() bool {
+	return true
+}
+
+// GetMetricHistory возвращает последние limit значений метрики по возрастанию времени.
+func (db *DB) GetMetricHistory(
+	ctx context.Context,
+	mType string,
+	name string,
+	limit int,
+) ([]models.HistoryPoint, error) {
+	db.logger.Debugf("GetMetricHistory name %s, mtype %s", name, mType)
+	const query = `
+		SELECT mh.created_at, mh.value
+		FROM "metric_history" mh
+		INNER JOIN metric_type mt on mt.id = mh.type_id
+		WHERE mh.name = $1 AND mt.name::text = $2
+		ORDER BY mh.created_at DESC, mh.id DESC
+		LIMIT $3;
+	`
+	rows, err := db.conn(ctx).Query(ctx, query, name, mType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get metric history failed, %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]models.HistoryPoint, 0, limit)
+	for rows.Next() {
+		var p models.HistoryPoint
+		if err = rows.Scan(&p.CreatedAt, &p.Value); err != nil {
+			return nil, fmt.Errorf("scan metric history failed, %w", err)
+		}
+		points = append(points, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("get metric history failed, %w", err)
+	}
+	slices.Reverse(points)
+	return points, nil
+}
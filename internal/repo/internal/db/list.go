@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// likeEscaper экранирует спецсимволы LIKE, экранирующий символ по умолчанию - обратный слеш.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListMetrics возвращает метрики по фильтру. Условия, сортировка, курсор и лимит
+// выполняются в запросе, чтобы не читать всю таблицу. Glob переводится в выражение без вложенных
+// повторений и проверяется оператором ~. Regex пользователя проверяется regexp с линейным
+// временем, а не движком Postgres с возвратами: строки читаются по порядку, пока не набран лимит.
+func (db *DB) ListMetrics(ctx context.Context, filter models.ListFilter) ([]models.Metric, error) {
+	db.logger.Debugf("ListMetrics filter %+v", filter)
+
+	var re *regexp.Regexp
+	if filter.Regex != "" {
+		var err error
+		if re, err = regexp.Compile(filter.Regex); err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	query, args, err := listQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list metrics failed, %w", err)
+	}
+	defer rows.Close()
+
+	var metrics []models.Metric
+	for rows.Next() {
+		var (
+			m     models.Metric
+			value float64
+		)
+		if err = rows.Scan(&m.Name, &m.Type, &value, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan metric failed, %w", err)
+		}
+		if re != nil && !re.MatchString(m.Name) {
+			continue
+		}
+		if m.Type == "counter" {
+			m.Delta = int64(value)
+		} else {
+			m.Value = value
+		}
+		metrics = append(metrics, m)
+		if filter.Limit > 0 && len(metrics) == filter.Limit {
+			break
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list metrics failed, %w", err)
+	}
+	return metrics, nil
+}
+
+// queryArgs собирает параметры запроса и возвращает их плейсхолдеры.
+type queryArgs []any
+
+func (a *queryArgs) add(v any) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// filterConditions переводит условия фильтра по типу и имени в SQL, без курсора и сортировки.
+// Regex в запрос не попадает, его проверяет ListMetrics.
+func filterConditions(filter models.ListFilter, args *queryArgs) ([]string, error) {
+	var conds []string
+	arg := args.add
+	if filter.Type != "" {
+		conds = append(conds, "mt.name::text = "+arg(filter.Type))
+	}
+	if filter.Prefix != "" {
+		conds = append(conds, "ma.name LIKE "+arg(likeEscaper.Replace(filter.Prefix)+"%"))
+	}
+	if filter.Glob != "" {
+		expr, err := models.GlobToRegexp(filter.Glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob: %w", err)
+		}
+		conds = append(conds, "ma.name ~ "+arg(expr))
+	}
+	return conds, nil
+}
+
+// listQuery собирает запрос списка метрик с параметрами.
+func listQuery(filter models.ListFilter) (string, []any, error) {
+	var args queryArgs
+	arg := args.add
+	conds, err := filterConditions(filter, &args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	op, dir := ">", "ASC"
+	if filter.Desc {
+		op, dir = "<", "DESC"
+	}
+	order := "ma.name " + dir + ", mt.name::text " + dir
+	if filter.Sort == models.SortByUpdatedAt {
+		order = "ma.updated_at " + dir + ", " + order
+	}
+	if filter.After != nil {
+		if filter.Sort == models.SortByUpdatedAt {
+			conds = append(conds, fmt.Sprintf("(ma.updated_at, ma.name, mt.name::text) %s (%s, %s, %s)",
+				op, arg(filter.After.UpdatedAt), arg(filter.After.Name), arg(filter.After.Type)))
+		} else {
+			conds = append(conds, fmt.Sprintf("(ma.name, mt.name::text) %s (%s, %s)",
+				op, arg(filter.After.Name), arg(filter.After.Type)))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`
+		SELECT ma.name, mt.name, ma.value, ma.updated_at
+		FROM "metric_actual" ma
+		INNER JOIN "metric_type" mt on mt.id = ma.type_id`)
+	if len(conds) > 0 {
+		sb.WriteString("\n\t\tWHERE ")
+		sb.WriteString(strings.Join(conds, " AND "))
+	}
+	sb.WriteString("\n\t\tORDER BY " + order)
+	if filter.Limit > 0 && filter.Regex == "" {
+		sb.WriteString("\n\t\tLIMIT " + arg(filter.Limit))
+	}
+
+	return sb.String(), args, nil
+}
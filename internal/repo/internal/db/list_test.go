@@ -0,0 +1,76 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+func TestListQuery(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    models.ListFilter
+		wantWhere string
+		wantOrder string
+		wantArgs  []any
+	}{
+		{
+			name:      "no filter",
+			filter:    models.ListFilter{Sort: models.SortByName},
+			wantOrder: "ORDER BY ma.name ASC, mt.name::text ASC",
+		},
+		{
+			name: "all conditions",
+			filter: models.ListFilter{
+				Type:   "gauge",
+				Prefix: "heap_%",
+				Glob:   "*.sys",
+				Sort:   models.SortByName,
+				After:  &models.Cursor{Name: "heap_a", Type: "gauge"},
+				Limit:  11,
+			},
+			wantWhere: "WHERE mt.name::text = $1 AND ma.name LIKE $2 AND ma.name ~ $3 AND (ma.name, mt.name::text) > ($4, $5)",
+			wantOrder: "ORDER BY ma.name ASC, mt.name::text ASC\n\t\tLIMIT $6",
+			wantArgs:  []any{"gauge", `heap\_\%%`, `^.*\.sys$`, "heap_a", "gauge", 11},
+		},
+		{
+			name:      "regex is not sent to postgres",
+			filter:    models.ListFilter{Regex: "(a+)+$", Sort: models.SortByName, Limit: 11},
+			wantOrder: "ORDER BY ma.name ASC, mt.name::text ASC",
+		},
+		{
+			name: "updated_at desc",
+			filter: models.ListFilter{
+				Sort:  models.SortByUpdatedAt,
+				Desc:  true,
+				After: &models.Cursor{Name: "a", Type: "counter", UpdatedAt: updatedAt},
+			},
+			wantWhere: "WHERE (ma.updated_at, ma.name, mt.name::text) < ($1, $2, $3)",
+			wantOrder: "ORDER BY ma.updated_at DESC, ma.name DESC, mt.name::text DESC",
+			wantArgs:  []any{updatedAt, "a", "counter"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := listQuery(tt.filter)
+			require.NoError(t, err)
+			if tt.wantWhere == "" {
+				assert.NotContains(t, query, "WHERE")
+			} else {
+				assert.Contains(t, query, tt.wantWhere)
+			}
+			assert.True(t, strings.HasSuffix(query, tt.wantOrder), query)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+
+	_, _, err := listQuery(models.ListFilter{Glob: "[a"})
+	assert.ErrorIs(t, err, models.ErrBadGlob)
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+BEGIN;
+CREATE TABLE applied_batch
+(
+    id          text PRIMARY KEY,
+    created_at  timestamp NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_applied_batch_created_at ON applied_batch(created_at);
+
+COMMIT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_applied_batch_created_at;
+DROP TABLE IF EXISTS applied_batch;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+BEGIN;
+CREATE INDEX idx_metric_actual_updated_at ON metric_actual(updated_at, name);
+CREATE INDEX idx_metric_actual_name_pattern ON metric_actual(name text_pattern_ops);
+
+COMMIT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_metric_actual_name_pattern;
+DROP INDEX IF EXISTS idx_metric_actual_updated_at;
+
+-- +goose StatementEnd
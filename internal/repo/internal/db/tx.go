@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type txKey struct{}
+
+// querier - общие методы пула соединений и транзакции.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// conn возвращает транзакцию InTx из контекста или пул соединений.
+func (db *DB) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.pool
+}
+
+// InTx выполняет fn в одной транзакции: запросы с контекстом fn идут в нее,
+// а транзакции отдельных методов становятся точками сохранения внутри нее.
+// Ошибка fn откатывает все изменения.
+func (db *DB) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := db.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("BeginTx InTx failed, %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit InTx failed, %w", err)
+	}
+	return nil
+}
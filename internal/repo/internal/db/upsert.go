@@ -0,0 +1,142 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// Запросы создают или изменяют метрику и пишут новое значение в историю за один вызов.
+// Метрика с тем же именем другого типа не меняется, тогда запрос не возвращает строк.
+const (
+	incrementCounterQuery = `
+		WITH upserted AS (
+			INSERT INTO "metric_actual" (name, type_id, value, updated_at)
+			SELECT $1, id, $2, $3 FROM "metric_type"
+			WHERE name = 'counter'
+			ON CONFLICT (name) DO UPDATE
+			SET value = "metric_actual".value + EXCLUDED.value, updated_at = EXCLUDED.updated_at
+			WHERE "metric_actual".type_id = EXCLUDED.type_id
+			RETURNING name, type_id, value
+		)
+		INSERT INTO "metric_history" (name, type_id, value)
+		SELECT name, type_id, value FROM upserted
+		RETURNING value;
+	`
+	upsertGaugeQuery = `
+		WITH upserted AS (
+			INSERT INTO "metric_actual" (name, type_id, value, updated_at)
+			SELECT $1, id, $2, $3 FROM "metric_type"
+			WHERE name = 'gauge'
+			ON CONFLICT (name) DO UPDATE
+			SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at
+			WHERE "metric_actual".type_id = EXCLUDED.type_id
+			RETURNING name, type_id, value
+		)
+		INSERT INTO "metric_history" (name, type_id, value)
+		SELECT name, type_id, value FROM upserted
+		RETURNING value;
+	`
+)
+
+// IncrementCounter прибавляет delta к counter метрике, создавая ее, и возвращает новое значение.
+// Прибавка выполняется в базе, поэтому одновременные обновления не теряются.
+func (db *DB) IncrementCounter(ctx context.Context, name string, delta int64) (int64, error) {
+	db.logger.Debugf("IncrementCounter name %s, delta %v", name, delta)
+
+	var value float64
+	err := db.conn(ctx).QueryRow(ctx, incrementCounterQuery, name, delta, time.Now()).Scan(&value)
+	if err != nil {
+		return 0, upsertError("IncrementCounter", err)
+	}
+	return int64(value), nil
+}
+
+// UpsertGauge сохраняет значение gauge метрики, создавая ее.
+func (db *DB) UpsertGauge(ctx context.Context, name string, value float64) error {
+	db.logger.Debugf("UpsertGauge name %s, value %v", name, value)
+
+	err := db.conn(ctx).QueryRow(ctx, upsertGaugeQuery, name, value, time.Now()).Scan(&value)
+	if err != nil {
+		return upsertError("UpsertGauge", err)
+	}
+	return nil
+}
+
+// ApplyBatch применяет изменения в одной транзакции, отправляя запросы одним пакетом.
+// Запросы идут в порядке (имя, тип): одновременные пакеты блокируют строки в одном порядке
+// и не ждут друг друга по кругу. Изменения одной метрики сохраняют порядок в пакете.
+// Возвращает значения метрик после изменений в порядке updates, у counter итог в Delta.
+func (db *DB) ApplyBatch(ctx context.Context, updates []models.Update) ([]models.Metric, error) {
+	db.logger.Debugf("ApplyBatch %d updates", len(updates))
+
+	for i, u := range updates {
+		if u.Type != "gauge" && u.Type != "counter" {
+			return nil, &models.UpdateError{Index: i, Err: fmt.Errorf("unknown metric type %q", u.Type)}
+		}
+	}
+	order := make([]int, len(updates))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Or(
+			cmp.Compare(updates[a].Name, updates[b].Name),
+			cmp.Compare(updates[a].Type, updates[b].Type),
+		)
+	})
+
+	now := time.Now()
+	batch := &pgx.Batch{}
+	for _, i := range order {
+		u := updates[i]
+		if u.Type == "gauge" {
+			batch.Queue(upsertGaugeQuery, u.Name, u.Value, now)
+		} else {
+			batch.Queue(incrementCounterQuery, u.Name, u.Delta, now)
+		}
+	}
+
+	metrics := make([]models.Metric, len(updates))
+	err := db.InTx(ctx, func(ctx context.Context) error {
+		results := db.conn(ctx).SendBatch(ctx, batch)
+		defer func() {
+			_ = results.Close()
+		}()
+		for _, i := range order {
+			u := updates[i]
+			var value float64
+			if err := results.QueryRow().Scan(&value); err != nil {
+				return &models.UpdateError{Index: i, Err: upsertError("ApplyBatch", err)}
+			}
+			m := models.Metric{UpdatedAt: now, Name: u.Name, Type: u.Type, Value: value}
+			if u.Type == "counter" {
+				m.Value, m.Delta = 0, int64(value)
+			}
+			metrics[i] = m
+		}
+		if err := results.Close(); err != nil {
+			return fmt.Errorf("close ApplyBatch results failed, %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func upsertError(method string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%s failed, metric of another type: %w", method, customerrors.ErrAlreadyExists)
+	}
+	return fmt.Errorf("%s failed, %w", method, err)
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// testDB подключается к базе из TEST_DATABASE_DSN, без нее тест пропускается.
+func testDB(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+	db, err := New(ctx, dsn, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, db.RunMigrations())
+	t.Cleanup(func() {
+		_, _ = db.pool.Exec(ctx, `DELETE FROM "metric_actual" WHERE name LIKE 'upsert_test_%'`)
+		_, _ = db.pool.Exec(ctx, `DELETE FROM "metric_history" WHERE name LIKE 'upsert_test_%'`)
+		_, _ = db.pool.Exec(ctx, `DELETE FROM "applied_batch" WHERE id LIKE 'upsert_test_%'`)
+		db.Shutdown(ctx)
+	})
+	return db
+}
+
+func TestDB__IncrementCounter_concurrent(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	const (
+		workers    = 8
+		increments = 50
+	)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range increments {
+				_, err := db.IncrementCounter(ctx, "upsert_test_counter", 1)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cm, err := db.GetCounterMetric(ctx, "upsert_test_counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*increments), cm.Value)
+}
+
+func TestDB__ApplyBatch(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	metrics, err := db.ApplyBatch(ctx, []models.Update{
+		{Name: "upsert_test_gauge", Type: "gauge", Value: 1.5},
+		{Name: "upsert_test_batch", Type: "counter", Delta: 2},
+		{Name: "upsert_test_batch", Type: "counter", Delta: 3},
+	})
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	assert.Equal(t, int64(5), metrics[2].Delta)
+
+	_, err = db.ApplyBatch(ctx, []models.Update{
+		{Name: "upsert_test_batch", Type: "counter", Delta: 1},
+		{Name: "upsert_test_gauge", Type: "counter", Delta: 1},
+	})
+	var updateErr *models.UpdateError
+	require.ErrorAs(t, err, &updateErr)
+	assert.Equal(t, 1, updateErr.Index)
+	assert.ErrorIs(t, err, customerrors.ErrAlreadyExists)
+
+	cm, err := db.GetCounterMetric(ctx, "upsert_test_batch")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), cm.Value)
+}
+
+func TestDB__ReserveBatchInTx(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	errBatch := errors.New("batch failed")
+
+	err := db.InTx(ctx, func(ctx context.Context) error {
+		ok, err := db.ReserveBatch(ctx, "upsert_test_batch_id")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		_, err = db.IncrementCounter(ctx, "upsert_test_batch_counter", 1)
+		require.NoError(t, err)
+		return errBatch
+	})
+	require.ErrorIs(t, err, errBatch)
+	_, err = db.GetCounterMetric(ctx, "upsert_test_batch_counter")
+	assert.ErrorIs(t, err, customerrors.ErrNotFound)
+
+	require.NoError(t, db.InTx(ctx, func(ctx context.Context) error {
+		ok, err := db.ReserveBatch(ctx, "upsert_test_batch_id")
+		require.NoError(t, err)
+		assert.True(t, ok, "key is rolled back with the batch")
+		return nil
+	}))
+	ok, err := db.ReserveBatch(ctx, "upsert_test_batch_id")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestDB__ApplyBatch_oppositeOrder(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	const rounds = 50
+	forward := []models.Update{
+		{Name: "upsert_test_a", Type: "counter", Delta: 1},
+		{Name: "upsert_test_b", Type: "counter", Delta: 1},
+	}
+	backward := []models.Update{forward[1], forward[0]}
+
+	var wg sync.WaitGroup
+	for _, updates := range [][]models.Update{forward, backward} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				metrics, err := db.ApplyBatch(ctx, updates)
+				if assert.NoError(t, err) {
+					assert.Equal(t, updates[0].Name, metrics[0].Name, "results follow the request order")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	cm, err := db.GetCounterMetric(ctx, "upsert_test_a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*rounds), cm.Value)
+}
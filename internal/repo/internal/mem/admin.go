@@ -0,0 +1,98 @@
+package mem
+
+import (
+	"context"
+	"time"
+
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// DeleteMetric удаляет метрику.
+func (r *InMemoryRepo) DeleteMetric(_ context.Context, mType string, name string) error {
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	var ok bool
+	switch mType {
+	case "gauge":
+		_, ok = sh.gauges[name]
+	case "counter":
+		_, ok = sh.counters[name]
+	}
+	if !ok {
+		return customerrors.ErrNotFound
+	}
+	return r.commit(deleteRecord(mType, name))
+}
+
+// DeleteMetrics удаляет метрики по условиям фильтра и возвращает их количество,
+// курсор, сортировка и лимит фильтра не учитываются.
+func (r *InMemoryRepo) DeleteMetrics(_ context.Context, filter models.ListFilter) (int64, error) {
+	match, err := filter.NameMatcher()
+	if err != nil {
+		return 0, err
+	}
+	unlock := r.store.lockAll()
+	defer unlock()
+	var recs []walRecord
+	for i := range r.store.shards {
+		sh := &r.store.shards[i]
+		if filter.Type == "" || filter.Type == "gauge" {
+			for name := range sh.gauges {
+				if match(name) {
+					recs = append(recs, deleteRecord("gauge", name))
+				}
+			}
+		}
+		if filter.Type == "" || filter.Type == "counter" {
+			for name := range sh.counters {
+				if match(name) {
+					recs = append(recs, deleteRecord("counter", name))
+				}
+			}
+		}
+	}
+	if err = r.commit(recs...); err != nil {
+		return 0, err
+	}
+	return int64(len(recs)), nil
+}
+
+// RenameMetric переименовывает метрику. Имя должно быть свободно у метрик обоих типов,
+// как в Postgres, где имя уникально.
+func (r *InMemoryRepo) RenameMetric(_ context.Context, mType string, name string, newName string) error {
+	unlock := r.store.lock(name, newName)
+	defer unlock()
+	sh, newSh := r.store.shard(name), r.store.shard(newName)
+	_, gaugeExists := newSh.gauges[newName]
+	_, counterExists := newSh.counters[newName]
+	var rec walRecord
+	switch mType {
+	case "gauge":
+		metric, ok := sh.gauges[name]
+		if !ok {
+			return customerrors.ErrNotFound
+		}
+		rec = gaugeRecord(newName, metric.Value, time.Now())
+		rec.Type = metric.Type
+	case "counter":
+		metric, ok := sh.counters[name]
+		if !ok {
+			return customerrors.ErrNotFound
+		}
+		rec = counterRecord(newName, metric.Value, time.Now())
+		rec.Type = metric.Type
+	default:
+		return customerrors.ErrNotFound
+	}
+	if gaugeExists || counterExists {
+		return customerrors.ErrAlreadyExists
+	}
+	return r.commit(deleteRecord(mType, name), rec)
+}
+
+// ResetCounter обнуляет counter метрику.
+func (r *InMemoryRepo) ResetCounter(ctx context.Context, name string) error {
+	return r.UpdateCounterMetric(ctx, name, 0)
+}
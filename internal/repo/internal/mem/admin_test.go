@@ -0,0 +1,79 @@
+package mem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+func initAdminRepo(t *testing.T) *InMemoryRepo {
+	t.Helper()
+	ctx := context.TODO()
+	repo, err := NewInMemoryRepo(logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateGaugeMetric(ctx, "HeapAlloc", "gauge", 1))
+	require.NoError(t, repo.CreateGaugeMetric(ctx, "HeapSys", "gauge", 2))
+	require.NoError(t, repo.CreateCounterMetric(ctx, "PollCount", "counter", 3))
+	return repo
+}
+
+func TestInMemoryRepo__DeleteMetric(t *testing.T) {
+	ctx := context.TODO()
+	repo := initAdminRepo(t)
+
+	assert.ErrorIs(t, repo.DeleteMetric(ctx, "counter", "HeapAlloc"), customerrors.ErrNotFound)
+	require.NoError(t, repo.DeleteMetric(ctx, "gauge", "HeapAlloc"))
+	_, err := repo.GetGaugeMetric(ctx, "HeapAlloc")
+	assert.ErrorIs(t, err, customerrors.ErrNotFound)
+}
+
+func TestInMemoryRepo__DeleteMetrics(t *testing.T) {
+	ctx := context.TODO()
+	repo := initAdminRepo(t)
+
+	deleted, err := repo.DeleteMetrics(ctx, models.ListFilter{Type: "counter", Prefix: "Heap"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = repo.DeleteMetrics(ctx, models.ListFilter{Glob: "*o*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	gms, cms, err := repo.GetAllMetrics(ctx)
+	require.NoError(t, err)
+	assert.Len(t, gms, 1)
+	assert.Empty(t, cms)
+}
+
+func TestInMemoryRepo__RenameMetric(t *testing.T) {
+	ctx := context.TODO()
+	repo := initAdminRepo(t)
+
+	assert.ErrorIs(t, repo.RenameMetric(ctx, "gauge", "HeapSys", "HeapAlloc"), customerrors.ErrAlreadyExists)
+	assert.ErrorIs(t, repo.RenameMetric(ctx, "gauge", "HeapSys", "PollCount"), customerrors.ErrAlreadyExists)
+	assert.ErrorIs(t, repo.RenameMetric(ctx, "counter", "HeapSys", "Sys"), customerrors.ErrNotFound)
+
+	require.NoError(t, repo.RenameMetric(ctx, "gauge", "HeapSys", "Sys"))
+	gm, err := repo.GetGaugeMetric(ctx, "Sys")
+	require.NoError(t, err)
+	assert.Equal(t, "Sys", gm.Name)
+	assert.Equal(t, float64(2), gm.Value)
+	_, err = repo.GetGaugeMetric(ctx, "HeapSys")
+	assert.ErrorIs(t, err, customerrors.ErrNotFound)
+}
+
+func TestInMemoryRepo__ResetCounter(t *testing.T) {
+	ctx := context.TODO()
+	repo := initAdminRepo(t)
+
+	require.NoError(t, repo.ResetCounter(ctx, "PollCount"))
+	cm, err := repo.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), cm.Value)
+	assert.ErrorIs(t, repo.ResetCounter(ctx, "HeapSys"), customerrors.ErrNotFound)
+}
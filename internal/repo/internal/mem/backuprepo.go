@@ -2,11 +2,12 @@ package mem
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -14,10 +15,6 @@ import (
 	"github.com/NStegura/metrics/internal/repo/models"
 )
 
-const (
-	ownerRWPerm fs.FileMode = 0600
-)
-
 // BackupRepo структура хранилища с созданием бекапа.
 type BackupRepo struct {
 	InMemoryRepo
@@ -25,23 +22,67 @@ type BackupRepo struct {
 	fileStoragePath string
 	storeInterval   time.Duration
 	synchronously   bool
+	retain          int
+	allowEmpty      bool
+	walEnabled      bool
+	// compacting не дает запустить второе фоновое сжатие журнала, пока идет первое.
+	compacting atomic.Bool
+	// backupMu не дает одновременным бекапам писать в файл вперемешку.
+	backupMu sync.Mutex
+	// stop останавливает периодический бекап, backupWG ждет его завершения в Shutdown.
+	stop     chan struct{}
+	stopOnce sync.Once
+	backupWG sync.WaitGroup
+}
+
+// BackupOption настраивает BackupRepo.
+type BackupOption func(*BackupRepo)
+
+// WithRetain задает, сколько последних снимков хранить, меньше одного - один.
+// Без опции хранится только последний снимок, значение по умолчанию задает конфиг сервера.
+func WithRetain(retain int) BackupOption {
+	return func(r *BackupRepo) {
+		r.retain = max(retain, 1)
+	}
+}
+
+// WithAllowEmpty разрешает стартовать без метрик, если все снимки повреждены.
+// Поврежденный журнал изменений остается ошибкой и с этой опцией.
+func WithAllowEmpty(allow bool) BackupOption {
+	return func(r *BackupRepo) {
+		r.allowEmpty = allow
+	}
+}
+
+// WithWAL включает журнал изменений: каждое изменение дописывается в журнал
+// и ждет его fsync, а снимок только сжимает журнал.
+func WithWAL(enabled bool) BackupOption {
+	return func(r *BackupRepo) {
+		r.walEnabled = enabled
+	}
 }
 
 func NewBackupRepo(
 	storeInterval time.Duration,
 	fileStoragePath string,
 	logger *logrus.Logger,
+	opts ...BackupOption,
 ) (*BackupRepo, error) {
-	return &BackupRepo{
-		InMemoryRepo{
-			m: &Metrics{
-				map[string]*models.GaugeMetric{},
-				map[string]*models.CounterMetric{},
-			}, logger: logger},
-		fileStoragePath,
-		storeInterval,
-		storeInterval == 0,
-	}, nil
+	r := &BackupRepo{
+		InMemoryRepo: InMemoryRepo{
+			store:   newStore(),
+			batches: newBatchStore(defaultBatchCacheSize, defaultBatchTTL),
+			logger:  logger},
+		fileStoragePath: fileStoragePath,
+		storeInterval:   storeInterval,
+		synchronously:   storeInterval == 0,
+		retain:          1,
+		stop:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
 }
 
 // CreateCounterMetric создает counter метрику.
@@ -50,13 +91,7 @@ func (r *BackupRepo) CreateCounterMetric(ctx context.Context, name string, mType
 	if err != nil {
 		return err
 	}
-	if r.synchronously {
-		err := r.makeBackup()
-		if err != nil {
-			r.logger.Warning(BackupError{err})
-		}
-	}
-	return nil
+	return r.syncBackup()
 }
 
 // UpdateCounterMetric обновляет counter метрику.
@@ -65,13 +100,7 @@ func (r *BackupRepo) UpdateCounterMetric(ctx context.Context, name string, value
 	if err != nil {
 		return err
 	}
-	if r.synchronously {
-		err := r.makeBackup()
-		if err != nil {
-			r.logger.Warning(BackupError{err})
-		}
-	}
-	return nil
+	return r.syncBackup()
 }
 
 // CreateGaugeMetric создает gauge метрику.
@@ -80,13 +109,7 @@ func (r *BackupRepo) CreateGaugeMetric(ctx context.Context, name string, mType s
 	if err != nil {
 		return err
 	}
-	if r.synchronously {
-		err := r.makeBackup()
-		if err != nil {
-			r.logger.Warning(BackupError{err})
-		}
-	}
-	return nil
+	return r.syncBackup()
 }
 
 // UpdateGaugeMetric обновляет gauge метрику.
@@ -95,26 +118,33 @@ func (r *BackupRepo) UpdateGaugeMetric(ctx context.Context, name string, value f
 	if err != nil {
 		return err
 	}
-	if r.synchronously {
-		err := r.makeBackup()
-		if err != nil {
-			r.logger.Warning(BackupError{err})
-		}
-	}
-	return nil
+	return r.syncBackup()
 }
 
-// LoadAndStartBackup загрузка бекапа и старт.
+// LoadAndStartBackup загружает последний целый снимок, повторяет поверх него журнал
+// и запускает бекап. Без снимков хранилище стартует пустым, а если все снимки
+// повреждены - возвращает ошибку, пока пустой старт не разрешен WithAllowEmpty.
 func (r *BackupRepo) LoadAndStartBackup(_ context.Context) error {
 	r.logger.Info("Init backup")
 
-	metrics, err := r.loadBackup(r.fileStoragePath)
+	metrics, walFrom, err := r.loadBackup()
 	if err != nil {
-		r.logger.Warning(BackupError{err})
+		if !r.allowEmpty {
+			return fmt.Errorf("failed to load backup: %w", err)
+		}
+		r.logger.Errorf("failed to load backup, starting empty: %s", err)
 	}
-	r.m = &metrics
+	r.store.restore(metrics)
 
+	if r.walEnabled && r.fileStoragePath != "" {
+		if err = r.startWAL(walFrom); err != nil {
+			return err
+		}
+	}
+
+	r.backupWG.Add(1)
 	go func() {
+		defer r.backupWG.Done()
 		err := r.startBackup()
 		if err != nil {
 			r.logger.Warning(BackupError{err})
@@ -123,13 +153,55 @@ func (r *BackupRepo) LoadAndStartBackup(_ context.Context) error {
 	return nil
 }
 
-// Shutdown выгружает в бекап и останавливает.
+// startWAL повторяет записи журнала с сегмента from поверх загруженного снимка и открывает новый сегмент.
+// Поврежденный журнал - всегда ошибка: без его записей метрики откатились бы назад незаметно.
+func (r *BackupRepo) startWAL(from int) error {
+	backupPath, err := r.backupPath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(backupPath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to mkdir for wal: %w", err)
+	}
+	last, replayed, err := replayWAL(backupPath, r.store, from)
+	if err != nil {
+		return fmt.Errorf("failed to replay wal: %w", err)
+	}
+	r.logger.Infof("Replayed %d wal records", replayed)
+	if r.wal, err = openWAL(backupPath, last); err != nil {
+		return err
+	}
+	r.wal.compact = r.compactWAL
+	return nil
+}
+
+// compactWAL сжимает журнал в снимок в фоне, если сжатие еще не идет.
+func (r *BackupRepo) compactWAL() {
+	if !r.compacting.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer r.compacting.Store(false)
+		if err := r.makeBackup(); err != nil {
+			r.logger.Warning(BackupError{err})
+		}
+	}()
+}
+
+// Shutdown останавливает периодический бекап и выгружает метрики в последний раз.
 func (r *BackupRepo) Shutdown(_ context.Context) {
 	r.logger.Info("Repo shutdown")
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	r.backupWG.Wait()
 	err := r.makeBackup()
 	if err != nil {
 		r.logger.Warning(BackupError{err})
 	}
+	if err = r.wal.close(); err != nil {
+		r.logger.Warning(BackupError{err})
+	}
 }
 
 func (r *BackupRepo) startBackup() error {
@@ -137,8 +209,14 @@ func (r *BackupRepo) startBackup() error {
 		r.logger.Info("storeInterval = 0, only sync backup")
 		return nil
 	}
+	ticker := time.NewTicker(r.storeInterval)
+	defer ticker.Stop()
 	for {
-		time.Sleep(r.storeInterval)
+		select {
+		case <-r.stop:
+			return nil
+		case <-ticker.C:
+		}
 		err := r.makeBackup()
 		if err != nil {
 			return err
@@ -146,18 +224,28 @@ func (r *BackupRepo) startBackup() error {
 	}
 }
 
+// backupPath возвращает путь снимка, относительный путь отсчитывается от рабочего каталога.
+func (r *BackupRepo) backupPath() (string, error) {
+	if filepath.IsAbs(r.fileStoragePath) {
+		return r.fileStoragePath, nil
+	}
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get path backup (getcwd): %w", err)
+	}
+	return filepath.Join(baseDir, r.fileStoragePath), nil
+}
+
 func (r *BackupRepo) makeBackup() error {
 	if r.fileStoragePath == "" {
 		r.logger.Infof("Backup is disabled, fileStoragePath = ''")
 		return nil
 	}
 
-	baseDir, err := os.Getwd()
+	backupPath, err := r.backupPath()
 	if err != nil {
-		return fmt.Errorf("failed to get path backup (getcwd): %w", err)
+		return err
 	}
-
-	backupPath := filepath.Join(baseDir, r.fileStoragePath)
 	r.logger.Infof("Make backup to %s", backupPath)
 
 	dirPath := filepath.Dir(backupPath)
@@ -170,42 +258,177 @@ func (r *BackupRepo) makeBackup() error {
 		}
 	}
 
-	file, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, ownerRWPerm)
-	if err != nil {
-		return fmt.Errorf("failed to open/create backup file: %w", err)
+	// снимок берется под блокировкой, чтобы более старый снимок не записался поверх нового.
+	r.backupMu.Lock()
+	defer r.backupMu.Unlock()
+	if r.wal == nil {
+		data, err := encodeSnapshot(r.Snapshot(), 0)
+		if err != nil {
+			return err
+		}
+		return writeSnapshot(backupPath, data, r.retain)
 	}
-	defer func() {
-		_ = file.Close()
-	}()
-	data, err := json.MarshalIndent(r.InMemoryRepo.m, "", "  ")
+
+	// новый сегмент журнала начинается в момент снимка, его номер записывается в снимок.
+	var (
+		segment int
+		rotErr  error
+	)
+	snapshot := r.store.snapshot(func() { segment, rotErr = r.wal.rotate() })
+	data, err := encodeSnapshot(snapshot, segment)
 	if err != nil {
-		return fmt.Errorf("failed to Marshal backup: %w", err)
+		return err
 	}
-	if _, err := file.Write(data); err != nil {
-		return fmt.Errorf("failed to write backup: %w", err)
+	if err = writeSnapshot(backupPath, data, r.retain); err != nil {
+		return err
 	}
-	return nil
+	if err = r.removeCoveredSegments(backupPath); err != nil {
+		return err
+	}
+	return rotErr
 }
 
-func (r *BackupRepo) loadBackup(fileStoragePath string) (Metrics, error) {
+// removeCoveredSegments удаляет сегменты журнала, которые не нужны ни одному хранимому снимку:
+// восстановление из более старого снимка повторяет журнал с сегмента из его заголовка.
+// Если сегмент снимка не прочитать или снимок без журнала, сегменты не удаляются.
+func (r *BackupRepo) removeCoveredSegments(backupPath string) error {
+	from := -1
+	for _, path := range snapshotPaths(backupPath, r.retain) {
+		walFrom, err := snapshotWALFrom(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			r.logger.Warningf("Keep wal segments, snapshot %s: %s", path, err)
+			return nil
+		}
+		if from == -1 || walFrom < from {
+			from = walFrom
+		}
+	}
+	if from <= 0 {
+		return nil
+	}
+	return r.wal.removeBefore(from)
+}
+
+// loadBackup возвращает метрики из самого нового целого снимка и первый сегмент журнала после него,
+// поврежденные снимки пропускаются с ошибкой в логе.
+func (r *BackupRepo) loadBackup() (Metrics, int, error) {
 	r.logger.Info("LoadBackup")
 	metrics := Metrics{
 		map[string]*models.GaugeMetric{},
 		map[string]*models.CounterMetric{},
 	}
+	if r.fileStoragePath == "" {
+		return metrics, 0, nil
+	}
 
-	baseDir, err := os.Getwd()
+	backupPath, err := r.backupPath()
 	if err != nil {
-		return metrics, fmt.Errorf("failed to get current path: %w", err)
+		return metrics, 0, err
+	}
+	removeTempSnapshots(backupPath)
+
+	var errs []error
+	for i, path := range snapshotPaths(backupPath, r.retain) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			var (
+				snapshot Metrics
+				walFrom  int
+			)
+			if snapshot, walFrom, err = decodeSnapshot(data); err == nil {
+				if i > 0 {
+					r.logger.Warningf("Restored from older snapshot %s", path)
+				}
+				return snapshot, walFrom, nil
+			}
+		}
+		r.logger.Errorf("Skip snapshot %s: %s", path, err)
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
 	}
-	backupPath := filepath.Join(baseDir, fileStoragePath)
-	data, err := os.ReadFile(backupPath)
+	if len(errs) == 0 {
+		r.logger.Infof("No backup in %s, starting empty", backupPath)
+		return metrics, 0, nil
+	}
+	return metrics, 0, fmt.Errorf("no valid snapshot: %w", errors.Join(errs...))
+}
+
+// DeleteMetric удаляет метрику.
+func (r *BackupRepo) DeleteMetric(ctx context.Context, mType string, name string) error {
+	if err := r.InMemoryRepo.DeleteMetric(ctx, mType, name); err != nil {
+		return err
+	}
+	return r.syncBackup()
+}
+
+// DeleteMetrics удаляет метрики по условиям фильтра.
+func (r *BackupRepo) DeleteMetrics(ctx context.Context, filter models.ListFilter) (int64, error) {
+	deleted, err := r.InMemoryRepo.DeleteMetrics(ctx, filter)
 	if err != nil {
-		return metrics, fmt.Errorf("failed to read backup: %w", err)
+		return 0, err
 	}
-	if err := json.Unmarshal(data, &metrics); err != nil {
-		return metrics, fmt.Errorf("failed to Unmarshal backup: %w", err)
+	if deleted > 0 {
+		return deleted, r.syncBackup()
 	}
+	return deleted, nil
+}
 
-	return metrics, nil
+// RenameMetric переименовывает метрику.
+func (r *BackupRepo) RenameMetric(ctx context.Context, mType string, name string, newName string) error {
+	if err := r.InMemoryRepo.RenameMetric(ctx, mType, name, newName); err != nil {
+		return err
+	}
+	return r.syncBackup()
+}
+
+// ResetCounter обнуляет counter метрику.
+func (r *BackupRepo) ResetCounter(ctx context.Context, name string) error {
+	if err := r.InMemoryRepo.ResetCounter(ctx, name); err != nil {
+		return err
+	}
+	return r.syncBackup()
+}
+
+// syncBackup сохраняет изменение на диск. С журналом изменение уже на диске:
+// журнал сохраняет его до применения в памяти. Без журнала сохраняет весь снимок,
+// если бекап синхронный.
+func (r *BackupRepo) syncBackup() error {
+	if r.wal != nil || !r.synchronously {
+		return nil
+	}
+	if err := r.makeBackup(); err != nil {
+		r.logger.Warning(BackupError{err})
+	}
+	return nil
+}
+
+// IncrementCounter прибавляет delta к counter метрике, создавая ее, и возвращает новое значение.
+func (r *BackupRepo) IncrementCounter(ctx context.Context, name string, delta int64) (int64, error) {
+	value, err := r.InMemoryRepo.IncrementCounter(ctx, name, delta)
+	if err != nil {
+		return 0, err
+	}
+	return value, r.syncBackup()
+}
+
+// UpsertGauge сохраняет значение gauge метрики, создавая ее.
+func (r *BackupRepo) UpsertGauge(ctx context.Context, name string, value float64) error {
+	if err := r.InMemoryRepo.UpsertGauge(ctx, name, value); err != nil {
+		return err
+	}
+	return r.syncBackup()
+}
+
+// ApplyBatch применяет изменения под одной блокировкой, бекап делается один раз на пакет.
+func (r *BackupRepo) ApplyBatch(ctx context.Context, updates []models.Update) ([]models.Metric, error) {
+	metrics, err := r.InMemoryRepo.ApplyBatch(ctx, updates)
+	if err != nil {
+		return nil, err
+	}
+	return metrics, r.syncBackup()
 }
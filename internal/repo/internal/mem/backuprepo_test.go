@@ -2,10 +2,13 @@ package mem
 
 import (
 	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBackupRepo__Init(t *testing.T) {
@@ -69,3 +72,16 @@ func TestBackupRepo__Shutdown(t *testing.T) {
 
 	repo.Shutdown(context.TODO())
 }
+
+func TestBackupRepo__ShutdownStopsBackup(t *testing.T) {
+	path := testBackupPath(t)
+	repo, err := NewBackupRepo(time.Millisecond, path, logrus.New())
+	require.NoError(t, err)
+	require.NoError(t, repo.LoadAndStartBackup(context.TODO()))
+	repo.Shutdown(context.TODO())
+	require.NoError(t, os.Remove(path))
+
+	time.Sleep(10 * time.Millisecond)
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "no backups after shutdown")
+}
@@ -0,0 +1,108 @@
+package mem
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBatchCacheSize и defaultBatchTTL ограничивают память под ключи примененных пакетов,
+	// TTL с запасом покрывает время повторов агента.
+	defaultBatchCacheSize = 100000
+	defaultBatchTTL       = 24 * time.Hour
+)
+
+// batchEntry - ключ пакета. Пока пакет применяется, done открыт,
+// повтор с тем же ключом ждет, чем закончится применение.
+type batchEntry struct {
+	appliedAt time.Time
+	done      chan struct{}
+	id        string
+}
+
+// batchStore помнит ключи последних примененных пакетов,
+// при переполнении или по истечении TTL вытесняются самые старые.
+type batchStore struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	order *list.List
+	ids   map[string]*list.Element
+	size  int
+	ttl   time.Duration
+}
+
+func newBatchStore(size int, ttl time.Duration) *batchStore {
+	return &batchStore{
+		now:   time.Now,
+		order: list.New(),
+		ids:   make(map[string]*list.Element),
+		size:  size,
+		ttl:   ttl,
+	}
+}
+
+// reserve запоминает ключ пакета, который начал применяться, nil - пакет уже применен.
+// Если пакет с этим ключом еще применяется, reserve ждет commit или release.
+func (s *batchStore) reserve(ctx context.Context, id string) (*list.Element, error) {
+	for {
+		s.mu.Lock()
+		now := s.now()
+		s.evict(now)
+		e, ok := s.ids[id]
+		if !ok {
+			if s.order.Len() >= s.size {
+				s.remove(s.order.Front())
+			}
+			e = s.order.PushBack(&batchEntry{id: id, appliedAt: now, done: make(chan struct{})})
+			s.ids[id] = e
+			s.mu.Unlock()
+			return e, nil
+		}
+		done := e.Value.(*batchEntry).done
+		s.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for batch %s, %w", id, ctx.Err())
+		}
+		s.mu.Lock()
+		applied := s.ids[id] == e
+		s.mu.Unlock()
+		if applied {
+			return nil, nil
+		}
+	}
+}
+
+// commit отмечает пакет примененным.
+func (s *batchStore) commit(e *list.Element) {
+	close(e.Value.(*batchEntry).done)
+}
+
+// release забывает ключ пакета, который не удалось применить, повтор применит его заново.
+func (s *batchStore) release(e *list.Element) {
+	s.mu.Lock()
+	if s.ids[e.Value.(*batchEntry).id] == e {
+		s.remove(e)
+	}
+	s.mu.Unlock()
+	close(e.Value.(*batchEntry).done)
+}
+
+func (s *batchStore) evict(now time.Time) {
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		if now.Sub(e.Value.(*batchEntry).appliedAt) < s.ttl {
+			return
+		}
+		s.remove(e)
+	}
+}
+
+func (s *batchStore) remove(e *list.Element) {
+	delete(s.ids, e.Value.(*batchEntry).id)
+	s.order.Remove(e)
+}
@@ -0,0 +1,99 @@
+package mem
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reserved сохраняет и сразу фиксирует ключ, false - ключ уже есть.
+func reserved(t *testing.T, s *batchStore, id string) bool {
+	t.Helper()
+	e, err := s.reserve(context.TODO(), id)
+	require.NoError(t, err)
+	if e == nil {
+		return false
+	}
+	s.commit(e)
+	return true
+}
+
+func TestBatchStore__reserve(t *testing.T) {
+	s := newBatchStore(2, time.Hour)
+
+	e, err := s.reserve(context.TODO(), "a")
+	require.NoError(t, err)
+	require.NotNil(t, e)
+	s.release(e)
+	assert.True(t, reserved(t, s, "a"), "released key is reserved again")
+	assert.False(t, reserved(t, s, "a"))
+}
+
+func TestBatchStore__waitPending(t *testing.T) {
+	s := newBatchStore(2, time.Hour)
+	e, err := s.reserve(context.TODO(), "a")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.reserve(ctx, "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "pending key blocks the retry")
+
+	done := make(chan bool)
+	go func() {
+		done <- reserved(t, s, "a")
+	}()
+	s.commit(e)
+	assert.False(t, <-done, "retry after commit is a replay")
+}
+
+func TestBatchStore__bounded(t *testing.T) {
+	now := time.Now()
+	s := newBatchStore(2, time.Minute)
+	s.now = func() time.Time { return now }
+
+	assert.True(t, reserved(t, s, "a"))
+	assert.True(t, reserved(t, s, "b"))
+	assert.True(t, reserved(t, s, "c"))
+	assert.True(t, reserved(t, s, "a"), "oldest key is evicted by size")
+	assert.False(t, reserved(t, s, "c"))
+
+	now = now.Add(time.Minute)
+	assert.True(t, reserved(t, s, "c"), "key is evicted by ttl")
+	assert.Len(t, s.ids, 1)
+}
+
+func TestBatchStore__concurrent(t *testing.T) {
+	s := newBatchStore(defaultBatchCacheSize, defaultBatchTTL)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		count = make(map[string]int)
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				id := strconv.Itoa(i)
+				if reserved(t, s, id) {
+					mu.Lock()
+					count[id]++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, count, 100)
+	for id, n := range count {
+		assert.Equal(t, 1, n, id)
+	}
+}
@@ -0,0 +1,18 @@
+package mem
+
+import (
+	"context"
+
+	"github.com/NStegura/metrics/internal/customerrors"
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// HistoryAvailable сообщает, что репозиторий хранит историю метрик, в памяти истории нет.
+func (r *InMemoryRepo) HistoryAvailable() bool {
+	return false
+}
+
+// GetMetricHistory возвращает customerrors.ErrNoHistory, в памяти хранятся только текущие значения.
+func (r *InMemoryRepo) GetMetricHistory(context.Context, string, string, int) ([]models.HistoryPoint, error) {
+	return nil, customerrors.ErrNoHistory
+}
@@ -0,0 +1,59 @@
+package mem
+
+import (
+	"context"
+	"slices"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// ListMetrics возвращает метрики по фильтру, отсортированные и начиная после курсора.
+func (r *InMemoryRepo) ListMetrics(_ context.Context, filter models.ListFilter) ([]models.Metric, error) {
+	match, err := filter.NameMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	gauges, counters := r.store.len()
+	metrics := make([]models.Metric, 0, gauges+counters)
+	r.store.readAll(func(sh *shard) {
+		if filter.Type == "" || filter.Type == "gauge" {
+			for _, m := range sh.gauges {
+				if match(m.Name) {
+					metrics = append(metrics, models.Metric{
+						Name: m.Name, Type: m.Type, Value: m.Value, UpdatedAt: m.UpdatedAt,
+					})
+				}
+			}
+		}
+		if filter.Type == "" || filter.Type == "counter" {
+			for _, m := range sh.counters {
+				if match(m.Name) {
+					metrics = append(metrics, models.Metric{
+						Name: m.Name, Type: m.Type, Delta: m.Value, UpdatedAt: m.UpdatedAt,
+					})
+				}
+			}
+		}
+	})
+
+	key := func(m models.Metric) models.Cursor {
+		return models.Cursor{Name: m.Name, Type: m.Type, UpdatedAt: m.UpdatedAt}
+	}
+	slices.SortFunc(metrics, func(a, b models.Metric) int {
+		return filter.Compare(key(a), key(b))
+	})
+	if filter.After != nil {
+		start, _ := slices.BinarySearchFunc(metrics, *filter.After, func(m models.Metric, after models.Cursor) int {
+			if filter.Compare(key(m), after) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		metrics = metrics[start:]
+	}
+	if filter.Limit > 0 && len(metrics) > filter.Limit {
+		metrics = metrics[:filter.Limit]
+	}
+	return metrics, nil
+}
@@ -2,6 +2,7 @@ package mem
 
 import (
 	"context"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -14,29 +15,35 @@ const (
 	countCounterMetrics int = 1
 )
 
+// Metrics - все метрики, формат снимка и бекапа.
 type Metrics struct {
 	GaugeMetrics   map[string]*models.GaugeMetric   `json:"gauge_metrics"`
 	CounterMetrics map[string]*models.CounterMetric `json:"counter_metrics"`
 }
 
-// InMemoryRepo структура хранилища в памяти.
+// InMemoryRepo структура хранилища в памяти, безопасна для одновременных вызовов.
 type InMemoryRepo struct {
-	m *Metrics
+	store   *store
+	batches *batchStore
+	// wal сохраняет изменения метрик до их применения в памяти, nil - без журнала.
+	wal *wal
 
 	logger *logrus.Logger
 }
 
 func NewInMemoryRepo(logger *logrus.Logger) (*InMemoryRepo, error) {
 	return &InMemoryRepo{
-		&Metrics{
-			map[string]*models.GaugeMetric{},
-			map[string]*models.CounterMetric{},
-		}, logger}, nil
+		store:   newStore(),
+		batches: newBatchStore(defaultBatchCacheSize, defaultBatchTTL),
+		logger:  logger}, nil
 }
 
 // GetCounterMetric получает counter метрику по названию.
 func (r *InMemoryRepo) GetCounterMetric(_ context.Context, name string) (cm models.CounterMetric, err error) {
-	metric, ok := r.m.CounterMetrics[name]
+	sh := r.store.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	metric, ok := sh.counters[name]
 	if !ok {
 		err = customerrors.ErrNotFound
 		return
@@ -46,23 +53,34 @@ func (r *InMemoryRepo) GetCounterMetric(_ context.Context, name string) (cm mode
 
 // CreateCounterMetric создает counter метрику.
 func (r *InMemoryRepo) CreateCounterMetric(_ context.Context, name string, mType string, value int64) error {
-	r.m.CounterMetrics[name] = &models.CounterMetric{Name: name, Type: mType, Value: value}
-	return nil
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	rec := counterRecord(name, value, time.Now())
+	rec.Type = mType
+	return r.commit(rec)
 }
 
 // UpdateCounterMetric обновляет counter метрику.
 func (r *InMemoryRepo) UpdateCounterMetric(_ context.Context, name string, value int64) error {
-	metric, ok := r.m.CounterMetrics[name]
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	metric, ok := sh.counters[name]
 	if !ok {
 		return customerrors.ErrNotFound
 	}
-	metric.Value = value
-	return nil
+	rec := counterRecord(name, value, time.Now())
+	rec.Type = metric.Type
+	return r.commit(rec)
 }
 
 // GetGaugeMetric получает gauge метрику.
 func (r *InMemoryRepo) GetGaugeMetric(_ context.Context, name string) (cm models.GaugeMetric, err error) {
-	metric, ok := r.m.GaugeMetrics[name]
+	sh := r.store.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	metric, ok := sh.gauges[name]
 	if !ok {
 		err = customerrors.ErrNotFound
 		return
@@ -72,17 +90,37 @@ func (r *InMemoryRepo) GetGaugeMetric(_ context.Context, name string) (cm models
 
 // CreateGaugeMetric создает gauge метрику.
 func (r *InMemoryRepo) CreateGaugeMetric(_ context.Context, name string, mType string, value float64) error {
-	r.m.GaugeMetrics[name] = &models.GaugeMetric{Name: name, Type: mType, Value: value}
-	return nil
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	rec := gaugeRecord(name, value, time.Now())
+	rec.Type = mType
+	return r.commit(rec)
 }
 
 // UpdateGaugeMetric обновляет gauge метрику.
 func (r *InMemoryRepo) UpdateGaugeMetric(_ context.Context, name string, value float64) error {
-	metric, ok := r.m.GaugeMetrics[name]
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	metric, ok := sh.gauges[name]
 	if !ok {
 		return customerrors.ErrNotFound
 	}
-	metric.Value = value
+	rec := gaugeRecord(name, value, time.Now())
+	rec.Type = metric.Type
+	return r.commit(rec)
+}
+
+// commit сохраняет записи в журнал и только после этого применяет их к памяти:
+// при ошибке журнала метрики не меняются. Вызывается под блокировкой шардов всех записей.
+func (r *InMemoryRepo) commit(recs ...walRecord) error {
+	if err := r.wal.commit(recs...); err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		r.store.shard(rec.Name).apply(rec)
+	}
 	return nil
 }
 
@@ -90,13 +128,37 @@ func (r *InMemoryRepo) UpdateGaugeMetric(_ context.Context, name string, value f
 func (r *InMemoryRepo) GetAllMetrics(_ context.Context) ([]models.GaugeMetric, []models.CounterMetric, error) {
 	gaugeMetrics := make([]models.GaugeMetric, 0, countGaugeMetrics)
 	counterMetrics := make([]models.CounterMetric, 0, countCounterMetrics)
-	for _, gMetric := range r.m.GaugeMetrics {
-		gaugeMetrics = append(gaugeMetrics, *gMetric)
+	r.store.readAll(func(sh *shard) {
+		for _, gMetric := range sh.gauges {
+			gaugeMetrics = append(gaugeMetrics, *gMetric)
+		}
+		for _, cMetric := range sh.counters {
+			counterMetrics = append(counterMetrics, *cMetric)
+		}
+	})
+	return gaugeMetrics, counterMetrics, nil
+}
+
+// Snapshot возвращает копию всех метрик на один момент времени.
+func (r *InMemoryRepo) Snapshot() Metrics {
+	return r.store.snapshot(nil)
+}
+
+// ReserveBatch запоминает ключ пакета, false - пакет с этим ключом уже применялся.
+// Внутри InTx ключ становится примененным после успеха транзакции, а до этого
+// повтор с тем же ключом ждет ее завершения.
+func (r *InMemoryRepo) ReserveBatch(ctx context.Context, batchID string) (bool, error) {
+	e, err := r.batches.reserve(ctx, batchID)
+	if err != nil || e == nil {
+		return false, err
 	}
-	for _, cMetric := range r.m.CounterMetrics {
-		counterMetrics = append(counterMetrics, *cMetric)
+	if t, ok := ctx.Value(txKey{}).(*tx); ok {
+		t.onCommit = append(t.onCommit, func() { r.batches.commit(e) })
+		t.onRollback = append(t.onRollback, func() { r.batches.release(e) })
+		return true, nil
 	}
-	return gaugeMetrics, counterMetrics, nil
+	r.batches.commit(e)
+	return true, nil
 }
 
 func (r *InMemoryRepo) Shutdown(_ context.Context) {
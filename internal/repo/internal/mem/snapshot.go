@@ -0,0 +1,176 @@
+package mem
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// snapshotMagic и snapshotVersion открывают заголовок снимка, за ними идет sha256 тела.
+	snapshotMagic   = "metrics-backup"
+	snapshotVersion = 1
+)
+
+var ErrCorruptBackup = errors.New("corrupt backup")
+
+// encodeSnapshot возвращает снимок с заголовком "metrics-backup v1 sha256=<hex> wal=<n>" и телом в json.
+// walFrom - первый сегмент журнала, записей которого нет в снимке, 0 - снимок без журнала.
+func encodeSnapshot(m Metrics, walFrom int) ([]byte, error) {
+	body, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	header := fmt.Sprintf("%s v%d sha256=%s wal=%d\n",
+		snapshotMagic, snapshotVersion, hex.EncodeToString(sum[:]), walFrom)
+	return append([]byte(header), body...), nil
+}
+
+// decodeSnapshot проверяет заголовок и контрольную сумму снимка и возвращает метрики
+// и первый сегмент журнала после снимка. Бекап без заголовка в прежнем формате читается как есть,
+// как и заголовок без сегмента журнала, для них сегмент 0.
+func decodeSnapshot(data []byte) (Metrics, int, error) {
+	m := Metrics{}
+	body := data
+	var walFrom int
+	if bytes.HasPrefix(data, []byte(snapshotMagic)) {
+		header, rest, ok := bytes.Cut(data, []byte("\n"))
+		if !ok {
+			return m, 0, fmt.Errorf("%w: no header end", ErrCorruptBackup)
+		}
+		sum, from, err := parseSnapshotHeader(string(header))
+		if err != nil {
+			return m, 0, err
+		}
+		actual := sha256.Sum256(rest)
+		if hex.EncodeToString(actual[:]) != sum {
+			return m, 0, fmt.Errorf("%w: checksum mismatch", ErrCorruptBackup)
+		}
+		body, walFrom = rest, from
+	}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return m, 0, fmt.Errorf("%w: %w", ErrCorruptBackup, err)
+	}
+	if m.GaugeMetrics == nil || m.CounterMetrics == nil {
+		return m, 0, fmt.Errorf("%w: no metrics", ErrCorruptBackup)
+	}
+	return m, walFrom, nil
+}
+
+// parseSnapshotHeader возвращает контрольную сумму тела и первый сегмент журнала из заголовка снимка.
+func parseSnapshotHeader(header string) (sum string, walFrom int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || len(fields) > 4 || fields[0] != snapshotMagic {
+		return "", 0, fmt.Errorf("%w: bad header", ErrCorruptBackup)
+	}
+	if fields[1] != "v"+strconv.Itoa(snapshotVersion) {
+		return "", 0, fmt.Errorf("%w: unsupported version %s", ErrCorruptBackup, fields[1])
+	}
+	sum, ok := strings.CutPrefix(fields[2], "sha256=")
+	if !ok {
+		return "", 0, fmt.Errorf("%w: bad header: no checksum", ErrCorruptBackup)
+	}
+	if len(fields) == 4 {
+		from, ok := strings.CutPrefix(fields[3], "wal=")
+		if walFrom, err = strconv.Atoi(from); !ok || err != nil || walFrom < 0 {
+			return "", 0, fmt.Errorf("%w: bad header: wal segment %q", ErrCorruptBackup, fields[3])
+		}
+	}
+	return sum, walFrom, nil
+}
+
+// snapshotWALFrom читает из заголовка снимка path первый сегмент журнала после снимка,
+// без самого снимка. Для снимка без сегмента в заголовке возвращает 0.
+func snapshotWALFrom(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	header, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil || !strings.HasPrefix(header, snapshotMagic) {
+		return 0, nil
+	}
+	_, walFrom, err := parseSnapshotHeader(header)
+	return walFrom, err
+}
+
+// snapshotPaths возвращает пути снимков от нового к старому: path, path.1, ..., path.<retain-1>.
+func snapshotPaths(path string, retain int) []string {
+	paths := []string{path}
+	for i := 1; i < retain; i++ {
+		paths = append(paths, path+"."+strconv.Itoa(i))
+	}
+	return paths
+}
+
+// writeSnapshot атомарно заменяет снимок path: данные пишутся во временный файл,
+// сбрасываются на диск и переименовываются, прежние снимки сдвигаются на одну позицию.
+// Сбой на любом шаге оставляет целыми прежние снимки.
+func writeSnapshot(path string, data []byte, retain int) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp snapshot: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp snapshot: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temp snapshot: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp snapshot: %w", err)
+	}
+
+	paths := snapshotPaths(path, retain)
+	for i := len(paths) - 1; i > 0; i-- {
+		if err = os.Rename(paths[i-1], paths[i]); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate snapshot: %w", err)
+		}
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename temp snapshot: %w", err)
+	}
+	return syncDir(dir)
+}
+
+// syncDir сбрасывает на диск запись каталога, чтобы переименование пережило сбой.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot dir: %w", err)
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+	if err = d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync snapshot dir: %w", err)
+	}
+	return nil
+}
+
+// removeTempSnapshots удаляет временные файлы, оставшиеся после сбоя во время записи.
+func removeTempSnapshots(path string) {
+	tmps, _ := filepath.Glob(path + ".tmp-*")
+	for _, tmp := range tmps {
+		_ = os.Remove(tmp)
+	}
+}
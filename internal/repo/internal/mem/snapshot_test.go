@@ -0,0 +1,148 @@
+package mem
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+func testMetrics(pollCount int64) Metrics {
+	return Metrics{
+		GaugeMetrics: map[string]*models.GaugeMetric{
+			"HeapAlloc": {Name: "HeapAlloc", Type: "gauge", Value: 1.5},
+		},
+		CounterMetrics: map[string]*models.CounterMetric{
+			"PollCount": {Name: "PollCount", Type: "counter", Value: pollCount},
+		},
+	}
+}
+
+// testBackupPath возвращает путь снимка во временном каталоге теста.
+func testBackupPath(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "metrics.json")
+}
+
+// startBackupRepo загружает бекап и останавливает периодический бекап в конце теста.
+func startBackupRepo(t *testing.T, repo *BackupRepo) {
+	t.Helper()
+	require.NoError(t, repo.LoadAndStartBackup(context.TODO()))
+	t.Cleanup(func() {
+		repo.Shutdown(context.TODO())
+	})
+}
+
+func TestSnapshot__encodeDecode(t *testing.T) {
+	data, err := encodeSnapshot(testMetrics(3), 5)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "metrics-backup v1 sha256=")
+	assert.Contains(t, string(data), " wal=5\n")
+
+	m, walFrom, err := decodeSnapshot(data)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), m.CounterMetrics["PollCount"].Value)
+	assert.Equal(t, 5, walFrom)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated", data: data[:len(data)-10]},
+		{name: "tampered", data: append(append([]byte{}, data[:len(data)-3]...), "9}}"...)},
+		{name: "no header end", data: []byte("metrics-backup v1 sha256=00")},
+		{name: "unknown version", data: []byte("metrics-backup v2 sha256=00\n{}")},
+		{name: "bad wal segment", data: []byte("metrics-backup v1 sha256=00 wal=x\n{}")},
+		{name: "empty", data: []byte{}},
+		{name: "legacy truncated", data: []byte(`{"gauge_metrics": {`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeSnapshot(tt.data)
+			assert.ErrorIs(t, err, ErrCorruptBackup)
+		})
+	}
+
+	legacy := []byte(`{"gauge_metrics": {}, "counter_metrics": {"PollCount": {"name": "PollCount", "value": 7}}}`)
+	m, walFrom, err = decodeSnapshot(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), m.CounterMetrics["PollCount"].Value)
+	assert.Zero(t, walFrom)
+
+	// заголовок без сегмента журнала записан до журнала.
+	withoutWAL := bytes.Replace(data, []byte(" wal=5"), nil, 1)
+	m, walFrom, err = decodeSnapshot(withoutWAL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), m.CounterMetrics["PollCount"].Value)
+	assert.Zero(t, walFrom)
+}
+
+func TestSnapshot__writeRetain(t *testing.T) {
+	path := testBackupPath(t)
+	for i := range 4 {
+		data, err := encodeSnapshot(testMetrics(int64(i)), 0)
+		require.NoError(t, err)
+		require.NoError(t, writeSnapshot(path, data, 3))
+	}
+
+	for i, p := range snapshotPaths(path, 3) {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+		m, _, err := decodeSnapshot(data)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3-i), m.CounterMetrics["PollCount"].Value)
+	}
+	_, err := os.Stat(path + ".3")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	tmps, err := filepath.Glob(path + ".tmp-*")
+	require.NoError(t, err)
+	assert.Empty(t, tmps)
+}
+
+func TestBackupRepo__LoadFallback(t *testing.T) {
+	ctx := context.TODO()
+	path := testBackupPath(t)
+	for i := range 2 {
+		data, err := encodeSnapshot(testMetrics(int64(i+1)), 0)
+		require.NoError(t, err)
+		require.NoError(t, writeSnapshot(path, data, 3))
+	}
+	require.NoError(t, os.WriteFile(path, []byte("metrics-backup v1 sha256=00\n{"), 0600))
+	require.NoError(t, os.WriteFile(path+".tmp-1", []byte("partial"), 0600))
+
+	repo, err := NewBackupRepo(time.Hour, path, logrus.New(), WithRetain(3))
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+	cm, err := repo.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cm.Value)
+	_, err = os.Stat(path + ".tmp-1")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path+".1", []byte("{"), 0600))
+	repo, err = NewBackupRepo(time.Hour, path, logrus.New(), WithRetain(3))
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.LoadAndStartBackup(ctx), ErrCorruptBackup)
+
+	repo, err = NewBackupRepo(time.Hour, path, logrus.New(), WithRetain(3), WithAllowEmpty(true))
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+	gms, cms, err := repo.GetAllMetrics(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, gms)
+	assert.Empty(t, cms)
+}
+
+func TestBackupRepo__LoadMissing(t *testing.T) {
+	repo, err := NewBackupRepo(time.Hour, testBackupPath(t), logrus.New())
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+}
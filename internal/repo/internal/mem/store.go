@@ -0,0 +1,200 @@
+package mem
+
+import (
+	"hash/fnv"
+	"slices"
+	"sync"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// shardCount - число шардов, запись метрик в разные шарды не конкурирует за блокировку.
+const shardCount = 32
+
+// shard - часть метрик под общей блокировкой. Имя метрики всегда попадает в один шард,
+// поэтому метрики обоих типов с одним именем проверяются под одной блокировкой.
+type shard struct {
+	mu       sync.RWMutex
+	gauges   map[string]*models.GaugeMetric
+	counters map[string]*models.CounterMetric
+}
+
+// store - метрики, разбитые на шарды по хешу имени.
+// Несколько шардов блокируются всегда по возрастанию номера, чтобы не было взаимных блокировок.
+type store struct {
+	shards [shardCount]shard
+}
+
+func newStore() *store {
+	s := &store{}
+	for i := range s.shards {
+		s.shards[i].gauges = map[string]*models.GaugeMetric{}
+		s.shards[i].counters = map[string]*models.CounterMetric{}
+	}
+	return s
+}
+
+func shardIndex(name string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32() % shardCount)
+}
+
+func (s *store) shard(name string) *shard {
+	return &s.shards[shardIndex(name)]
+}
+
+// lock блокирует на запись шарды с метриками names и возвращает разблокировку.
+func (s *store) lock(names ...string) (unlock func()) {
+	idx := make([]int, 0, len(names))
+	for _, name := range names {
+		idx = append(idx, shardIndex(name))
+	}
+	slices.Sort(idx)
+	idx = slices.Compact(idx)
+	for _, i := range idx {
+		s.shards[i].mu.Lock()
+	}
+	return func() {
+		for _, i := range idx {
+			s.shards[i].mu.Unlock()
+		}
+	}
+}
+
+// readAll вызывает fn для всех шардов, заблокированных на чтение разом:
+// fn видит состояние метрик на один момент времени.
+func (s *store) readAll(fn func(sh *shard)) {
+	for i := range s.shards {
+		s.shards[i].mu.RLock()
+	}
+	defer func() {
+		for i := range s.shards {
+			s.shards[i].mu.RUnlock()
+		}
+	}()
+	for i := range s.shards {
+		fn(&s.shards[i])
+	}
+}
+
+// lockAll блокирует на запись все шарды и возвращает разблокировку.
+func (s *store) lockAll() (unlock func()) {
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+	}
+	return func() {
+		for i := range s.shards {
+			s.shards[i].mu.Unlock()
+		}
+	}
+}
+
+// writeAll вызывает fn для всех шардов, заблокированных на запись разом.
+func (s *store) writeAll(fn func(sh *shard)) {
+	unlock := s.lockAll()
+	defer unlock()
+	for i := range s.shards {
+		fn(&s.shards[i])
+	}
+}
+
+// len возвращает число метрик каждого типа.
+func (s *store) len() (gauges int, counters int) {
+	s.readAll(func(sh *shard) {
+		gauges += len(sh.gauges)
+		counters += len(sh.counters)
+	})
+	return
+}
+
+// snapshot возвращает копию всех метрик на один момент времени,
+// locked, если задан, выполняется в этот же момент, пока метрики не меняются.
+func (s *store) snapshot(locked func()) Metrics {
+	gauges, counters := s.len()
+	m := Metrics{
+		GaugeMetrics:   make(map[string]*models.GaugeMetric, gauges),
+		CounterMetrics: make(map[string]*models.CounterMetric, counters),
+	}
+	visited := 0
+	s.readAll(func(sh *shard) {
+		for name, metric := range sh.gauges {
+			gm := *metric
+			m.GaugeMetrics[name] = &gm
+		}
+		for name, metric := range sh.counters {
+			cm := *metric
+			m.CounterMetrics[name] = &cm
+		}
+		// readAll держит блокировки всех шардов, пока не обойдет последний.
+		if visited++; visited == shardCount && locked != nil {
+			locked()
+		}
+	})
+	return m
+}
+
+// restore заменяет все метрики копией m.
+func (s *store) restore(m Metrics) {
+	type part struct {
+		gauges   []*models.GaugeMetric
+		counters []*models.CounterMetric
+	}
+	parts := make(map[*shard]*part, shardCount)
+	partOf := func(name string) *part {
+		sh := s.shard(name)
+		if parts[sh] == nil {
+			parts[sh] = &part{}
+		}
+		return parts[sh]
+	}
+	for name, metric := range m.GaugeMetrics {
+		gm := *metric
+		gm.Name = name
+		partOf(name).gauges = append(partOf(name).gauges, &gm)
+	}
+	for name, metric := range m.CounterMetrics {
+		cm := *metric
+		cm.Name = name
+		partOf(name).counters = append(partOf(name).counters, &cm)
+	}
+
+	s.writeAll(func(sh *shard) {
+		clear(sh.gauges)
+		clear(sh.counters)
+		p, ok := parts[sh]
+		if !ok {
+			return
+		}
+		for _, gm := range p.gauges {
+			sh.gauges[gm.Name] = gm
+		}
+		for _, cm := range p.counters {
+			sh.counters[cm.Name] = cm
+		}
+	})
+}
+
+// apply применяет запись журнала.
+func (s *store) apply(rec walRecord) {
+	sh := s.shard(rec.Name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.apply(rec)
+}
+
+// apply применяет запись журнала к шарду, вызывается под его блокировкой.
+func (sh *shard) apply(rec walRecord) {
+	switch {
+	case rec.Op == walDelete && rec.Type == "gauge":
+		delete(sh.gauges, rec.Name)
+	case rec.Op == walDelete && rec.Type == "counter":
+		delete(sh.counters, rec.Name)
+	case rec.Op == walSetGauge:
+		sh.gauges[rec.Name] = &models.GaugeMetric{Name: rec.Name, Type: rec.Type, Value: rec.Value, UpdatedAt: rec.At}
+	case rec.Op == walSetCounter:
+		sh.counters[rec.Name] = &models.CounterMetric{
+			Name: rec.Name, Type: rec.Type, Value: rec.Delta, UpdatedAt: rec.At,
+		}
+	}
+}
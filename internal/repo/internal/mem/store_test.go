@@ -0,0 +1,133 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+func TestInMemoryRepo__parallelWriters(t *testing.T) {
+	ctx := context.TODO()
+	repo, err := NewInMemoryRepo(logrus.New())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range increments {
+				name := fmt.Sprintf("gauge_%d_%d", w, i%10)
+				assert.NoError(t, repo.UpsertGauge(ctx, name, float64(i)))
+				_, err := repo.IncrementCounter(ctx, "PollCount", 1)
+				assert.NoError(t, err)
+				_, err = repo.GetGaugeMetric(ctx, name)
+				assert.NoError(t, err)
+				if i%20 == 0 {
+					_, _, err = repo.GetAllMetrics(ctx)
+					assert.NoError(t, err)
+					_, err = repo.ListMetrics(ctx, models.ListFilter{Prefix: "gauge_", Sort: models.SortByName})
+					assert.NoError(t, err)
+					_ = repo.RenameMetric(ctx, "gauge", name, name+"_renamed")
+					_ = repo.DeleteMetric(ctx, "gauge", name+"_renamed")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	cm, err := repo.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*increments), cm.Value)
+}
+
+func TestInMemoryRepo__Snapshot(t *testing.T) {
+	ctx := context.TODO()
+	repo, err := NewInMemoryRepo(logrus.New())
+	require.NoError(t, err)
+
+	// счетчики меняются только вместе, поэтому в согласованном снимке они равны.
+	batch := []models.Update{
+		{Name: "first", Type: "counter", Delta: 1},
+		{Name: "second", Type: "counter", Delta: 1},
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range increments {
+				_, err := repo.ApplyBatch(ctx, batch)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		snapshot := repo.Snapshot()
+		first, second := snapshot.CounterMetrics["first"], snapshot.CounterMetrics["second"]
+		if first != nil || second != nil {
+			require.NotNil(t, first)
+			require.NotNil(t, second)
+			require.Equal(t, first.Value, second.Value)
+		}
+		select {
+		case <-done:
+			snapshot = repo.Snapshot()
+			assert.Equal(t, int64(workers*increments), snapshot.CounterMetrics["second"].Value)
+
+			// снимок - копия, его изменение не меняет хранилище.
+			snapshot.CounterMetrics["first"].Value = 0
+			cm, err := repo.GetCounterMetric(ctx, "first")
+			require.NoError(t, err)
+			assert.Equal(t, int64(workers*increments), cm.Value)
+			return
+		default:
+		}
+	}
+}
+
+func TestBackupRepo__parallelWriters(t *testing.T) {
+	// каждое изменение синхронно пишет бекап, поэтому изменений немного.
+	const backups = 2
+	ctx := context.TODO()
+	path := testBackupPath(t)
+
+	repo, err := NewBackupRepo(0, path, logrus.New())
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range backups {
+				assert.NoError(t, repo.UpsertGauge(ctx, fmt.Sprintf("gauge_%d", w), float64(i)))
+				_, err := repo.IncrementCounter(ctx, "PollCount", 1)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	repo.Shutdown(ctx)
+
+	restored, err := NewBackupRepo(0, path, logrus.New())
+	require.NoError(t, err)
+	startBackupRepo(t, restored)
+	cm, err := restored.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*backups), cm.Value)
+	gauges, _ := restored.store.len()
+	assert.Equal(t, workers, gauges)
+}
@@ -0,0 +1,37 @@
+package mem
+
+import (
+	"context"
+)
+
+type txKey struct{}
+
+// tx хранит действия, которые выполняются при завершении InTx.
+type tx struct {
+	onCommit   []func()
+	onRollback []func()
+}
+
+// InTx выполняет fn. Изменения метрик в памяти атомарны по отдельности и не откатываются:
+// возврат прежнего значения затер бы изменения, сделанные другими запросами за время fn.
+// Ключ пакета, сохраненный ReserveBatch с контекстом fn, считается примененным только
+// после успеха fn, а при ошибке забывается. Вложенный вызов при успехе входит во внешний.
+func (r *InMemoryRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	parent, nested := ctx.Value(txKey{}).(*tx)
+	t := &tx{}
+	if err := fn(context.WithValue(ctx, txKey{}, t)); err != nil {
+		for _, rollback := range t.onRollback {
+			rollback()
+		}
+		return err
+	}
+	if nested {
+		parent.onCommit = append(parent.onCommit, t.onCommit...)
+		parent.onRollback = append(parent.onRollback, t.onRollback...)
+		return nil
+	}
+	for _, commit := range t.onCommit {
+		commit()
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package mem
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepo__ReserveBatchInTx(t *testing.T) {
+	ctx := context.TODO()
+	repo, err := NewInMemoryRepo(logrus.New())
+	require.NoError(t, err)
+	errBatch := errors.New("batch failed")
+
+	err = repo.InTx(ctx, func(ctx context.Context) error {
+		ok, err := repo.ReserveBatch(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		return errBatch
+	})
+	assert.ErrorIs(t, err, errBatch)
+
+	err = repo.InTx(ctx, func(ctx context.Context) error {
+		require.NoError(t, repo.InTx(ctx, func(ctx context.Context) error {
+			ok, err := repo.ReserveBatch(ctx, "a")
+			require.NoError(t, err)
+			assert.True(t, ok, "key of the rolled back transaction is released")
+			return nil
+		}))
+		return errBatch
+	})
+	assert.ErrorIs(t, err, errBatch, "outer rollback releases the key of the nested transaction")
+
+	require.NoError(t, repo.InTx(ctx, func(ctx context.Context) error {
+		ok, err := repo.ReserveBatch(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		return nil
+	}))
+	ok, err := repo.ReserveBatch(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,78 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+// IncrementCounter прибавляет delta к counter метрике, создавая ее, и возвращает новое значение.
+func (r *InMemoryRepo) IncrementCounter(_ context.Context, name string, delta int64) (int64, error) {
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	var value int64
+	if metric, ok := sh.counters[name]; ok {
+		value = metric.Value
+	}
+	value += delta
+	if err := r.commit(counterRecord(name, value, time.Now())); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// UpsertGauge сохраняет значение gauge метрики, создавая ее.
+func (r *InMemoryRepo) UpsertGauge(_ context.Context, name string, value float64) error {
+	sh := r.store.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return r.commit(gaugeRecord(name, value, time.Now()))
+}
+
+// ApplyBatch применяет изменения, заблокировав шарды всех метрик пакета:
+// другие изменения этих метрик не попадают между изменениями пакета.
+// Все изменения пакета пишутся в журнал одной строкой, поэтому применяются целиком или никак.
+// Возвращает значения метрик после изменений, у counter итог в Delta.
+func (r *InMemoryRepo) ApplyBatch(_ context.Context, updates []models.Update) ([]models.Metric, error) {
+	for i, u := range updates {
+		if u.Type != "gauge" && u.Type != "counter" {
+			return nil, &models.UpdateError{Index: i, Err: fmt.Errorf("unknown metric type %q", u.Type)}
+		}
+	}
+
+	names := make([]string, 0, len(updates))
+	for _, u := range updates {
+		names = append(names, u.Name)
+	}
+	unlock := r.store.lock(names...)
+	defer unlock()
+	metrics := make([]models.Metric, 0, len(updates))
+	recs := make([]walRecord, 0, len(updates))
+	// counters - значения counter метрик с учетом предыдущих изменений пакета.
+	counters := map[string]int64{}
+	now := time.Now()
+	for _, u := range updates {
+		m := models.Metric{UpdatedAt: now, Name: u.Name, Type: u.Type}
+		if u.Type == "gauge" {
+			m.Value = u.Value
+			recs = append(recs, gaugeRecord(u.Name, u.Value, now))
+		} else {
+			value, ok := counters[u.Name]
+			if metric, exists := r.store.shard(u.Name).counters[u.Name]; !ok && exists {
+				value = metric.Value
+			}
+			value += u.Delta
+			counters[u.Name] = value
+			m.Delta = value
+			recs = append(recs, counterRecord(u.Name, value, now))
+		}
+		metrics = append(metrics, m)
+	}
+	if err := r.commit(recs...); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
@@ -0,0 +1,81 @@
+package mem
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/repo/models"
+)
+
+const (
+	workers    = 16
+	increments = 200
+)
+
+func TestInMemoryRepo__IncrementCounter_concurrent(t *testing.T) {
+	ctx := context.TODO()
+	repo, err := NewInMemoryRepo(logrus.New())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range increments {
+				_, err := repo.IncrementCounter(ctx, "PollCount", 1)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, err := repo.IncrementCounter(ctx, "PollCount", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*increments), value)
+}
+
+func TestInMemoryRepo__ApplyBatch(t *testing.T) {
+	ctx := context.TODO()
+	repo, err := NewInMemoryRepo(logrus.New())
+	require.NoError(t, err)
+
+	batch := []models.Update{
+		{Name: "PollCount", Type: "counter", Delta: 2},
+		{Name: "HeapAlloc", Type: "gauge", Value: 1.5},
+		{Name: "PollCount", Type: "counter", Delta: 3},
+	}
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ApplyBatch(ctx, batch)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	metrics, err := repo.ApplyBatch(ctx, batch)
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	assert.Equal(t, int64(workers*5+2), metrics[0].Delta)
+	assert.Equal(t, 1.5, metrics[1].Value)
+	assert.Equal(t, int64(workers*5+5), metrics[2].Delta)
+
+	_, err = repo.ApplyBatch(ctx, []models.Update{
+		{Name: "PollCount", Type: "counter", Delta: 1},
+		{Name: "Unknown", Type: "histogram"},
+	})
+	var updateErr *models.UpdateError
+	require.ErrorAs(t, err, &updateErr)
+	assert.Equal(t, 1, updateErr.Index)
+	value, err := repo.IncrementCounter(ctx, "PollCount", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*5+5), value)
+}
@@ -0,0 +1,359 @@
+package mem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	walSuffix = ".wal."
+	// walCompactSize - размер сегмента журнала, после которого журнал сжимается в снимок.
+	walCompactSize = 16 << 20
+)
+
+var ErrCorruptWAL = errors.New("corrupt wal")
+
+type walOp string
+
+const (
+	walSetGauge   walOp = "set_gauge"
+	walSetCounter walOp = "set_counter"
+	walDelete     walOp = "delete"
+)
+
+// walRecord - новое состояние метрики. Запись хранит значение, а не изменение,
+// поэтому повтор уже учтенной в снимке записи при восстановлении ничего не портит.
+// Op выбирает вид метрики, Type - тип, с которым метрика хранится, у delete - gauge или counter.
+type walRecord struct {
+	At    time.Time `json:"at"`
+	Op    walOp     `json:"op"`
+	Type  string    `json:"type"`
+	Name  string    `json:"name"`
+	Value float64   `json:"value,omitempty"`
+	Delta int64     `json:"delta,omitempty"`
+}
+
+func gaugeRecord(name string, value float64, at time.Time) walRecord {
+	return walRecord{At: at, Op: walSetGauge, Type: "gauge", Name: name, Value: value}
+}
+
+func counterRecord(name string, value int64, at time.Time) walRecord {
+	return walRecord{At: at, Op: walSetCounter, Type: "counter", Name: name, Delta: value}
+}
+
+func deleteRecord(mType string, name string) walRecord {
+	return walRecord{At: time.Now(), Op: walDelete, Type: mType, Name: name}
+}
+
+// wal - журнал изменений метрик из сегментов <path>.wal.<номер>. Каждая строка -
+// "<crc32> <json массив записей>", записи одного изменения применяются при восстановлении целиком.
+// commit вызывается под блокировкой шардов изменения и возвращается после fsync,
+// поэтому в память попадают только изменения, уже сохраненные на диске.
+// Одновременные commit разных шардов делят один fsync.
+// Методы nil журнала ничего не делают, так хранилище работает без журнала.
+type wal struct {
+	file *os.File
+	buf  *bufio.Writer
+	// err - сегмент не удалось вернуть к последнему fsync, записи не принимаются до сжатия.
+	err     error
+	path    string
+	segment int
+	size    int64
+	// syncedSize - длина сегмента после последнего удачного fsync.
+	syncedSize int64
+	written    uint64
+	// epoch увеличивается при каждой ошибке записи, failSynced[e] - номер последней записи
+	// на диске, когда закончилась эпоха e: записи эпохи e после него потеряны.
+	epoch      int
+	failSynced []uint64
+	// compact, если задан, запускает сжатие журнала в снимок, не дожидаясь его.
+	compact func()
+	mu      sync.Mutex
+
+	// syncMu пропускает к fsync по одному, пока он идет, следующие записи копятся в одну группу.
+	syncMu sync.Mutex
+	synced uint64
+}
+
+func segmentPath(path string, segment int) string {
+	return fmt.Sprintf("%s%s%06d", path, walSuffix, segment)
+}
+
+// walSegments возвращает номера сегментов журнала по возрастанию.
+func walSegments(path string) ([]int, error) {
+	files, err := filepath.Glob(path + walSuffix + "*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wal segments: %w", err)
+	}
+	segments := make([]int, 0, len(files))
+	for _, file := range files {
+		segment, err := strconv.Atoi(strings.TrimPrefix(file, path+walSuffix))
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	slices.Sort(segments)
+	return segments, nil
+}
+
+func openSegment(path string, segment int) (*os.File, error) {
+	file, err := os.OpenFile(segmentPath(path, segment), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wal segment: %w", err)
+	}
+	if err = syncDir(filepath.Dir(path)); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// openWAL начинает новый сегмент журнала после segment.
+func openWAL(path string, segment int) (*wal, error) {
+	file, err := openSegment(path, segment+1)
+	if err != nil {
+		return nil, err
+	}
+	return &wal{file: file, buf: bufio.NewWriter(file), path: path, segment: segment + 1}, nil
+}
+
+// commit дописывает записи одной строкой и ждет их fsync. При ошибке записи
+// не применены, а сегмент возвращается к последнему fsync.
+func (w *wal) commit(recs ...walRecord) error {
+	if w == nil || len(recs) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(recs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal wal record: %w", err)
+	}
+
+	w.mu.Lock()
+	if w.err != nil {
+		err = w.err
+		w.mu.Unlock()
+		w.requestCompaction()
+		return err
+	}
+	n, err := fmt.Fprintf(w.buf, "%08x %s\n", crc32.ChecksumIEEE(data), data)
+	w.size += int64(n)
+	w.written++
+	seq, epoch := w.written, w.epoch
+	if err != nil {
+		err = w.fail(fmt.Errorf("failed to write wal record: %w", err))
+	}
+	w.mu.Unlock()
+	if err == nil {
+		err = w.syncTo(seq, epoch)
+	}
+	w.requestCompaction()
+	return err
+}
+
+// syncTo ждет, пока запись seq эпохи epoch попадет на диск, и сбрасывает ее сам,
+// если ее еще не сбросил fsync другой группы.
+func (w *wal) syncTo(seq uint64, epoch int) error {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+
+	w.mu.Lock()
+	if epoch != w.epoch {
+		lost := seq > w.failSynced[epoch]
+		w.mu.Unlock()
+		if lost {
+			return errors.New("failed to write wal: record lost with failed group")
+		}
+		return nil
+	}
+	if w.synced >= seq {
+		w.mu.Unlock()
+		return nil
+	}
+	if err := w.buf.Flush(); err != nil {
+		err = w.fail(fmt.Errorf("failed to write wal: %w", err))
+		w.mu.Unlock()
+		return err
+	}
+	target, size, file := w.written, w.size, w.file
+	w.mu.Unlock()
+
+	err := file.Sync()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		return w.fail(fmt.Errorf("failed to sync wal: %w", err))
+	}
+	w.synced, w.syncedSize = target, size
+	return nil
+}
+
+// fail отбрасывает записи, которые не попали на диск, и обрезает сегмент до последнего fsync.
+// Если обрезать не удалось, журнал не принимает записи до сжатия в снимок.
+// Вызывается под w.mu, возвращает err.
+func (w *wal) fail(err error) error {
+	w.buf.Reset(w.file)
+	w.failSynced = append(w.failSynced, w.synced)
+	w.epoch++
+	w.size = w.syncedSize
+	if truncErr := w.file.Truncate(w.syncedSize); truncErr != nil {
+		w.err = fmt.Errorf("failed to truncate wal after error: %w", truncErr)
+	}
+	return err
+}
+
+// requestCompaction запускает сжатие, если сегмент разросся или журнал не принимает записи.
+func (w *wal) requestCompaction() {
+	w.mu.Lock()
+	need := w.compact != nil && (w.size > walCompactSize || w.err != nil)
+	w.mu.Unlock()
+	if need {
+		w.compact()
+	}
+}
+
+// rotate начинает новый сегмент и возвращает его номер. Вызывается, пока заблокированы все шарды:
+// commit держит блокировку шарда до fsync, поэтому к этому моменту все записи старого сегмента,
+// попавшие в память, уже на диске, а остальные отброшены. Ошибка старого сегмента после этого
+// не важна: его записи есть в снимке, который делается в это же время.
+// Если новый сегмент открыть не удалось, журнал продолжает старый сегмент и возвращается его номер.
+func (w *wal) rotate() (int, error) {
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size != w.syncedSize && w.err == nil {
+		return w.segment, errors.New("failed to rotate wal: segment has unsynced records")
+	}
+	file, err := openSegment(w.path, w.segment+1)
+	if err != nil {
+		return w.segment, err
+	}
+	_ = w.file.Close()
+	w.file, w.buf, w.err = file, bufio.NewWriter(file), nil
+	w.segment++
+	w.size, w.syncedSize = 0, 0
+	return w.segment, nil
+}
+
+// removeBefore удаляет сегменты, записи которых уже есть во всех хранимых снимках.
+func (w *wal) removeBefore(segment int) error {
+	segments, err := walSegments(w.path)
+	if err != nil {
+		return err
+	}
+	for _, s := range segments {
+		if s >= segment {
+			break
+		}
+		if err = os.Remove(segmentPath(w.path, s)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove wal segment: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *wal) close() error {
+	if w == nil {
+		return nil
+	}
+	w.syncMu.Lock()
+	defer w.syncMu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close wal: %w", err)
+	}
+	return nil
+}
+
+// replayWAL применяет к s записи сегментов журнала path, начиная с from, по порядку
+// и возвращает номер последнего. Более ранние сегменты уже учтены в снимке и пропускаются.
+// Недописанная при сбое последняя запись последнего сегмента отрезается,
+// поврежденная запись в другом месте и пропавший сегмент - ошибка ErrCorruptWAL.
+func replayWAL(path string, s *store, from int) (last int, replayed int, err error) {
+	segments, err := walSegments(path)
+	if err != nil || len(segments) == 0 {
+		return 0, 0, err
+	}
+	// при ошибке тоже возвращается последний номер, чтобы новые записи не попали в старый сегмент.
+	last = segments[len(segments)-1]
+	start, found := slices.BinarySearch(segments, from)
+	if from > 0 && !found && start < len(segments) {
+		return last, 0, fmt.Errorf("%w: segment %d is missing", ErrCorruptWAL, from)
+	}
+	for i, segment := range segments[start:] {
+		if expected := segments[start] + i; segment != expected {
+			return last, replayed, fmt.Errorf("%w: segment %d is missing", ErrCorruptWAL, expected)
+		}
+		data, err := os.ReadFile(segmentPath(path, segment))
+		if err != nil {
+			return last, replayed, fmt.Errorf("failed to read wal segment: %w", err)
+		}
+		valid, n, err := replaySegment(data, s)
+		replayed += n
+		if err != nil {
+			if segment != last || !isTail(data[valid:]) {
+				return last, replayed, fmt.Errorf("segment %d: %w", segment, err)
+			}
+			if err = os.Truncate(segmentPath(path, segment), int64(valid)); err != nil {
+				return last, replayed, fmt.Errorf("failed to truncate wal segment: %w", err)
+			}
+		}
+	}
+	return last, replayed, nil
+}
+
+// isTail проверяет, что rest - одна последняя строка, возможно без конца строки.
+func isTail(rest []byte) bool {
+	end := bytes.IndexByte(rest, '\n')
+	return end == -1 || end == len(rest)-1
+}
+
+// replaySegment применяет строки сегмента и возвращает длину целой части и число записей.
+func replaySegment(data []byte, s *store) (valid int, replayed int, err error) {
+	for valid < len(data) {
+		line, _, ok := bytes.Cut(data[valid:], []byte("\n"))
+		if !ok {
+			return valid, replayed, fmt.Errorf("%w: unterminated record", ErrCorruptWAL)
+		}
+		recs, err := decodeRecords(line)
+		if err != nil {
+			return valid, replayed, err
+		}
+		for _, rec := range recs {
+			s.apply(rec)
+		}
+		valid += len(line) + 1
+		replayed += len(recs)
+	}
+	return valid, replayed, nil
+}
+
+func decodeRecords(line []byte) ([]walRecord, error) {
+	var recs []walRecord
+	sum, data, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return nil, fmt.Errorf("%w: no checksum", ErrCorruptWAL)
+	}
+	crc, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(crc) != crc32.ChecksumIEEE(data) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptWAL)
+	}
+	if err = json.Unmarshal(data, &recs); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptWAL, err)
+	}
+	return recs, nil
+}
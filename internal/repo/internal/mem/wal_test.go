@@ -0,0 +1,236 @@
+package mem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAL__replay(t *testing.T) {
+	path := testBackupPath(t)
+	w, err := openWAL(path, 0)
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, w.commit(counterRecord("PollCount", 5, now)))
+	require.NoError(t, w.commit(gaugeRecord("HeapAlloc", 1.5, now)))
+	require.NoError(t, w.commit(gaugeRecord("Old", 1, now), deleteRecord("gauge", "Old")))
+	require.NoError(t, w.close())
+
+	segment := segmentPath(path, 1)
+	valid, err := os.ReadFile(segment)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(segment, append(valid, `1234 [{"op":"se`...), 0600))
+
+	s := newStore()
+	last, replayed, err := replayWAL(path, s, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, last)
+	assert.Equal(t, 4, replayed)
+	m := s.snapshot(nil)
+	assert.Equal(t, int64(5), m.CounterMetrics["PollCount"].Value)
+	assert.Equal(t, 1.5, m.GaugeMetrics["HeapAlloc"].Value)
+	assert.NotContains(t, m.GaugeMetrics, "Old")
+	data, err := os.ReadFile(segment)
+	require.NoError(t, err)
+	assert.Equal(t, valid, data, "torn tail must be truncated")
+
+	// сегменты до from уже учтены в снимке.
+	last, replayed, err = replayWAL(path, newStore(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, last)
+	assert.Zero(t, replayed)
+
+	// поврежденная запись не в хвосте последнего сегмента - ошибка.
+	require.NoError(t, os.WriteFile(segment, append([]byte("00000000 []\n"), valid...), 0600))
+	last, _, err = replayWAL(path, newStore(), 0)
+	assert.ErrorIs(t, err, ErrCorruptWAL)
+	assert.Equal(t, 1, last)
+}
+
+func TestWAL__replayMissingSegment(t *testing.T) {
+	path := testBackupPath(t)
+	for segment := range 3 {
+		w, err := openWAL(path, segment)
+		require.NoError(t, err)
+		require.NoError(t, w.commit(counterRecord("PollCount", int64(segment), time.Now())))
+		require.NoError(t, w.close())
+	}
+	require.NoError(t, os.Remove(segmentPath(path, 2)))
+
+	_, _, err := replayWAL(path, newStore(), 1)
+	assert.ErrorIs(t, err, ErrCorruptWAL)
+	_, _, err = replayWAL(path, newStore(), 2)
+	assert.ErrorIs(t, err, ErrCorruptWAL)
+	s := newStore()
+	_, replayed, err := replayWAL(path, s, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+}
+
+func TestBackupRepo__WALRecovery(t *testing.T) {
+	const increments = 10
+	ctx := context.TODO()
+	path := testBackupPath(t)
+
+	repo, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true))
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range increments {
+				_, err := repo.IncrementCounter(ctx, "PollCount", 1)
+				assert.NoError(t, err)
+				assert.NoError(t, repo.UpsertGauge(ctx, fmt.Sprintf("gauge_%d", w), float64(i)))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, repo.RenameMetric(ctx, "gauge", "gauge_0", "renamed"))
+	require.NoError(t, repo.DeleteMetric(ctx, "gauge", "gauge_1"))
+	// сбой: снимок не записан, есть только журнал.
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	restored, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true))
+	require.NoError(t, err)
+	startBackupRepo(t, restored)
+	gauges, counters := restored.store.len()
+	assert.Equal(t, workers-1, gauges)
+	assert.Equal(t, 1, counters)
+	cm, err := restored.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*increments), cm.Value)
+	gm, err := restored.GetGaugeMetric(ctx, "renamed")
+	require.NoError(t, err)
+	assert.Equal(t, float64(increments-1), gm.Value)
+	_, err = restored.GetGaugeMetric(ctx, "gauge_1")
+	assert.Error(t, err)
+}
+
+func TestBackupRepo__WALCompaction(t *testing.T) {
+	ctx := context.TODO()
+	path := testBackupPath(t)
+
+	repo, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true))
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+	_, err = repo.IncrementCounter(ctx, "PollCount", 1)
+	require.NoError(t, err)
+	require.NoError(t, repo.makeBackup())
+	_, err = repo.IncrementCounter(ctx, "PollCount", 2)
+	require.NoError(t, err)
+
+	segments, err := walSegments(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, segments, "segments covered by snapshot must be removed")
+
+	restored, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true))
+	require.NoError(t, err)
+	require.NoError(t, restored.LoadAndStartBackup(ctx))
+	cm, err := restored.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cm.Value)
+
+	restored.Shutdown(ctx)
+	segments, err = walSegments(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, segments)
+}
+
+func TestBackupRepo__WALWriteFailure(t *testing.T) {
+	ctx := context.TODO()
+	path := testBackupPath(t)
+
+	repo, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true))
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+	_, err = repo.IncrementCounter(ctx, "PollCount", 1)
+	require.NoError(t, err)
+
+	// запись в журнал перестает проходить: изменение не должно попасть в память.
+	require.NoError(t, repo.wal.file.Close())
+	_, err = repo.IncrementCounter(ctx, "PollCount", 1)
+	require.Error(t, err)
+	assert.Error(t, repo.UpsertGauge(ctx, "HeapAlloc", 1))
+	cm, err := repo.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cm.Value)
+	_, err = repo.GetGaugeMetric(ctx, "HeapAlloc")
+	assert.Error(t, err)
+
+	// сжатие в снимок начинает новый сегмент, и запись восстанавливается.
+	assert.Eventually(t, func() bool {
+		return repo.UpsertGauge(ctx, "HeapAlloc", 1) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	value, err := repo.IncrementCounter(ctx, "PollCount", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), value)
+	require.Eventually(t, func() bool {
+		return !repo.compacting.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	restored, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true))
+	require.NoError(t, err)
+	startBackupRepo(t, restored)
+	cm, err = restored.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cm.Value)
+	gm, err := restored.GetGaugeMetric(ctx, "HeapAlloc")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), gm.Value)
+}
+
+func TestBackupRepo__WALRetainedSnapshot(t *testing.T) {
+	ctx := context.TODO()
+	path := testBackupPath(t)
+
+	repo, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true), WithRetain(2))
+	require.NoError(t, err)
+	startBackupRepo(t, repo)
+	for _, delta := range []int64{1, 2} {
+		_, err = repo.IncrementCounter(ctx, "PollCount", delta)
+		require.NoError(t, err)
+		require.NoError(t, repo.makeBackup())
+	}
+	_, err = repo.IncrementCounter(ctx, "PollCount", 4)
+	require.NoError(t, err)
+
+	segments, err := walSegments(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, segments, "segments needed by older snapshot must be kept")
+
+	// новый снимок поврежден: восстановление из старого повторяет журнал с его сегмента.
+	require.NoError(t, os.WriteFile(path, []byte("metrics-backup v1 sha256=00 wal=3\n{"), 0600))
+	restored, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true), WithRetain(2))
+	require.NoError(t, err)
+	startBackupRepo(t, restored)
+	cm, err := restored.GetCounterMetric(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), cm.Value)
+}
+
+func TestBackupRepo__WALCorruptSegment(t *testing.T) {
+	path := testBackupPath(t)
+	for segment := range 2 {
+		w, err := openWAL(path, segment)
+		require.NoError(t, err)
+		require.NoError(t, w.commit(counterRecord("PollCount", int64(segment+1), time.Now())))
+		require.NoError(t, w.close())
+	}
+	require.NoError(t, os.WriteFile(segmentPath(path, 1), []byte("00000000 []\n"), 0600))
+
+	repo, err := NewBackupRepo(time.Hour, path, logrus.New(), WithWAL(true), WithAllowEmpty(true))
+	require.NoError(t, err)
+	err = repo.LoadAndStartBackup(context.TODO())
+	assert.ErrorIs(t, err, ErrCorruptWAL)
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SortField - поле сортировки списка метрик.
+type SortField string
+
+const (
+	SortByName      SortField = "name"
+	SortByUpdatedAt SortField = "updated_at"
+)
+
+var ErrBadGlob = errors.New("bad glob pattern")
+
+// Metric - метрика любого типа в списке: у gauge заполнено Value, у counter - Delta.
+type Metric struct {
+	UpdatedAt time.Time
+	Name      string
+	Type      string
+	Value     float64
+	Delta     int64
+}
+
+// Cursor - последняя метрика предыдущей страницы, список продолжается строго после нее.
+// Gauge и counter могут называться одинаково, поэтому тип входит в курсор.
+type Cursor struct {
+	UpdatedAt time.Time
+	Name      string
+	Type      string
+}
+
+// ListFilter задает выборку метрик. Пустые условия не применяются,
+// Glob и Regex проверяются по имени целиком.
+type ListFilter struct {
+	After  *Cursor
+	Type   string
+	Prefix string
+	Glob   string
+	Regex  string
+	Sort   SortField
+	Desc   bool
+	Limit  int
+}
+
+// Compare сравнивает метрики в порядке фильтра, имя и тип различают метрики с одинаковым временем.
+func (f ListFilter) Compare(a, b Cursor) int {
+	c := strings.Compare(a.Name, b.Name)
+	if c == 0 {
+		c = strings.Compare(a.Type, b.Type)
+	}
+	if f.Sort == SortByUpdatedAt {
+		if byTime := a.UpdatedAt.Compare(b.UpdatedAt); byTime != 0 {
+			c = byTime
+		}
+	}
+	if f.Desc {
+		return -c
+	}
+	return c
+}
+
+// NameMatcher возвращает проверку имени по Prefix, Glob и Regex фильтра.
+func (f ListFilter) NameMatcher() (func(string) bool, error) {
+	var patterns []*regexp.Regexp
+	if f.Glob != "" {
+		expr, err := GlobToRegexp(f.Glob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob: %w", err)
+		}
+		patterns = append(patterns, regexp.MustCompile(expr))
+	}
+	if f.Regex != "" {
+		re, err := regexp.Compile(f.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return func(name string) bool {
+		if !strings.HasPrefix(name, f.Prefix) {
+			return false
+		}
+		for _, re := range patterns {
+			if !re.MatchString(name) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// GlobToRegexp переводит glob в регулярное выражение для имени целиком:
+// * - любая строка, ? - любой символ, [abc], [a-z] и [!abc] - классы символов.
+// Одно выражение понятно и regexp, и оператору ~ в Postgres.
+func GlobToRegexp(glob string) (string, error) {
+	var sb strings.Builder
+	sb.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteByte('.')
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 1 {
+				return "", ErrBadGlob
+			}
+			class := glob[i+1 : i+1+end]
+			sb.WriteByte('[')
+			if class[0] == '!' {
+				sb.WriteByte('^')
+				class = class[1:]
+			}
+			sb.WriteString(strings.ReplaceAll(class, `\`, `\\`))
+			sb.WriteByte(']')
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteByte('$')
+	if _, err := regexp.Compile(sb.String()); err != nil {
+		return "", ErrBadGlob
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob      string
+		match     []string
+		notMatch  []string
+		wantError bool
+	}{
+		{glob: "Heap*", match: []string{"Heap", "HeapAlloc"}, notMatch: []string{"xHeap"}},
+		{glob: "cpu.?", match: []string{"cpu.1"}, notMatch: []string{"cpu12", "cpu.12"}},
+		{glob: "m[0-9]", match: []string{"m1"}, notMatch: []string{"ma"}},
+		{glob: "m[!0-9]", match: []string{"ma"}, notMatch: []string{"m1"}},
+		{glob: "a+b(c)", match: []string{"a+b(c)"}, notMatch: []string{"aab"}},
+		{glob: "[a", wantError: true},
+		{glob: "[]", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			expr, err := GlobToRegexp(tt.glob)
+			if tt.wantError {
+				assert.ErrorIs(t, err, ErrBadGlob)
+				return
+			}
+			require.NoError(t, err)
+			re := regexp.MustCompile(expr)
+			for _, name := range tt.match {
+				assert.True(t, re.MatchString(name), name)
+			}
+			for _, name := range tt.notMatch {
+				assert.False(t, re.MatchString(name), name)
+			}
+		})
+	}
+}
+
+func TestListFilter_Compare(t *testing.T) {
+	now := time.Now()
+	older := Cursor{Name: "b", UpdatedAt: now}
+	newer := Cursor{Name: "a", UpdatedAt: now.Add(time.Second)}
+
+	assert.Positive(t, ListFilter{Sort: SortByName}.Compare(older, newer))
+	assert.Negative(t, ListFilter{Sort: SortByUpdatedAt}.Compare(older, newer))
+	assert.Positive(t, ListFilter{Sort: SortByUpdatedAt, Desc: true}.Compare(older, newer))
+	assert.Zero(t, ListFilter{Sort: SortByUpdatedAt, Desc: true}.Compare(older, older))
+
+	counter := Cursor{Name: "b", Type: "counter", UpdatedAt: now}
+	gauge := Cursor{Name: "b", Type: "gauge", UpdatedAt: now}
+	assert.Negative(t, ListFilter{Sort: SortByName}.Compare(counter, gauge))
+	assert.Positive(t, ListFilter{Sort: SortByUpdatedAt, Desc: true}.Compare(counter, gauge))
+}
@@ -1,13 +1,17 @@
 package models
 
+import "time"
+
 type GaugeMetric struct {
-	Name  string  `json:"name"`
-	Type  string  `json:"type"`
-	Value float64 `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Value     float64   `json:"value"`
 }
 
 type CounterMetric struct {
-	Name  string `json:"name"`
-	Type  string `json:"type"`
-	Value int64  `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Value     int64     `json:"value"`
 }
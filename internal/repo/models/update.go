@@ -0,0 +1,25 @@
+package models
+
+import "fmt"
+
+// Update - изменение метрики в пакете: gauge получает Value, к counter прибавляется Delta.
+type Update struct {
+	Name  string
+	Type  string
+	Value float64
+	Delta int64
+}
+
+// UpdateError - ошибка изменения с индексом Index в пакете, весь пакет при этом не применен.
+type UpdateError struct {
+	Err   error
+	Index int
+}
+
+func (e *UpdateError) Error() string {
+	return fmt.Sprintf("update %d failed: %s", e.Index, e.Err)
+}
+
+func (e *UpdateError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,21 @@
+package repo
+
+import "github.com/NStegura/metrics/internal/repo/internal/mem"
+
+// Option настраивает хранилище в памяти с бекапом, для Postgres опции не применяются.
+type Option = mem.BackupOption
+
+// WithBackupRetain задает, сколько последних снимков бекапа хранить.
+func WithBackupRetain(retain int) Option {
+	return mem.WithRetain(retain)
+}
+
+// WithAllowEmptyRestore разрешает стартовать без метрик, если все снимки бекапа повреждены.
+func WithAllowEmptyRestore(allow bool) Option {
+	return mem.WithAllowEmpty(allow)
+}
+
+// WithWAL включает журнал изменений рядом с файлом бекапа.
+func WithWAL(enabled bool) Option {
+	return mem.WithWAL(enabled)
+}
@@ -20,7 +20,19 @@ type Repository interface {
 	GetGaugeMetric(ctx context.Context, name string) (models.GaugeMetric, error)
 	CreateGaugeMetric(ctx context.Context, name string, mType string, value float64) error
 	UpdateGaugeMetric(ctx context.Context, name string, value float64) error
+	IncrementCounter(ctx context.Context, name string, delta int64) (int64, error)
+	UpsertGauge(ctx context.Context, name string, value float64) error
+	ApplyBatch(ctx context.Context, updates []models.Update) ([]models.Metric, error)
 	GetAllMetrics(ctx context.Context) ([]models.GaugeMetric, []models.CounterMetric, error)
+	ListMetrics(ctx context.Context, filter models.ListFilter) ([]models.Metric, error)
+	DeleteMetric(ctx context.Context, mType string, name string) error
+	DeleteMetrics(ctx context.Context, filter models.ListFilter) (int64, error)
+	RenameMetric(ctx context.Context, mType string, name string, newName string) error
+	ResetCounter(ctx context.Context, name string) error
+	HistoryAvailable() bool
+	GetMetricHistory(ctx context.Context, mType string, name string, limit int) ([]models.HistoryPoint, error)
+	ReserveBatch(ctx context.Context, batchID string) (bool, error)
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
 
 	Shutdown(ctx context.Context)
 	Ping(ctx context.Context) error
@@ -34,6 +46,7 @@ func New(
 	fileStoragePath string,
 	restore bool,
 	logger *logrus.Logger,
+	opts ...Option,
 ) (Repository, error) {
 	if dbDSN != "" {
 		repo, err := initDB(ctx, dbDSN, logger)
@@ -44,7 +57,7 @@ func New(
 	}
 
 	if restore {
-		repo, err := initBackupRepo(ctx, storeInterval, fileStoragePath, logger)
+		repo, err := initBackupRepo(ctx, storeInterval, fileStoragePath, logger, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -77,9 +90,10 @@ func initBackupRepo(
 	storeInterval time.Duration,
 	fileStoragePath string,
 	logger *logrus.Logger,
+	opts ...Option,
 ) (Repository, error) {
 	logger.Info("Init mem repo with backup")
-	repo, err := mem.NewBackupRepo(storeInterval, fileStoragePath, logger)
+	repo, err := mem.NewBackupRepo(storeInterval, fileStoragePath, logger, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create mem repo with backup: %w", err)
 	}
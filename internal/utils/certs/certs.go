@@ -0,0 +1,218 @@
+// Package certs настраивает TLS и mTLS с перечитыванием сертификатов с диска без перезапуска.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var errNoCertificates = errors.New("no certificates found in CA file")
+
+// fileState хранит время изменения файла для проверки, нужно ли его перечитать.
+type fileState struct {
+	path    string
+	modTime time.Time
+}
+
+func (f *fileState) changed() (bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", f.path, err)
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return false, nil
+	}
+	f.modTime = info.ModTime()
+	return true, nil
+}
+
+// Reloader отдает сертификат и пул CA, перечитывая их при изменении файлов.
+type Reloader struct {
+	cert   *tls.Certificate
+	pool   *x509.CertPool
+	files  []*fileState
+	mu     sync.Mutex
+	certs  bool
+	caPath string
+}
+
+// NewReloader загружает сертификат с ключом и CA, пустые пути пропускаются.
+func NewReloader(certPath, keyPath, caPath string) (*Reloader, error) {
+	r := &Reloader{caPath: caPath}
+	if certPath != "" || keyPath != "" {
+		r.certs = true
+		r.files = append(r.files, &fileState{path: certPath}, &fileState{path: keyPath})
+	}
+	if caPath != "" {
+		r.files = append(r.files, &fileState{path: caPath})
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload перечитывает файлы, если какой-либо из них изменился.
+func (r *Reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changed bool
+	for _, f := range r.files {
+		ok, err := f.changed()
+		if err != nil {
+			return err
+		}
+		changed = changed || ok
+	}
+	if !changed {
+		return nil
+	}
+
+	err := r.load()
+	if err != nil {
+		// файлы могут быть записаны не полностью, перечитаем при следующем обращении.
+		for _, f := range r.files {
+			f.modTime = time.Time{}
+		}
+	}
+	return err
+}
+
+func (r *Reloader) load() error {
+	if r.certs {
+		cert, err := tls.LoadX509KeyPair(r.files[0].path, r.files[1].path)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair: %w", err)
+		}
+		r.cert = &cert
+	}
+	if r.caPath != "" {
+		pool, err := loadCertPool(r.caPath)
+		if err != nil {
+			return err
+		}
+		r.pool = pool
+	}
+	return nil
+}
+
+// current возвращает актуальные сертификат и пул CA,
+// если перечитать файлы не удалось, используются ранее загруженные.
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool, error) {
+	err := r.reload()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil && r.cert == nil && r.pool == nil {
+		return nil, nil, err
+	}
+	return r.cert, r.pool, nil
+}
+
+// ServerConfig возвращает tls.Config сервера, при verifyClient требуется сертификат клиента, подписанный CA.
+// CA без verifyClient отклоняется: сертификат клиента тогда не запрашивается, и CA ничего не проверяет.
+func ServerConfig(certPath, keyPath, caPath string, verifyClient bool) (*tls.Config, error) {
+	if certPath == "" || keyPath == "" {
+		return nil, errors.New("server requires certificate and key")
+	}
+	if verifyClient && caPath == "" {
+		return nil, errors.New("client verification requires CA")
+	}
+	if !verifyClient && caPath != "" {
+		return nil, errors.New("CA is set but client verification is disabled, client certificates are not requested")
+	}
+	r, err := NewReloader(certPath, keyPath, caPath)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool, err := r.current()
+			if err != nil {
+				return nil, err
+			}
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}
+			if verifyClient {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return cfg, nil
+		},
+	}, nil
+}
+
+// ClientConfig возвращает tls.Config клиента для сервера serverName, сертификат клиента передается серверу для mTLS.
+// Сертификат, ключ и CA перечитываются при изменении файлов вместе: с CA сертификат сервера проверяется
+// в VerifyConnection по актуальному пулу, поэтому serverName обязателен - SNI не передает IP адрес сервера.
+func ClientConfig(serverName, certPath, keyPath, caPath string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName}
+	if caPath != "" && serverName == "" {
+		return nil, errors.New("server name is required to verify server certificate with CA")
+	}
+	if certPath == "" && keyPath == "" && caPath == "" {
+		return cfg, nil
+	}
+	r, err := NewReloader(certPath, keyPath, caPath)
+	if err != nil {
+		return nil, err
+	}
+	if certPath != "" || keyPath != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _, err := r.current()
+			return cert, err
+		}
+	}
+	if caPath != "" {
+		// стандартная проверка взяла бы пул CA на момент создания конфига.
+		cfg.InsecureSkipVerify = true //nolint:gosec // сертификат сервера проверяет VerifyConnection
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			_, pool, err := r.current()
+			if err != nil {
+				return err
+			}
+			return verifyServer(cs, serverName, pool)
+		}
+	}
+	return cfg, nil
+}
+
+// verifyServer проверяет цепочку сертификата сервера по пулу CA и имя или IP сервера.
+func verifyServer(cs tls.ConnectionState, serverName string, pool *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server sent no certificate")
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         pool,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("failed to verify server certificate: %w", err)
+	}
+	return nil
+}
+
+func loadCertPool(caPath string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errNoCertificates
+	}
+	return pool, nil
+}
@@ -0,0 +1,188 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	path string
+}
+
+func newTestCA(t *testing.T, dir string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return &testCA{cert: cert, key: key, path: path}
+}
+
+// issue выпускает сертификат и записывает его с ключом в dir/name.pem и dir/name.key.
+func (ca *testCA) issue(t *testing.T, dir, name, cn string) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath = filepath.Join(dir, name+".pem")
+	keyPath = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath
+}
+
+// handshake устанавливает соединение и возвращает сертификаты, которые увидели сервер и клиент.
+func handshake(t *testing.T, serverCfg, clientCfg *tls.Config) (client, server *x509.Certificate) {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	peers := make(chan *x509.Certificate, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			peers <- nil
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		tlsConn, _ := conn.(*tls.Conn)
+		if err = tlsConn.Handshake(); err != nil || len(tlsConn.ConnectionState().PeerCertificates) == 0 {
+			peers <- nil
+			return
+		}
+		peers <- tlsConn.ConnectionState().PeerCertificates[0]
+	}()
+
+	clientCfg.ServerName = "localhost"
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	return <-peers, conn.ConnectionState().PeerCertificates[0]
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "server", "metrics-server")
+	clientCert, clientKey := ca.issue(t, dir, "client", "agent-1")
+
+	serverCfg, err := ServerConfig(serverCert, serverKey, ca.path, true)
+	require.NoError(t, err)
+	clientCfg, err := ClientConfig("localhost", clientCert, clientKey, ca.path)
+	require.NoError(t, err)
+
+	client, server := handshake(t, serverCfg, clientCfg)
+	require.NotNil(t, client)
+	assert.Equal(t, "metrics-server", server.Subject.CommonName)
+
+	id := IdentityFromCert(client)
+	assert.Equal(t, "agent-1", id.CommonName)
+	assert.Equal(t, []string{"localhost", "127.0.0.1"}, id.SANs)
+}
+
+func TestServerConfig__reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "server", "server-v1")
+
+	serverCfg, err := ServerConfig(serverCert, serverKey, "", false)
+	require.NoError(t, err)
+	clientCfg, err := ClientConfig("localhost", "", "", ca.path)
+	require.NoError(t, err)
+
+	_, server := handshake(t, serverCfg, clientCfg.Clone())
+	assert.Equal(t, "server-v1", server.Subject.CommonName)
+
+	ca.issue(t, dir, "server", "server-v2")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(serverCert, future, future))
+	require.NoError(t, os.Chtimes(serverKey, future, future))
+
+	_, server = handshake(t, serverCfg, clientCfg.Clone())
+	assert.Equal(t, "server-v2", server.Subject.CommonName)
+}
+
+func TestServerConfig__clientAuthRequiresCA(t *testing.T) {
+	_, err := ServerConfig("cert.pem", "key.pem", "", true)
+	assert.Error(t, err)
+}
+
+func TestServerConfig__CAWithoutClientAuth(t *testing.T) {
+	_, err := ServerConfig("cert.pem", "key.pem", "ca.pem", false)
+	assert.Error(t, err)
+}
+
+func TestClientConfig__reloadCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "server", "server-v1")
+	serverCfg, err := ServerConfig(serverCert, serverKey, "", false)
+	require.NoError(t, err)
+	clientCfg, err := ClientConfig("localhost", "", "", ca.path)
+	require.NoError(t, err)
+
+	_, server := handshake(t, serverCfg, clientCfg.Clone())
+	assert.Equal(t, "server-v1", server.Subject.CommonName)
+
+	// сервер и CA сменились, клиент доверяет новому CA без пересоздания конфига.
+	rotated := newTestCA(t, t.TempDir())
+	rotated.issue(t, dir, "server", "server-v2")
+	data, err := os.ReadFile(rotated.path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ca.path, data, 0600))
+	future := time.Now().Add(time.Minute)
+	for _, path := range []string{serverCert, serverKey, ca.path} {
+		require.NoError(t, os.Chtimes(path, future, future))
+	}
+
+	_, server = handshake(t, serverCfg, clientCfg.Clone())
+	assert.Equal(t, "server-v2", server.Subject.CommonName)
+
+	_, err = ClientConfig("", "", "", ca.path)
+	assert.Error(t, err, "CA requires server name")
+}
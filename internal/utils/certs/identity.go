@@ -0,0 +1,47 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+)
+
+type identityKey struct{}
+
+// Identity описывает клиента, предъявившего сертификат.
+type Identity struct {
+	CommonName string
+	SANs       []string
+}
+
+func (i Identity) String() string {
+	if len(i.SANs) == 0 {
+		return i.CommonName
+	}
+	return i.CommonName + " (" + strings.Join(i.SANs, ", ") + ")"
+}
+
+// IdentityFromCert извлекает CN и SAN из сертификата.
+func IdentityFromCert(cert *x509.Certificate) Identity {
+	id := Identity{CommonName: cert.Subject.CommonName}
+	id.SANs = append(id.SANs, cert.DNSNames...)
+	id.SANs = append(id.SANs, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		id.SANs = append(id.SANs, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		id.SANs = append(id.SANs, uri.String())
+	}
+	return id
+}
+
+// WithIdentity сохраняет данные клиента в контексте запроса.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext возвращает данные клиента, если он предъявил сертификат.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
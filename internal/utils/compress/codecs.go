@@ -0,0 +1,160 @@
+package compress
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	Gzip   = "gzip"
+	Zstd   = "zstd"
+	Snappy = "snappy"
+)
+
+func init() {
+	Register(&gzipCodec{})
+	Register(&zstdCodec{})
+	Register(&snappyCodec{})
+}
+
+type gzipCodec struct {
+	writers sync.Pool
+}
+
+func (c *gzipCodec) Name() string {
+	return Gzip
+}
+
+func (c *gzipCodec) Compress(w io.Writer) (io.WriteCloser, error) {
+	zw, ok := c.writers.Get().(*gzip.Writer)
+	if !ok {
+		return &pooledWriter{WriteCloser: gzip.NewWriter(w), pool: &c.writers}, nil
+	}
+	zw.Reset(w)
+	return &pooledWriter{WriteCloser: zw, pool: &c.writers}, nil
+}
+
+func (c *gzipCodec) Decompress(r io.Reader) (io.Reader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init gzip reader: %w", err)
+	}
+	return zr, nil
+}
+
+// zstdCodec переиспользует энкодеры и декодеры: их создание дороже сжатия небольшого батча.
+type zstdCodec struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+func (c *zstdCodec) Name() string {
+	return Zstd
+}
+
+func (c *zstdCodec) Compress(w io.Writer) (io.WriteCloser, error) {
+	enc, ok := c.encoders.Get().(*zstd.Encoder)
+	if !ok {
+		var err error
+		enc, err = zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init zstd writer: %w", err)
+		}
+	} else {
+		enc.Reset(w)
+	}
+	return &pooledWriter{WriteCloser: enc, pool: &c.encoders}, nil
+}
+
+func (c *zstdCodec) Decompress(r io.Reader) (io.Reader, error) {
+	dec, ok := c.decoders.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		dec, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init zstd reader: %w", err)
+		}
+	} else if err := dec.Reset(r); err != nil {
+		c.decoders.Put(dec)
+		return nil, fmt.Errorf("failed to reset zstd reader: %w", err)
+	}
+	return &zstdReader{dec: dec, pool: &c.decoders}, nil
+}
+
+// zstdReader возвращает декодер в пул, когда поток прочитан до конца или закрыт.
+type zstdReader struct {
+	dec  *zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	if r.dec == nil {
+		return 0, io.EOF
+	}
+	n, err := r.dec.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.release()
+	}
+	return n, err //nolint:wrapcheck // io.EOF сравнивается вызывающим
+}
+
+func (r *zstdReader) Close() error {
+	r.release()
+	return nil
+}
+
+func (r *zstdReader) release() {
+	if r.dec == nil {
+		return
+	}
+	if err := r.dec.Reset(nil); err == nil {
+		r.pool.Put(r.dec)
+	}
+	r.dec = nil
+}
+
+type snappyCodec struct {
+	writers sync.Pool
+}
+
+func (c *snappyCodec) Name() string {
+	return Snappy
+}
+
+func (c *snappyCodec) Compress(w io.Writer) (io.WriteCloser, error) {
+	sw, ok := c.writers.Get().(*snappy.Writer)
+	if !ok {
+		return &pooledWriter{WriteCloser: snappy.NewBufferedWriter(w), pool: &c.writers}, nil
+	}
+	sw.Reset(w)
+	return &pooledWriter{WriteCloser: sw, pool: &c.writers}, nil
+}
+
+func (c *snappyCodec) Decompress(r io.Reader) (io.Reader, error) {
+	return snappy.NewReader(r), nil
+}
+
+// pooledWriter возвращает писатель в пул после закрытия.
+type pooledWriter struct {
+	io.WriteCloser
+	pool *sync.Pool
+}
+
+func (w *pooledWriter) Close() error {
+	if w.WriteCloser == nil {
+		return nil
+	}
+	err := w.WriteCloser.Close()
+	w.pool.Put(w.WriteCloser)
+	w.WriteCloser = nil
+	if err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package compress содержит реестр кодеков сжатия, общий для клиента и сервера.
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// ErrUnknownCodec возвращается для незарегистрированного типа сжатия.
+var ErrUnknownCodec = errors.New("unknown compress codec")
+
+// Codec сжимает и распаковывает поток данных.
+// Интерфейс совпадает с encoding.Compressor из gRPC.
+type Codec interface {
+	Name() string
+	Compress(w io.Writer) (io.WriteCloser, error)
+	Decompress(r io.Reader) (io.Reader, error)
+}
+
+var registry = make(map[string]Codec)
+
+// Register добавляет кодек в реестр и в gRPC, чтобы клиент и сервер
+// понимали одинаковые Content-Encoding и grpc-encoding.
+// Как и в gRPC, вызывается только при инициализации пакета.
+func Register(codec Codec) {
+	registry[codec.Name()] = codec
+	encoding.RegisterCompressor(codec)
+}
+
+// Get возвращает кодек по имени из Content-Encoding.
+func Get(name string) (Codec, error) {
+	codec, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, name)
+	}
+	return codec, nil
+}
+
+// Names возвращает имена зарегистрированных кодеков.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Encode сжимает data кодеком.
+func Encode(codec Codec, data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := codec.Compress(&b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init %s writer: %w", codec.Name(), err)
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write data to compress buffer: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Negotiate выбирает кодек по заголовку Accept-Encoding: с наибольшим q,
+// при равных q - первый в заголовке. false, если подходящего кодека нет.
+func Negotiate(acceptEncoding string) (Codec, bool) {
+	var (
+		best  Codec
+		bestQ float64
+	)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		codec, err := Get(name)
+		if err != nil {
+			continue
+		}
+		q := quality(params)
+		if q > bestQ {
+			best, bestQ = codec, q
+		}
+	}
+	return best, best != nil
+}
+
+// quality разбирает параметр q из Accept-Encoding, по умолчанию 1.
+func quality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(key) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
@@ -0,0 +1,86 @@
+package compress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type benchMetric struct {
+	Delta *int64   `json:"delta,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+	ID    string   `json:"id"`
+	MType string   `json:"type"`
+}
+
+// updatesBatch собирает тело /updates/, похожее на батч агента:
+// runtime и host gauge метрики и счетчик опросов.
+func updatesBatch(size int) []byte {
+	metrics := make([]benchMetric, 0, size+1)
+	delta := int64(size)
+	metrics = append(metrics, benchMetric{ID: "PollCount", MType: "counter", Delta: &delta})
+	for i := range size {
+		value := float64(i)*1234.5678 + 0.125
+		metrics = append(metrics, benchMetric{ID: fmt.Sprintf("RuntimeGauge%d", i), MType: "gauge", Value: &value})
+	}
+	body, err := json.Marshal(metrics)
+	if err != nil {
+		panic(err)
+	}
+	return body
+}
+
+// BenchmarkEncode сравнивает CPU и степень сжатия кодеков, ratio - доля от исходного размера.
+func BenchmarkEncode(b *testing.B) {
+	for _, size := range []int{30, 300} {
+		data := updatesBatch(size)
+		for _, name := range []string{Gzip, Zstd, Snappy} {
+			codec, err := Get(name)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("%s/%d", name, size), func(b *testing.B) {
+				var encoded []byte
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for range b.N {
+					if encoded, err = Encode(codec, data); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(encoded))/float64(len(data)), "ratio")
+			})
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, size := range []int{30, 300} {
+		data := updatesBatch(size)
+		for _, name := range []string{Gzip, Zstd, Snappy} {
+			codec, err := Get(name)
+			if err != nil {
+				b.Fatal(err)
+			}
+			encoded, err := Encode(codec, data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("%s/%d", name, size), func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				b.ReportAllocs()
+				for range b.N {
+					r, err := codec.Decompress(bytes.NewReader(encoded))
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err = io.Copy(io.Discard, r); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, codec Codec, data []byte) []byte {
+	t.Helper()
+	r, err := codec.Decompress(bytes.NewReader(data))
+	require.NoError(t, err)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out
+}
+
+func TestCodecs(t *testing.T) {
+	data := updatesBatch(40)
+	for _, name := range []string{Gzip, Zstd, Snappy} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := Get(name)
+			require.NoError(t, err)
+
+			// Повторные вызовы проверяют переиспользование энкодеров и декодеров из пула.
+			for range 3 {
+				encoded, err := Encode(codec, data)
+				require.NoError(t, err)
+				assert.Less(t, len(encoded), len(data))
+				assert.Equal(t, data, decode(t, codec, encoded))
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	codec, err := Get(" ZSTD ")
+	require.NoError(t, err)
+	assert.Equal(t, Zstd, codec.Name())
+
+	_, err = Get("br")
+	require.ErrorIs(t, err, ErrUnknownCodec)
+
+	assert.Equal(t, []string{Gzip, Snappy, Zstd}, Names())
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{"empty", "", ""},
+		{"unknown only", "br, deflate", ""},
+		{"single", "gzip", Gzip},
+		{"first wins on equal q", "zstd, gzip", Zstd},
+		{"highest q", "gzip;q=0.5, snappy;q=0.8, zstd;q=0.1", Snappy},
+		{"q zero is refusal", "zstd;q=0, gzip;q=0.2", Gzip},
+		{"bad q", "zstd;q=x", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, ok := Negotiate(tt.acceptEncoding)
+			assert.Equal(t, tt.want != "", ok)
+			if ok {
+				assert.Equal(t, tt.want, codec.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package idempotency описывает ключ идемпотентности пакета метрик:
+// клиент передает один ключ во всех повторах пакета, сервер применяет пакет
+// с известным ключом один раз, повторы считаются успешными.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Header передает ключ в HTTP запросе.
+	Header = "Idempotency-Key"
+	// ReplayedHeader выставляется в ответе, если пакет с ключом уже был применен.
+	ReplayedHeader = "Idempotent-Replayed"
+	// Key передает ключ в метаданных gRPC.
+	Key = "idempotency-key"
+
+	keyBytes  = 16
+	maxKeyLen = 128
+)
+
+var ErrInvalidKey = errors.New("invalid idempotency key")
+
+type keyCtx struct{}
+
+// NewKey возвращает случайный ключ в hex.
+func NewKey() (string, error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Validate проверяет ключ от клиента: непустой, ограниченной длины, из печатных ASCII символов.
+func Validate(key string) error {
+	if key == "" || len(key) > maxKeyLen {
+		return ErrInvalidKey
+	}
+	for i := range len(key) {
+		if key[i] < '!' || key[i] > '~' {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// WithKey сохраняет в контексте ключ пакета, из которого клиент выводит ключи частей пакета:
+// повтор того же пакета с тем же ключом сервер не применит второй раз.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtx{}, key)
+}
+
+// ChunkKey возвращает ключ части i пакета: производный от ключа из контекста или новый, если ключа нет.
+func ChunkKey(ctx context.Context, i int) (string, error) {
+	if key, ok := ctx.Value(keyCtx{}).(string); ok {
+		return fmt.Sprintf("%s-%d", key, i), nil
+	}
+	return NewKey()
+}
@@ -0,0 +1,58 @@
+package idempotency
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKey(t *testing.T) {
+	first, err := NewKey()
+	require.NoError(t, err)
+	second, err := NewKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.NoError(t, Validate(first))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "hex", key: "0123456789abcdef"},
+		{name: "uuid", key: "8f14e45f-ceea-467f-a0e8-6c1b1e3b4a5d"},
+		{name: "empty", key: "", wantErr: true},
+		{name: "too long", key: strings.Repeat("a", maxKeyLen+1), wantErr: true},
+		{name: "space", key: "batch 1", wantErr: true},
+		{name: "non ascii", key: "пакет", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidKey)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestChunkKey(t *testing.T) {
+	ctx := WithKey(context.Background(), "batch")
+	first, err := ChunkKey(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "batch-1", first)
+	assert.NoError(t, Validate(first))
+
+	first, err = ChunkKey(context.Background(), 1)
+	require.NoError(t, err)
+	second, err := ChunkKey(context.Background(), 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "without key in context every chunk gets a new key")
+}
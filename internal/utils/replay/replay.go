@@ -0,0 +1,151 @@
+// Package replay защищает подписанные запросы от повторной отправки:
+// клиент подписывает вместе с телом метку времени и случайный nonce,
+// сервер отклоняет устаревшие метки и уже встречавшиеся nonce.
+package replay
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// TimestampHeader и NonceHeader передают метку времени и nonce в HTTP запросе.
+	TimestampHeader = "X-Request-Timestamp"
+	NonceHeader     = "X-Request-Nonce"
+	// TimestampKey и NonceKey передают метку времени и nonce в метаданных gRPC.
+	TimestampKey = "x-request-timestamp"
+	NonceKey     = "x-request-nonce"
+
+	nonceBytes  = 16
+	maxNonceLen = 64
+)
+
+var (
+	ErrInvalid  = errors.New("invalid request timestamp or nonce")
+	ErrSkew     = errors.New("request timestamp is outside of allowed window")
+	ErrReplayed = errors.New("request nonce was already used")
+)
+
+// NewNonce возвращает случайный nonce в hex.
+func NewNonce() (string, error) {
+	b := make([]byte, nonceBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Timestamp форматирует метку времени запроса в миллисекундах unix.
+func Timestamp(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+// SignedData возвращает данные для HMAC: метку времени, nonce и тело.
+// Без метки и nonce подписывается только тело, как в старых клиентах.
+func SignedData(timestamp, nonce string, body []byte) []byte {
+	if timestamp == "" && nonce == "" {
+		return body
+	}
+	var b bytes.Buffer
+	b.Grow(len(timestamp) + len(nonce) + len(body) + 2)
+	b.WriteString(timestamp)
+	b.WriteByte('\n')
+	b.WriteString(nonce)
+	b.WriteByte('\n')
+	b.Write(body)
+	return b.Bytes()
+}
+
+type entry struct {
+	timestamp time.Time
+	nonce     string
+}
+
+// entries - куча запомненных nonce по возрастанию меток времени.
+type entries []entry
+
+func (h entries) Len() int           { return len(h) }
+func (h entries) Less(i, j int) bool { return h[i].timestamp.Before(h[j].timestamp) }
+func (h entries) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *entries) Push(x any) {
+	*h = append(*h, x.(entry)) //nolint:forcetypeassert // в куче только entry
+}
+
+func (h *entries) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// Guard хранит nonce запросов из окна допустимого расхождения часов.
+// Кэш ограничен size записями: при переполнении вытесняется запрос с самой старой меткой,
+// она запоминается, и запросы не новее нее отклоняются, так как их nonce уже не проверить.
+type Guard struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	seen   map[string]struct{}
+	order  entries
+	floor  time.Time
+	window time.Duration
+	size   int
+}
+
+func NewGuard(window time.Duration, size int) *Guard {
+	return &Guard{
+		now:    time.Now,
+		seen:   make(map[string]struct{}),
+		window: window,
+		size:   max(1, size),
+	}
+}
+
+// Check проверяет метку времени и запоминает nonce.
+// Вызывается после проверки подписи, чтобы неподписанные запросы не занимали кэш.
+func (g *Guard) Check(timestamp, nonce string) error {
+	if nonce == "" || len(nonce) > maxNonceLen {
+		return ErrInvalid
+	}
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	ts := time.UnixMilli(ms)
+	now := g.now()
+	if ts.Before(now.Add(-g.window)) || ts.After(now.Add(g.window)) {
+		return ErrSkew
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.evictExpired(now)
+	if _, ok := g.seen[nonce]; ok || !ts.After(g.floor) {
+		return ErrReplayed
+	}
+	if g.order.Len() >= g.size {
+		oldest := heap.Pop(&g.order).(entry) //nolint:forcetypeassert // в куче только entry
+		delete(g.seen, oldest.nonce)
+		if oldest.timestamp.After(g.floor) {
+			g.floor = oldest.timestamp
+		}
+	}
+	g.seen[nonce] = struct{}{}
+	heap.Push(&g.order, entry{timestamp: ts, nonce: nonce})
+	return nil
+}
+
+// evictExpired удаляет nonce, чьи метки уже вне окна и отклоняются по времени.
+func (g *Guard) evictExpired(now time.Time) {
+	deadline := now.Add(-g.window)
+	for g.order.Len() > 0 && g.order[0].timestamp.Before(deadline) {
+		old := heap.Pop(&g.order).(entry) //nolint:forcetypeassert // в куче только entry
+		delete(g.seen, old.nonce)
+	}
+}
@@ -0,0 +1,72 @@
+package replay
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGuard(size int) (*Guard, *time.Time) {
+	now := time.UnixMilli(1_700_000_000_000)
+	g := NewGuard(time.Minute, size)
+	g.now = func() time.Time { return now }
+	return g, &now
+}
+
+func TestGuard_Check(t *testing.T) {
+	g, now := newTestGuard(100)
+	ts := Timestamp(*now)
+
+	require.NoError(t, g.Check(ts, "a"))
+	require.ErrorIs(t, g.Check(ts, "a"), ErrReplayed)
+	require.NoError(t, g.Check(ts, "b"))
+
+	require.ErrorIs(t, g.Check(Timestamp(now.Add(-2*time.Minute)), "c"), ErrSkew)
+	require.ErrorIs(t, g.Check(Timestamp(now.Add(2*time.Minute)), "c"), ErrSkew)
+	require.ErrorIs(t, g.Check("yesterday", "c"), ErrInvalid)
+	require.ErrorIs(t, g.Check(ts, ""), ErrInvalid)
+	require.ErrorIs(t, g.Check(ts, strings.Repeat("n", maxNonceLen+1)), ErrInvalid)
+}
+
+func TestGuard_CheckExpired(t *testing.T) {
+	g, now := newTestGuard(100)
+	require.NoError(t, g.Check(Timestamp(*now), "a"))
+
+	*now = now.Add(2 * time.Minute)
+	require.NoError(t, g.Check(Timestamp(*now), "b"))
+	assert.Equal(t, 1, g.order.Len(), "expired nonce must be evicted")
+}
+
+func TestGuard_CheckBounded(t *testing.T) {
+	g, now := newTestGuard(3)
+	for i := range 5 {
+		require.NoError(t, g.Check(Timestamp(now.Add(time.Duration(i)*time.Millisecond)), fmt.Sprint(i)))
+	}
+	assert.Equal(t, 3, g.order.Len())
+
+	// Вытесненный nonce нельзя проверить, поэтому запрос с его меткой отклоняется.
+	require.ErrorIs(t, g.Check(Timestamp(*now), "0"), ErrReplayed)
+	require.NoError(t, g.Check(Timestamp(now.Add(time.Second)), "5"))
+}
+
+func TestGuard_CheckEvictsOldestTimestamp(t *testing.T) {
+	g, now := newTestGuard(2)
+	require.NoError(t, g.Check(Timestamp(now.Add(time.Second)), "late"))
+	require.NoError(t, g.Check(Timestamp(*now), "early"))
+	require.NoError(t, g.Check(Timestamp(now.Add(2*time.Second)), "new"))
+
+	// вытеснен запрос с самой старой меткой, а не первый по порядку:
+	// отклоняются только метки не новее нее.
+	require.ErrorIs(t, g.Check(Timestamp(*now), "other"), ErrReplayed)
+	require.NoError(t, g.Check(Timestamp(now.Add(500*time.Millisecond)), "other"))
+}
+
+func TestSignedData(t *testing.T) {
+	body := []byte("body")
+	assert.Equal(t, body, SignedData("", "", body))
+	assert.Equal(t, []byte("1\nabc\nbody"), SignedData("1", "abc", body))
+}
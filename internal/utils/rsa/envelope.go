@@ -0,0 +1,138 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Формат конверта:
+//
+//	magic (4 байта) | version (1 байт) | длина ключа (2 байта) | ключ AES, зашифрованный RSA-OAEP |
+//	nonce (12 байт) | тело, зашифрованное AES-256-GCM
+//
+// magic и version входят в дополнительные данные GCM.
+const (
+	envelopeMagic    = "MENV"
+	EnvelopeVersion1 = byte(1)
+
+	aesKeySize    = 32
+	gcmNonceSize  = 12
+	gcmTagSize    = 16
+	keyLenSize    = 2
+	envHeaderSize = len(envelopeMagic) + 1
+)
+
+// IsEnvelope проверяет, что сообщение начинается с заголовка конверта.
+func IsEnvelope(msg []byte) bool {
+	return len(msg) > envHeaderSize && bytes.HasPrefix(msg, []byte(envelopeMagic))
+}
+
+// EnvelopeOverhead возвращает, на сколько байт конверт больше исходного сообщения.
+func EnvelopeOverhead(public *rsa.PublicKey) int {
+	return envHeaderSize + keyLenSize + public.Size() + gcmNonceSize + gcmTagSize
+}
+
+// EncryptEnvelope шифрует сообщение случайным ключом AES-256-GCM,
+// сам ключ шифруется один раз через RSA-OAEP.
+func EncryptEnvelope(random io.Reader, public *rsa.PublicKey, msg []byte) ([]byte, error) {
+	key := make([]byte, aesKeySize)
+	if _, err := io.ReadFull(random, key); err != nil {
+		return nil, fmt.Errorf("failed to generate key, %w", err)
+	}
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), random, public, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt key, %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(random, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce, %w", err)
+	}
+
+	header := append([]byte(envelopeMagic), EnvelopeVersion1)
+	out := make([]byte, 0, len(msg)+EnvelopeOverhead(public))
+	out = append(out, header...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(wrappedKey)))
+	out = append(out, wrappedKey...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, msg, header), nil
+}
+
+// DecryptEnvelope расшифровывает сообщение в формате конверта.
+func DecryptEnvelope(private *rsa.PrivateKey, msg []byte) ([]byte, error) {
+	if !IsEnvelope(msg) {
+		return nil, errEnvelopeFormat
+	}
+	header, rest := msg[:envHeaderSize], msg[envHeaderSize:]
+	if version := header[len(envelopeMagic)]; version != EnvelopeVersion1 {
+		return nil, fmt.Errorf("%w: %v", errEnvelopeVersion, version)
+	}
+
+	if len(rest) < keyLenSize {
+		return nil, errEnvelopeFormat
+	}
+	keyLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[keyLenSize:]
+	if len(rest) < keyLen {
+		return nil, errEnvelopeFormat
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), nil, private, rest[:keyLen], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt key, %w", err)
+	}
+	rest = rest[keyLen:]
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return nil, errEnvelopeFormat
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt body, %w", err)
+	}
+	return plain, nil
+}
+
+// Decrypt расшифровывает сообщение в формате конверта или в устаревшем формате блоков RSA-OAEP.
+func Decrypt(private *rsa.PrivateKey, msg []byte) ([]byte, error) {
+	if IsEnvelope(msg) {
+		plain, err := DecryptEnvelope(private, msg)
+		if err == nil {
+			return plain, nil
+		}
+		// устаревший шифротекст может случайно начинаться с заголовка конверта.
+		legacy, legacyErr := DecryptOAEP(sha256.New(), rand.Reader, private, msg, nil)
+		if legacyErr != nil {
+			return nil, err
+		}
+		return legacy, nil
+	}
+	return DecryptOAEP(sha256.New(), rand.Reader, private, msg, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init cipher, %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init gcm, %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,113 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeyBits = 2048
+
+func testBatch(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":"Metric%v","type":"gauge","value":%v.123}`, i, i)
+	}
+	b.WriteString("]")
+	return b.Bytes()
+}
+
+func TestEnvelope(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, testKeyBits)
+	require.NoError(t, err)
+	msg := testBatch(100)
+
+	encrypted, err := EncryptEnvelope(rand.Reader, &key.PublicKey, msg)
+	require.NoError(t, err)
+	assert.True(t, IsEnvelope(encrypted))
+	assert.Len(t, encrypted, len(msg)+EnvelopeOverhead(&key.PublicKey))
+
+	decrypted, err := Decrypt(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+
+	encrypted[len(encrypted)-1] ^= 0xff
+	_, err = Decrypt(key, encrypted)
+	assert.Error(t, err)
+}
+
+func TestDecrypt__legacy(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, testKeyBits)
+	require.NoError(t, err)
+	msg := testBatch(100)
+
+	encrypted, err := EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, msg, nil)
+	require.NoError(t, err)
+
+	decrypted, err := Decrypt(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+}
+
+func TestDecryptEnvelope__unsupportedVersion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, testKeyBits)
+	require.NoError(t, err)
+
+	encrypted, err := EncryptEnvelope(rand.Reader, &key.PublicKey, []byte("msg"))
+	require.NoError(t, err)
+	encrypted[len(envelopeMagic)] = 2
+
+	_, err = DecryptEnvelope(key, encrypted)
+	assert.ErrorIs(t, err, errEnvelopeVersion)
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	require.NoError(b, err)
+	msg := testBatch(30)
+
+	b.Run("legacy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			encrypted, _ := EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, msg, nil)
+			b.ReportMetric(float64(len(encrypted)), "bytes/op")
+		}
+	})
+	b.Run("envelope", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			encrypted, _ := EncryptEnvelope(rand.Reader, &key.PublicKey, msg)
+			b.ReportMetric(float64(len(encrypted)), "bytes/op")
+		}
+	})
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	key, err := rsa.GenerateKey(rand.Reader, 4096)
+	require.NoError(b, err)
+	msg := testBatch(30)
+
+	legacy, err := EncryptOAEP(sha256.New(), rand.Reader, &key.PublicKey, msg, nil)
+	require.NoError(b, err)
+	envelope, err := EncryptEnvelope(rand.Reader, &key.PublicKey, msg)
+	require.NoError(b, err)
+
+	b.Run("legacy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = Decrypt(key, legacy)
+		}
+	})
+	b.Run("envelope", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = Decrypt(key, envelope)
+		}
+	})
+}
@@ -1,8 +1,36 @@
 package rsa
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	errPrivateKeyType = errors.New("invalid private key format")
-	errPublicKeyType  = errors.New("invalid public key format")
+	errPrivateKeyType  = errors.New("invalid private key format")
+	errPublicKeyType   = errors.New("invalid public key format")
+	errEnvelopeFormat  = errors.New("invalid envelope format")
+	errEnvelopeVersion = errors.New("unsupported envelope version")
+	errUnknownKeyID    = errors.New("unknown crypto key id")
+	errNoKeys          = errors.New("no private keys in keyring")
+	errKeyEncryption   = errors.New("unsupported key encryption")
+	errKeyFormat       = errors.New("unknown key format")
+
+	// ErrPassphraseRequired возвращается для зашифрованного ключа, если пароль не задан.
+	ErrPassphraseRequired = errors.New("private key is encrypted, passphrase required")
+	// ErrIncorrectPassphrase возвращается, если ключ не удалось расшифровать паролем.
+	ErrIncorrectPassphrase = errors.New("incorrect private key passphrase")
 )
+
+// UnsupportedKeyError возвращается для PEM блока неизвестного типа
+// или для ключа другого алгоритма, например EC в PKCS#8.
+type UnsupportedKeyError struct {
+	BlockType string
+	KeyType   string
+}
+
+func (e *UnsupportedKeyError) Error() string {
+	if e.KeyType != "" {
+		return fmt.Sprintf("unsupported key type %s in %q block, only RSA keys are supported", e.KeyType, e.BlockType)
+	}
+	return fmt.Sprintf("unsupported PEM block %q", e.BlockType)
+}
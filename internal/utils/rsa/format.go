@@ -0,0 +1,72 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// Форматы, в которых генератор сохраняет ключи.
+const (
+	// FormatPKCS1 - RSA PRIVATE KEY и RSA PUBLIC KEY.
+	FormatPKCS1 = "pkcs1"
+	// FormatPKCS8 - PRIVATE KEY (ENCRYPTED PRIVATE KEY с паролем) и PUBLIC KEY.
+	FormatPKCS8 = "pkcs8"
+)
+
+// Formats перечисляет поддерживаемые форматы.
+var Formats = []string{FormatPKCS1, FormatPKCS8}
+
+// MarshalPrivateKey кодирует ключ в PEM формата format.
+// С паролем PKCS#1 шифруется устаревшей схемой PEM, PKCS#8 - по PBES2.
+func MarshalPrivateKey(key *rsa.PrivateKey, format string, passphrase []byte) ([]byte, error) {
+	var block *pem.Block
+	switch format {
+	case FormatPKCS1:
+		der := x509.MarshalPKCS1PrivateKey(key)
+		if len(passphrase) == 0 {
+			block = &pem.Block{Type: PrivateKeyType, Bytes: der}
+			break
+		}
+		var err error
+		//nolint:staticcheck // формат нужен для совместимости с openssl genrsa -aes256
+		block, err = x509.EncryptPEMBlock(rand.Reader, PrivateKeyType, der, passphrase, x509.PEMCipherAES256)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+		}
+	case FormatPKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal private key: %w", err)
+		}
+		if len(passphrase) == 0 {
+			block = &pem.Block{Type: PKCS8PrivateKeyType, Bytes: der}
+			break
+		}
+		if der, err = encryptPKCS8(der, passphrase); err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: EncryptedPKCS8PrivateKeyType, Bytes: der}
+	default:
+		return nil, fmt.Errorf("%w: %q", errKeyFormat, format)
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalPublicKey кодирует публичный ключ в PEM формата format.
+func MarshalPublicKey(key *rsa.PublicKey, format string) ([]byte, error) {
+	switch format {
+	case FormatPKCS1:
+		return pem.EncodeToMemory(&pem.Block{Type: PublicKeyType, Bytes: x509.MarshalPKCS1PublicKey(key)}), nil
+	case FormatPKCS8:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal public key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: PKIXPublicKeyType, Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errKeyFormat, format)
+	}
+}
@@ -0,0 +1,148 @@
+package rsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalPrivateKey__formats(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, testKeyBits)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		format        string
+		passphrase    []byte
+		privateType   string
+		publicType    string
+		wantEncrypted bool
+	}{
+		{name: "pkcs1", format: FormatPKCS1, privateType: PrivateKeyType, publicType: PublicKeyType},
+		{name: "pkcs8", format: FormatPKCS8, privateType: PKCS8PrivateKeyType, publicType: PKIXPublicKeyType},
+		{
+			name:          "pkcs1 encrypted",
+			format:        FormatPKCS1,
+			passphrase:    []byte("secret"),
+			privateType:   PrivateKeyType,
+			publicType:    PublicKeyType,
+			wantEncrypted: true,
+		},
+		{
+			name:          "pkcs8 encrypted",
+			format:        FormatPKCS8,
+			passphrase:    []byte("secret"),
+			privateType:   EncryptedPKCS8PrivateKeyType,
+			publicType:    PKIXPublicKeyType,
+			wantEncrypted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privatePEM, err := MarshalPrivateKey(key, tt.format, tt.passphrase)
+			require.NoError(t, err)
+			publicPEM, err := MarshalPublicKey(&key.PublicKey, tt.format)
+			require.NoError(t, err)
+
+			block, _ := pem.Decode(privatePEM)
+			require.NotNil(t, block)
+			assert.Equal(t, tt.privateType, block.Type)
+			block, _ = pem.Decode(publicPEM)
+			require.NotNil(t, block)
+			assert.Equal(t, tt.publicType, block.Type)
+
+			dir := t.TempDir()
+			privatePath, publicPath := filepath.Join(dir, "private.pem"), filepath.Join(dir, "public.pem")
+			require.NoError(t, os.WriteFile(privatePath, privatePEM, 0600))
+			require.NoError(t, os.WriteFile(publicPath, publicPEM, 0600))
+
+			private, err := ReadPrivateKeyWithPassphrase(privatePath, tt.passphrase)
+			require.NoError(t, err)
+			assert.True(t, key.Equal(private))
+			public, err := ReadPublicKey(publicPath)
+			require.NoError(t, err)
+			assert.True(t, key.PublicKey.Equal(public))
+
+			if tt.wantEncrypted {
+				_, err = ReadPrivateKey(privatePath)
+				assert.ErrorIs(t, err, ErrPassphraseRequired)
+				_, err = ReadPrivateKeyWithPassphrase(privatePath, []byte("wrong"))
+				assert.ErrorIs(t, err, ErrIncorrectPassphrase)
+			}
+		})
+	}
+}
+
+func TestParseKey__unsupported(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	ecPubDER, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	require.NoError(t, err)
+	ecSEC1, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		block   *pem.Block
+		public  bool
+		wantErr UnsupportedKeyError
+	}{
+		{
+			name:    "ecdsa pkcs8",
+			block:   &pem.Block{Type: PKCS8PrivateKeyType, Bytes: ecDER},
+			wantErr: UnsupportedKeyError{BlockType: PKCS8PrivateKeyType, KeyType: "ECDSA"},
+		},
+		{
+			name:    "ed25519 pkcs8",
+			block:   &pem.Block{Type: PKCS8PrivateKeyType, Bytes: edDER},
+			wantErr: UnsupportedKeyError{BlockType: PKCS8PrivateKeyType, KeyType: "Ed25519"},
+		},
+		{
+			name:    "ec sec1",
+			block:   &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecSEC1},
+			wantErr: UnsupportedKeyError{BlockType: "EC PRIVATE KEY"},
+		},
+		{
+			name:    "ecdsa pkix",
+			block:   &pem.Block{Type: PKIXPublicKeyType, Bytes: ecPubDER},
+			public:  true,
+			wantErr: UnsupportedKeyError{BlockType: PKIXPublicKeyType, KeyType: "ECDSA"},
+		},
+		{
+			name:    "certificate",
+			block:   &pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")},
+			public:  true,
+			wantErr: UnsupportedKeyError{BlockType: "CERTIFICATE"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := pem.EncodeToMemory(tt.block)
+			if tt.public {
+				_, err = ParsePublicKey(data)
+			} else {
+				_, err = ParsePrivateKey(data, nil)
+			}
+			var unsupported *UnsupportedKeyError
+			require.ErrorAs(t, err, &unsupported)
+			assert.Equal(t, tt.wantErr, *unsupported)
+		})
+	}
+}
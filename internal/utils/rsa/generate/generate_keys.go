@@ -5,69 +5,149 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+
+	rsaKeys "github.com/NStegura/metrics/internal/utils/rsa"
+	"github.com/NStegura/metrics/internal/utils/signing"
 )
 
 const (
-	RSAPrivateKeyType = "RSA PRIVATE KEY"
-	RSAPublicKeyType  = "RSA PUBLIC KEY"
-
 	PublicFileName  = "public_key.pem"
 	PrivateFileName = "private_key.pem"
 
+	// SigningPrivateFileSuffix дописывается к id ключа подписи, публичный ключ
+	// сохраняется как <id>.pem, чтобы его можно было положить в каталог ключей сервера.
+	SigningPrivateFileSuffix = ".key"
+
+	ModeRSA  = "rsa"
+	ModeSign = "sign"
+
 	BitSizeKey = 4096
 	FilePerm   = 0600
 )
 
 func main() {
-	// создаём новый RSA-ключ длиной 4096 бит
 	var (
 		outPrivateKeyOutFilePath string
 		outPublicKeyOutFilePath  string
+		mode                     string
+		alg                      string
+		keyID                    string
+		format                   string
+		passphraseFile           string
 	)
 	flag.StringVar(&outPrivateKeyOutFilePath, "private", ".", "private file pathout")
 	flag.StringVar(&outPublicKeyOutFilePath, "public", ".", "public file path out")
+	flag.StringVar(&mode, "mode", ModeRSA, "rsa - keys to encrypt requests, sign - agent keys to sign requests")
+	flag.StringVar(&alg, "alg", signing.AlgEd25519, "signing key algorithm: ed25519 or ecdsa")
+	flag.StringVar(&keyID, "id", "", "signing key id, public key fingerprint if empty")
+	flag.StringVar(&format, "format", rsaKeys.FormatPKCS1, "rsa key format: pkcs1 or pkcs8 (PKIX public key)")
+	flag.StringVar(
+		&passphraseFile,
+		"passphrase-file",
+		"",
+		"file with passphrase to encrypt rsa private key, KEY_PASSPHRASE env is used if empty",
+	)
 	flag.Parse()
 
-	privateKey, err := rsa.GenerateKey(rand.Reader, BitSizeKey)
-	pubKey := &privateKey.PublicKey
+	switch mode {
+	case ModeRSA:
+		passphrase, err := readPassphrase(passphraseFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		generateRSAKeys(outPrivateKeyOutFilePath, outPublicKeyOutFilePath, format, passphrase)
+	case ModeSign:
+		generateSigningKeys(outPrivateKeyOutFilePath, outPublicKeyOutFilePath, alg, keyID)
+	default:
+		log.Fatalf("unknown mode %q", mode)
+	}
+}
+
+// generateSigningKeys создает пару ключей для подписи запросов агентом.
+func generateSigningKeys(outPrivateKeyOutFilePath, outPublicKeyOutFilePath, alg, keyID string) {
+	key, err := signing.GenerateKey(alg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer, err := signing.NewSigner(key, keyID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	privateKeyPEM, err := signing.MarshalPrivateKey(key)
 	if err != nil {
 		log.Fatal(err)
 	}
+	publicKeyPEM, err := signing.MarshalPublicKey(key.Public())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	privatePath := filepath.Join(outPrivateKeyOutFilePath, signer.KeyID()+SigningPrivateFileSuffix)
+	if err = os.WriteFile(privatePath, privateKeyPEM, FilePerm); err != nil {
+		log.Fatal(err)
+	}
+	publicPath := filepath.Join(outPublicKeyOutFilePath, signer.KeyID()+".pem")
+	if err = os.WriteFile(publicPath, publicKeyPEM, FilePerm); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Ключи подписи %s сохранены: %s, %s\n", signer.KeyID(), privatePath, publicPath)
+}
 
-	var privateKeyPEM bytes.Buffer
-	if err = pem.Encode(&privateKeyPEM, &pem.Block{
-		Type:  RSAPrivateKeyType,
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-	}); err != nil {
+// readPassphrase читает пароль из файла или из KEY_PASSPHRASE, пустой пароль - ключ не шифруется.
+func readPassphrase(path string) ([]byte, error) {
+	if path == "" {
+		return []byte(os.Getenv("KEY_PASSPHRASE")), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	return bytes.TrimRight(data, "\r\n"), nil
+}
+
+// generateRSAKeys создает пару RSA ключей для шифрования запросов.
+func generateRSAKeys(outPrivateKeyOutFilePath, outPublicKeyOutFilePath, format string, passphrase []byte) {
+	// создаём новый RSA-ключ длиной 4096 бит
+	privateKey, err := rsa.GenerateKey(rand.Reader, BitSizeKey)
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	var publicKeyPEM bytes.Buffer
-	if err = pem.Encode(&publicKeyPEM, &pem.Block{
-		Type:  RSAPublicKeyType,
-		Bytes: x509.MarshalPKCS1PublicKey(pubKey),
-	}); err != nil {
+	privateKeyPEM, err := rsaKeys.MarshalPrivateKey(privateKey, format, passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+	publicKeyPEM, err := rsaKeys.MarshalPublicKey(&privateKey.PublicKey, format)
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	if err = checkKeys(privateKey, pubKey); err != nil {
+	// проверяем ключи в том виде, в котором их прочитают сервер и агент.
+	readPrivateKey, err := rsaKeys.ParsePrivateKey(privateKeyPEM, passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+	readPublicKey, err := rsaKeys.ParsePublicKey(publicKeyPEM)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = checkKeys(readPrivateKey, readPublicKey); err != nil {
 		log.Fatal(err)
 	}
 
-	err = os.WriteFile(filepath.Join(outPrivateKeyOutFilePath, PrivateFileName), privateKeyPEM.Bytes(), FilePerm)
+	err = os.WriteFile(filepath.Join(outPrivateKeyOutFilePath, PrivateFileName), privateKeyPEM, FilePerm)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = os.WriteFile(filepath.Join(outPublicKeyOutFilePath, PublicFileName), publicKeyPEM.Bytes(), FilePerm)
+	err = os.WriteFile(filepath.Join(outPublicKeyOutFilePath, PublicFileName), publicKeyPEM, FilePerm)
 	if err != nil {
 		log.Fatal(err)
 	}
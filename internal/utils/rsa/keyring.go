@@ -0,0 +1,169 @@
+package rsa
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// KeyIDHeader передает идентификатор ключа, которым зашифровано тело запроса.
+	KeyIDHeader = "X-Crypto-Key-Id"
+
+	keyIDBytes            = 8
+	privateKeyExt         = ".pem"
+	keyringReloadInterval = time.Second
+)
+
+// KeyID вычисляет идентификатор ключа: начало SHA-256 от публичного ключа в PKCS#1.
+// Клиент и сервер получают его из своей половины пары без дополнительной настройки.
+func KeyID(public *rsa.PublicKey) string {
+	sum := sha256.Sum256(x509.MarshalPKCS1PublicKey(public))
+	return hex.EncodeToString(sum[:keyIDBytes])
+}
+
+// Keyring хранит приватные ключи сервера по идентификаторам, чтобы менять ключ
+// без одновременного обновления всех агентов: новый ключ добавляется в каталог,
+// агенты переходят на новый публичный ключ, старый ключ удаляется.
+// Каталог перечитывается при добавлении или удалении файлов, не чаще раза в секунду.
+type Keyring struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	static     map[string]*rsa.PrivateKey
+	keys       map[string]*rsa.PrivateKey
+	ids        []string
+	checkedAt  time.Time
+	dirModTime time.Time
+	dir        string
+	passphrase []byte
+}
+
+// NewKeyring загружает ключ из keyPath и все *.pem ключи из dir, пустые пути пропускаются.
+// Зашифрованные ключи расшифровываются общим паролем passphrase.
+func NewKeyring(keyPath, dir string, passphrase []byte) (*Keyring, error) {
+	k := &Keyring{
+		now:        time.Now,
+		static:     make(map[string]*rsa.PrivateKey),
+		dir:        dir,
+		passphrase: passphrase,
+	}
+	if keyPath != "" {
+		key, err := ReadPrivateKeyWithPassphrase(keyPath, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		k.static[KeyID(&key.PublicKey)] = key
+	}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	if len(k.keys) == 0 {
+		return nil, errNoKeys
+	}
+	k.checkedAt = k.now()
+	return k, nil
+}
+
+// IDs возвращает идентификаторы загруженных ключей.
+func (k *Keyring) IDs() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.reload()
+	return slices.Clone(k.ids)
+}
+
+// Decrypt расшифровывает сообщение ключом keyID.
+// Без keyID, как у старых клиентов, перебираются все ключи.
+func (k *Keyring) Decrypt(keyID string, msg []byte) ([]byte, error) {
+	k.mu.Lock()
+	k.reload()
+	key, ok := k.keys[keyID]
+	candidates := make([]*rsa.PrivateKey, 0, len(k.ids))
+	for _, id := range k.ids {
+		candidates = append(candidates, k.keys[id])
+	}
+	k.mu.Unlock()
+
+	if keyID != "" {
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errUnknownKeyID, keyID)
+		}
+		return Decrypt(key, msg)
+	}
+
+	var errs []error
+	for _, key := range candidates {
+		plain, err := Decrypt(key, msg)
+		if err == nil {
+			return plain, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("no key fits the message: %w", errors.Join(errs...))
+}
+
+func (k *Keyring) reload() {
+	if k.dir == "" {
+		return
+	}
+	now := k.now()
+	if now.Sub(k.checkedAt) < keyringReloadInterval {
+		return
+	}
+	k.checkedAt = now
+
+	info, err := os.Stat(k.dir)
+	if err != nil || info.ModTime().Equal(k.dirModTime) {
+		return
+	}
+	// при ошибке остаются прежние ключи, каталог перечитается при следующем изменении.
+	_ = k.load()
+}
+
+func (k *Keyring) load() error {
+	keys := make(map[string]*rsa.PrivateKey, len(k.static))
+	for id, key := range k.static {
+		keys[id] = key
+	}
+	var dirModTime time.Time
+	if k.dir != "" {
+		info, err := os.Stat(k.dir)
+		if err != nil {
+			return fmt.Errorf("failed to stat crypto keys dir: %w", err)
+		}
+		dirModTime = info.ModTime()
+		entries, err := os.ReadDir(k.dir)
+		if err != nil {
+			return fmt.Errorf("failed to read crypto keys dir: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != privateKeyExt {
+				continue
+			}
+			key, err := ReadPrivateKeyWithPassphrase(filepath.Join(k.dir, entry.Name()), k.passphrase)
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", entry.Name(), err)
+			}
+			keys[KeyID(&key.PublicKey)] = key
+		}
+	}
+
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	k.keys = keys
+	k.ids = ids
+	k.dirModTime = dirModTime
+	return nil
+}
@@ -0,0 +1,98 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestKey(t *testing.T, dir, name string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, testKeyBits)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{
+		Type:  PrivateKeyType,
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}), 0600))
+	return key
+}
+
+func TestKeyring_Decrypt(t *testing.T) {
+	dir, oldDir := t.TempDir(), t.TempDir()
+	oldKey := writeTestKey(t, oldDir, "old.pem")
+	newKey := writeTestKey(t, dir, "new.pem")
+	oldPath := filepath.Join(oldDir, "old.pem")
+
+	keyring, err := NewKeyring(oldPath, dir, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{KeyID(&oldKey.PublicKey), KeyID(&newKey.PublicKey)}, keyring.IDs())
+
+	msg := testBatch(10)
+	for _, key := range []*rsa.PrivateKey{oldKey, newKey} {
+		encrypted, err := EncryptEnvelope(rand.Reader, &key.PublicKey, msg)
+		require.NoError(t, err)
+
+		decrypted, err := keyring.Decrypt(KeyID(&key.PublicKey), encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, msg, decrypted)
+
+		decrypted, err = keyring.Decrypt("", encrypted)
+		require.NoError(t, err, "legacy clients without key id")
+		assert.Equal(t, msg, decrypted)
+	}
+
+	encrypted, err := EncryptEnvelope(rand.Reader, &newKey.PublicKey, msg)
+	require.NoError(t, err)
+	_, err = keyring.Decrypt("unknown", encrypted)
+	require.ErrorIs(t, err, errUnknownKeyID)
+	_, err = keyring.Decrypt(KeyID(&oldKey.PublicKey), encrypted)
+	require.Error(t, err)
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := writeTestKey(t, dir, "old.pem")
+	keyring, err := NewKeyring("", dir, nil)
+	require.NoError(t, err)
+	now := time.Now()
+	keyring.now = func() time.Time { return now }
+
+	newKey := writeTestKey(t, dir, "new.pem")
+	require.NoError(t, os.Chtimes(dir, now.Add(time.Minute), now.Add(time.Minute)))
+	now = now.Add(keyringReloadInterval)
+	assert.ElementsMatch(t, []string{KeyID(&oldKey.PublicKey), KeyID(&newKey.PublicKey)}, keyring.IDs())
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "old.pem")))
+	require.NoError(t, os.Chtimes(dir, now.Add(2*time.Minute), now.Add(2*time.Minute)))
+	now = now.Add(keyringReloadInterval)
+	assert.Equal(t, []string{KeyID(&newKey.PublicKey)}, keyring.IDs())
+}
+
+func TestNewKeyring__empty(t *testing.T) {
+	_, err := NewKeyring("", t.TempDir(), nil)
+	require.ErrorIs(t, err, errNoKeys)
+}
+
+func TestNewKeyring__encrypted(t *testing.T) {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, testKeyBits)
+	require.NoError(t, err)
+	data, err := MarshalPrivateKey(key, FormatPKCS8, []byte("secret"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key.pem"), data, 0600))
+
+	_, err = NewKeyring("", dir, nil)
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+
+	keyring, err := NewKeyring("", dir, []byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{KeyID(&key.PublicKey)}, keyring.IDs())
+}
@@ -0,0 +1,175 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // PBKDF2 с HMAC-SHA1 - значение по умолчанию в PKCS#5
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Зашифрованный PKCS#8 (PBES2 из PKCS#5): ключ шифрования выводится PBKDF2
+// из пароля, сам ключ шифруется AES-CBC. Поддерживаются параметры openssl по умолчанию.
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+const (
+	pbkdf2Iterations = 100000
+	pbkdf2SaltSize   = 16
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+func aesKeySizeByOID(oid asn1.ObjectIdentifier) (int, bool) {
+	switch {
+	case oid.Equal(oidAES128CBC):
+		return 16, true
+	case oid.Equal(oidAES192CBC):
+		return 24, true
+	case oid.Equal(oidAES256CBC):
+		return 32, true
+	default:
+		return 0, false
+	}
+}
+
+func prfByOID(oid asn1.ObjectIdentifier) (func() hash.Hash, bool) {
+	switch {
+	case len(oid) == 0 || oid.Equal(oidHMACWithSHA1):
+		return sha1.New, true
+	case oid.Equal(oidHMACWithSHA256):
+		return sha256.New, true
+	default:
+		return nil, false
+	}
+}
+
+// decryptPKCS8 расшифровывает блок ENCRYPTED PRIVATE KEY и возвращает DER PKCS#8.
+func decryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("%w: encryption %v", errKeyEncryption, info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 params: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("%w: kdf %v", errKeyEncryption, params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 params: %w", err)
+	}
+	prf, ok := prfByOID(kdf.PRF.Algorithm)
+	if !ok {
+		return nil, fmt.Errorf("%w: prf %v", errKeyEncryption, kdf.PRF.Algorithm)
+	}
+	keySize, ok := aesKeySizeByOID(params.EncryptionScheme.Algorithm)
+	if !ok {
+		return nil, fmt.Errorf("%w: cipher %v", errKeyEncryption, params.EncryptionScheme.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("failed to parse cipher iv: %w", err)
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData)%aes.BlockSize != 0 || len(info.EncryptedData) == 0 {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", errKeyEncryption)
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, kdf.Salt, kdf.Iterations, keySize, prf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to init cipher: %w", err)
+	}
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.EncryptedData)
+
+	// неверная длина выравнивания почти всегда означает неверный пароль.
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize ||
+		!bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrIncorrectPassphrase
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+// encryptPKCS8 шифрует DER PKCS#8 по схеме PBES2 с PBKDF2-HMAC-SHA256 и AES-256-CBC.
+func encryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, pbkdf2SaltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("failed to generate iv: %w", err)
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, pbkdf2Iterations, aesKeySize, sha256.New))
+	if err != nil {
+		return nil, fmt.Errorf("failed to init cipher: %w", err)
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	plain := append(bytes.Clone(der), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: pbkdf2Iterations,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal PBKDF2 params: %w", err)
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cipher iv: %w", err)
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal PBES2 params: %w", err)
+	}
+	info, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal encrypted private key: %w", err)
+	}
+	return info, nil
+}
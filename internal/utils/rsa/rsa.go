@@ -1,9 +1,13 @@
 package rsa
 
 import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
@@ -13,25 +17,79 @@ import (
 const (
 	PrivateKeyType = "RSA PRIVATE KEY"
 	PublicKeyType  = "RSA PUBLIC KEY"
-	tail           = 2
+	// PKCS8PrivateKeyType и PKIXPublicKeyType - форматы openssl genpkey и openssl pkey -pubout.
+	PKCS8PrivateKeyType          = "PRIVATE KEY"
+	EncryptedPKCS8PrivateKeyType = "ENCRYPTED PRIVATE KEY"
+	PKIXPublicKeyType            = "PUBLIC KEY"
+	tail                         = 2
 )
 
 func ReadPrivateKey(filePath string) (*rsa.PrivateKey, error) {
+	return ReadPrivateKeyWithPassphrase(filePath, nil)
+}
+
+// ReadPrivateKeyWithPassphrase читает ключ в PKCS#1 или PKCS#8,
+// зашифрованный ключ расшифровывается паролем passphrase.
+func ReadPrivateKeyWithPassphrase(filePath string, passphrase []byte) (*rsa.PrivateKey, error) {
 	keyData, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file, %w", err)
 	}
+	return ParsePrivateKey(keyData, passphrase)
+}
 
+// ParsePrivateKey разбирает PEM приватного ключа, см. ReadPrivateKeyWithPassphrase.
+func ParsePrivateKey(keyData, passphrase []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(keyData)
-	if block == nil || block.Type != PrivateKeyType {
+	if block == nil {
 		return nil, errPrivateKeyType
 	}
 
-	pk, err := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse private key, %w", err)
+	switch block.Type {
+	case PrivateKeyType:
+		der := block.Bytes
+		//nolint:staticcheck // устаревшее шифрование PEM поддерживается для ключей openssl genrsa -aes256
+		if x509.IsEncryptedPEMBlock(block) {
+			if len(passphrase) == 0 {
+				return nil, ErrPassphraseRequired
+			}
+			var err error
+			//nolint:staticcheck // см. выше
+			if der, err = x509.DecryptPEMBlock(block, passphrase); err != nil {
+				if errors.Is(err, x509.IncorrectPasswordError) {
+					return nil, ErrIncorrectPassphrase
+				}
+				return nil, fmt.Errorf("failed to decrypt private key, %w", err)
+			}
+		}
+		pk, err := x509.ParsePKCS1PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key, %w", err)
+		}
+		return pk, nil
+	case PKCS8PrivateKeyType:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key, %w", err)
+		}
+		return rsaPrivateKey(key, block.Type)
+	case EncryptedPKCS8PrivateKeyType:
+		if len(passphrase) == 0 {
+			return nil, ErrPassphraseRequired
+		}
+		der, err := decryptPKCS8(block.Bytes, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		key, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			// выравнивание случайно совпало, но ключ не разобрался - пароль неверный.
+			return nil, ErrIncorrectPassphrase
+		}
+		return rsaPrivateKey(key, block.Type)
+	default:
+		return nil, &UnsupportedKeyError{BlockType: block.Type}
 	}
-	return pk, nil
 }
 
 func ReadPublicKey(filePath string) (*rsa.PublicKey, error) {
@@ -39,23 +97,67 @@ func ReadPublicKey(filePath string) (*rsa.PublicKey, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file, %w", err)
 	}
+	return ParsePublicKey(keyData)
+}
 
+// ParsePublicKey разбирает PEM публичного ключа в PKCS#1 или PKIX.
+func ParsePublicKey(keyData []byte) (*rsa.PublicKey, error) {
 	block, _ := pem.Decode(keyData)
-	if block == nil || block.Type != PublicKeyType {
+	if block == nil {
 		return nil, errPublicKeyType
 	}
 
-	pubInterface, err := x509.ParsePKCS1PublicKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse publick key")
+	switch block.Type {
+	case PublicKeyType:
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key, %w", err)
+		}
+		return pub, nil
+	case PKIXPublicKeyType:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key, %w", err)
+		}
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, &UnsupportedKeyError{BlockType: block.Type, KeyType: keyTypeName(key)}
+		}
+		return pub, nil
+	default:
+		return nil, &UnsupportedKeyError{BlockType: block.Type}
 	}
+}
+
+func rsaPrivateKey(key any, blockType string) (*rsa.PrivateKey, error) {
+	pk, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, &UnsupportedKeyError{BlockType: blockType, KeyType: keyTypeName(key)}
+	}
+	return pk, nil
+}
+
+func keyTypeName(key any) string {
+	switch key.(type) {
+	case *ecdsa.PrivateKey, *ecdsa.PublicKey:
+		return "ECDSA"
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return "Ed25519"
+	case *ecdh.PrivateKey, *ecdh.PublicKey:
+		return "ECDH"
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
 
-	return pubInterface, nil
+// PlainBlockSize возвращает размер блока открытого текста для EncryptOAEP.
+func PlainBlockSize(hash hash.Hash, public *rsa.PublicKey) int {
+	return public.Size() - 2*hash.Size() - tail
 }
 
 func EncryptOAEP(hash hash.Hash, random io.Reader, public *rsa.PublicKey, msg []byte, label []byte) ([]byte, error) {
 	msgLen := len(msg)
-	step := public.Size() - 2*hash.Size() - tail
+	step := PlainBlockSize(hash, public)
 	var encryptedBytes []byte
 
 	for start := 0; start < msgLen; start += step {
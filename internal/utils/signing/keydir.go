@@ -0,0 +1,185 @@
+package signing
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RevokedFileName содержит отозванные идентификаторы ключей, по одному в строке.
+	RevokedFileName = "revoked.txt"
+
+	publicKeyExt   = ".pem"
+	reloadInterval = time.Second
+)
+
+// KeyDir хранит доверенные публичные ключи из каталога, файл <key id>.pem на ключ.
+// Каталог и список отзыва перечитываются при изменении, не чаще раза в секунду,
+// при ошибке чтения остаются прежние ключи.
+type KeyDir struct {
+	mu             sync.Mutex
+	now            func() time.Time
+	keys           map[string]crypto.PublicKey
+	revoked        map[string]struct{}
+	checkedAt      time.Time
+	dirModTime     time.Time
+	revokedModTime time.Time
+	dir            string
+}
+
+// LoadKeyDir загружает ключи из каталога.
+func LoadKeyDir(dir string) (*KeyDir, error) {
+	d := &KeyDir{dir: dir, now: time.Now}
+	dirModTime, revokedModTime, err := d.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err = d.load(dirModTime, revokedModTime); err != nil {
+		return nil, err
+	}
+	d.checkedAt = d.now()
+	return d, nil
+}
+
+// Verify проверяет подпись ключом keyID.
+func (d *KeyDir) Verify(keyID string, data []byte, signature string) error {
+	pub, err := d.lookup(keyID)
+	if err != nil {
+		return err
+	}
+	return verify(pub, data, signature)
+}
+
+func (d *KeyDir) lookup(keyID string) (crypto.PublicKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.reload()
+	if _, ok := d.revoked[keyID]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrRevokedKey, keyID)
+	}
+	pub, ok := d.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return pub, nil
+}
+
+func (d *KeyDir) reload() {
+	now := d.now()
+	if now.Sub(d.checkedAt) < reloadInterval {
+		return
+	}
+	d.checkedAt = now
+
+	dirModTime, revokedModTime, err := d.modTimes()
+	if err != nil {
+		return
+	}
+	if dirModTime.Equal(d.dirModTime) && revokedModTime.Equal(d.revokedModTime) {
+		return
+	}
+	_ = d.load(dirModTime, revokedModTime)
+}
+
+// modTimes возвращает время изменения каталога и списка отзыва.
+func (d *KeyDir) modTimes() (time.Time, time.Time, error) {
+	info, err := os.Stat(d.dir)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat signing keys dir: %w", err)
+	}
+	var revokedModTime time.Time
+	revokedInfo, err := os.Stat(filepath.Join(d.dir, RevokedFileName))
+	switch {
+	case err == nil:
+		revokedModTime = revokedInfo.ModTime()
+	case !errors.Is(err, fs.ErrNotExist):
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to stat revoked keys: %w", err)
+	}
+	return info.ModTime(), revokedModTime, nil
+}
+
+func (d *KeyDir) load(dirModTime, revokedModTime time.Time) error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read signing keys dir: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != publicKeyExt {
+			continue
+		}
+		pub, err := readPublicKey(filepath.Join(d.dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", name, err)
+		}
+		keys[strings.TrimSuffix(name, publicKeyExt)] = pub
+	}
+	revoked, err := readRevoked(filepath.Join(d.dir, RevokedFileName))
+	if err != nil {
+		return err
+	}
+
+	d.keys = keys
+	d.revoked = revoked
+	d.dirModTime = dirModTime
+	d.revokedModTime = revokedModTime
+	return nil
+}
+
+// readPublicKey читает публичный ключ Ed25519 или ECDSA в формате PKIX.
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PublicKeyType {
+		return nil, fmt.Errorf("%w: expected %s PEM block", ErrKeyType, PublicKeyType)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch pub.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrKeyType, pub)
+	}
+}
+
+// readRevoked читает список отзыва, строки с # считаются комментариями.
+func readRevoked(path string) (map[string]struct{}, error) {
+	revoked := make(map[string]struct{})
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return revoked, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revoked keys: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		revoked[line] = struct{}{}
+	}
+	return revoked, nil
+}
@@ -0,0 +1,55 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+const (
+	AlgEd25519 = "ed25519"
+	AlgECDSA   = "ecdsa"
+)
+
+// GenerateKey создает ключ подписи: Ed25519 или ECDSA на кривой P-256.
+func GenerateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case AlgEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		return key, nil
+	case AlgECDSA:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ecdsa key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrKeyType, alg)
+	}
+}
+
+// MarshalPrivateKey кодирует приватный ключ в PEM с PKCS#8.
+func MarshalPrivateKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PrivateKeyType, Bytes: der}), nil
+}
+
+// MarshalPublicKey кодирует публичный ключ в PEM с PKIX.
+func MarshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PublicKeyType, Bytes: der}), nil
+}
@@ -0,0 +1,152 @@
+// Package signing подписывает запросы собственным ключом агента (Ed25519 или ECDSA)
+// и проверяет подписи по каталогу доверенных публичных ключей.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// KeyIDHeader и SignatureHeader передают подпись в HTTP запросе.
+	KeyIDHeader     = "X-Signature-Key-Id"
+	SignatureHeader = "X-Signature"
+	// KeyIDKey и SignatureKey передают подпись в метаданных gRPC.
+	KeyIDKey     = "x-signature-key-id"
+	SignatureKey = "x-signature"
+
+	PrivateKeyType = "PRIVATE KEY"
+	PublicKeyType  = "PUBLIC KEY"
+
+	keyIDBytes = 8
+)
+
+var (
+	ErrKeyType      = errors.New("unsupported signing key type")
+	ErrUnknownKey   = errors.New("unknown signing key")
+	ErrRevokedKey   = errors.New("signing key is revoked")
+	ErrBadSignature = errors.New("invalid request signature")
+)
+
+// Signer подписывает запросы приватным ключом агента.
+type Signer struct {
+	key   crypto.Signer
+	keyID string
+}
+
+// LoadSigner читает приватный ключ PKCS#8 из PEM файла.
+// Если keyID пустой, используется отпечаток публичного ключа.
+func LoadSigner(path, keyID string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PrivateKeyType {
+		return nil, fmt.Errorf("%w: expected %s PEM block", ErrKeyType, PrivateKeyType)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	return NewSigner(key, keyID)
+}
+
+// NewSigner создает Signer из ключа Ed25519 или ECDSA.
+func NewSigner(key any, keyID string) (*Signer, error) {
+	var signer crypto.Signer
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		signer = k
+	case *ecdsa.PrivateKey:
+		signer = k
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrKeyType, key)
+	}
+	if keyID == "" {
+		var err error
+		if keyID, err = KeyID(signer.Public()); err != nil {
+			return nil, err
+		}
+	}
+	return &Signer{key: signer, keyID: keyID}, nil
+}
+
+// KeyID возвращает идентификатор ключа, под которым его ищет сервер.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign подписывает данные и возвращает подпись в base64.
+// ECDSA подписывает SHA-256 от данных, Ed25519 - сами данные.
+func (s *Signer) Sign(data []byte) (string, error) {
+	var (
+		sig []byte
+		err error
+	)
+	switch k := s.key.(type) {
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, data)
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256(data)
+		sig, err = ecdsa.SignASN1(rand.Reader, k, digest[:])
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign data: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// KeyID вычисляет отпечаток публичного ключа: начало SHA-256 от PKIX представления.
+func KeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:keyIDBytes]), nil
+}
+
+// verify проверяет подпись в base64 публичным ключом Ed25519 или ECDSA.
+func verify(pub crypto.PublicKey, data []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrBadSignature
+	}
+	var ok bool
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, data, sig)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(k, digest[:], sig)
+	}
+	if !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+type keyIDCtxKey struct{}
+
+// WithKeyID сохраняет в контексте идентификатор ключа, которым подписан запрос.
+func WithKeyID(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, keyIDCtxKey{}, keyID)
+}
+
+// KeyIDFromContext возвращает идентификатор ключа, если подпись запроса проверена.
+func KeyIDFromContext(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(keyIDCtxKey{}).(string)
+	return keyID, ok
+}
@@ -0,0 +1,90 @@
+package signing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair сохраняет приватный ключ во временный файл, а публичный в каталог ключей.
+func writeKeyPair(t *testing.T, dir, alg, keyID string) *Signer {
+	t.Helper()
+	key, err := GenerateKey(alg)
+	require.NoError(t, err)
+	private, err := MarshalPrivateKey(key)
+	require.NoError(t, err)
+	public, err := MarshalPublicKey(key.Public())
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(t.TempDir(), "signing_key.pem")
+	require.NoError(t, os.WriteFile(privatePath, private, 0600))
+	signer, err := LoadSigner(privatePath, keyID)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, signer.KeyID()+publicKeyExt), public, 0600))
+	return signer
+}
+
+func TestKeyDir_Verify(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("body")
+	for _, alg := range []string{AlgEd25519, AlgECDSA} {
+		t.Run(alg, func(t *testing.T) {
+			signer := writeKeyPair(t, dir, alg, "")
+			keys, err := LoadKeyDir(dir)
+			require.NoError(t, err)
+
+			sig, err := signer.Sign(data)
+			require.NoError(t, err)
+			require.NoError(t, keys.Verify(signer.KeyID(), data, sig))
+			require.ErrorIs(t, keys.Verify(signer.KeyID(), []byte("forged"), sig), ErrBadSignature)
+			require.ErrorIs(t, keys.Verify("unknown", data, sig), ErrUnknownKey)
+		})
+	}
+}
+
+func TestKeyDir_Revoke(t *testing.T) {
+	dir := t.TempDir()
+	signer := writeKeyPair(t, dir, AlgEd25519, "agent-1")
+	keys, err := LoadKeyDir(dir)
+	require.NoError(t, err)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+
+	data := []byte("body")
+	sig, err := signer.Sign(data)
+	require.NoError(t, err)
+	require.NoError(t, keys.Verify("agent-1", data, sig))
+
+	revoked := filepath.Join(dir, RevokedFileName)
+	require.NoError(t, os.WriteFile(revoked, []byte("# compromised\nagent-1\n"), 0600))
+	require.NoError(t, os.Chtimes(revoked, now.Add(time.Minute), now.Add(time.Minute)))
+	require.NoError(t, keys.Verify("agent-1", data, sig), "list must not be reread more often than reloadInterval")
+
+	now = now.Add(reloadInterval)
+	require.ErrorIs(t, keys.Verify("agent-1", data, sig), ErrRevokedKey)
+}
+
+func TestLoadSigner__unsupportedKey(t *testing.T) {
+	_, err := GenerateKey("rsa")
+	require.ErrorIs(t, err, ErrKeyType)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a pem"), 0600))
+	_, err = LoadSigner(path, "")
+	require.ErrorIs(t, err, ErrKeyType)
+}
+
+func TestKeyID(t *testing.T) {
+	key, err := GenerateKey(AlgEd25519)
+	require.NoError(t, err)
+	signer, err := NewSigner(key, "")
+	require.NoError(t, err)
+	id, err := KeyID(key.Public())
+	require.NoError(t, err)
+	assert.Equal(t, id, signer.KeyID())
+	assert.Len(t, id, 2*keyIDBytes)
+}
@@ -0,0 +1,108 @@
+// Package agent позволяет встроить сбор и отправку метрик процесса в Go сервис без отдельного агента.
+//
+//	ag, err := agent.New("localhost:8080", agent.WithReportInterval(10*time.Second))
+//	if err != nil {
+//		return err
+//	}
+//	defer ag.Close()
+//	go ag.Run(ctx)
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/app/agent"
+	"github.com/NStegura/metrics/internal/clients/metric"
+	"github.com/NStegura/metrics/pkg/transport"
+)
+
+const (
+	defaultPollInterval   = 2 * time.Second
+	defaultReportInterval = 10 * time.Second
+	defaultRateLimit      = 1
+)
+
+// Agent встраиваемый агент сбора метрик.
+type Agent struct {
+	cfg      *config.AgentConfig
+	connOpts []transport.Option
+	logger   *logrus.Logger
+
+	agent  *agent.Agent
+	sender transport.Sender
+}
+
+// metricSender отправляет метрики внутреннего агента через transport.Sender.
+type metricSender struct {
+	transport.Sender
+}
+
+func (s metricSender) UpdateMetrics(ctx context.Context, metrics []metric.Metrics) error {
+	batch := make([]transport.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		batch = append(batch, transport.Metric(m))
+	}
+	return s.Sender.UpdateMetrics(ctx, batch) //nolint:wrapcheck // proxy
+}
+
+// New создает агент, отправляющий метрики на сервер addr.
+// По умолчанию отправляются только runtime метрики процесса по HTTP, протокол задается WithConnection.
+func New(addr string, options ...Option) (*Agent, error) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	a := &Agent{
+		cfg: &config.AgentConfig{
+			HTTPAddr:           addr,
+			GRPCAddr:           addr,
+			RateLimit:          defaultRateLimit,
+			PollInterval:       config.Duration(defaultPollInterval),
+			ReportInterval:     config.Duration(defaultReportInterval),
+			DisableHostMetrics: true,
+		},
+		logger: logger,
+	}
+	for _, opt := range options {
+		if err := opt(a); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	a.sender, err = transport.New(addr, a.logger, a.connOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	a.agent = agent.New(a.cfg, metricSender{a.sender}, a.logger)
+	return a, nil
+}
+
+// Close закрывает подключение к серверу, вызывается после остановки Run.
+func (a *Agent) Close() error {
+	if err := a.sender.Close(); err != nil {
+		return fmt.Errorf("failed to close agent: %w", err)
+	}
+	return nil
+}
+
+// Run собирает и отправляет метрики, пока не отменен ctx.
+func (a *Agent) Run(ctx context.Context) error {
+	if err := a.agent.Run(ctx); err != nil {
+		return fmt.Errorf("agent stopped with error: %w", err)
+	}
+	return nil
+}
+
+// Flush синхронно собирает и отправляет один набор метрик.
+func (a *Agent) Flush(ctx context.Context) error {
+	if err := a.agent.RunOnce(ctx); err != nil {
+		return fmt.Errorf("failed to flush metrics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/pkg/transport"
+)
+
+func TestAgent_Run(t *testing.T) {
+	var updates atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/updates/" {
+			updates.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	ag, err := New(ts.URL,
+		WithPollInterval(10*time.Millisecond),
+		WithReportInterval(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+	assert.Len(t, ag.agent.Collectors(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, ag.Run(ctx))
+	assert.Positive(t, updates.Load())
+}
+
+func TestAgent_Run__cancelFullQueue(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	// отправка не начнется до отмены, очередь сбора из одного набора заполняется сразу.
+	ag, err := New(ts.URL,
+		WithPollInterval(time.Millisecond),
+		WithReportInterval(time.Hour),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ag.Run(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after ctx cancel")
+	}
+}
+
+func TestAgent_Flush(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	ag, err := New(ts.URL, WithHostMetrics(true))
+	require.NoError(t, err)
+	assert.Len(t, ag.agent.Collectors(), 2)
+	assert.Error(t, ag.Flush(context.Background()))
+}
+
+func TestAgent_Close(t *testing.T) {
+	ag, err := New("localhost:8080", WithConnection(transport.WithTransport(transport.GRPC)))
+	require.NoError(t, err)
+	require.NoError(t, ag.Close())
+	assert.Error(t, ag.Flush(context.Background()), "closed agent does not send")
+}
+
+func TestNew__invalidOptions(t *testing.T) {
+	_, err := New("localhost:8080", WithConnection(transport.WithTransport("udp")))
+	assert.Error(t, err)
+
+	_, err = New("localhost:8080", WithPollInterval(0))
+	assert.Error(t, err)
+}
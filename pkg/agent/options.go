@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/pkg/transport"
+)
+
+// Option настраивает встраиваемый агент.
+type Option func(*Agent) error
+
+// WithConnection Опция для настройки подключения к серверу: протокола, TLS, подписи, сжатия и повторов.
+func WithConnection(options ...transport.Option) Option {
+	return func(a *Agent) error {
+		a.connOpts = append(a.connOpts, options...)
+		return nil
+	}
+}
+
+// WithLogger Опция для настройки логгера.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(a *Agent) error {
+		a.logger = logger
+		return nil
+	}
+}
+
+// WithPollInterval Опция для настройки частоты сбора метрик.
+func WithPollInterval(interval time.Duration) Option {
+	return func(a *Agent) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid poll interval: %v", interval)
+		}
+		a.cfg.PollInterval = config.Duration(interval)
+		return nil
+	}
+}
+
+// WithReportInterval Опция для настройки частоты отправки метрик.
+func WithReportInterval(interval time.Duration) Option {
+	return func(a *Agent) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid report interval: %v", interval)
+		}
+		a.cfg.ReportInterval = config.Duration(interval)
+		return nil
+	}
+}
+
+// WithRateLimit Опция для настройки количества одновременных отправок.
+func WithRateLimit(rateLimit int) Option {
+	return func(a *Agent) error {
+		if rateLimit < 1 {
+			return fmt.Errorf("invalid rate limit: %v", rateLimit)
+		}
+		a.cfg.RateLimit = rateLimit
+		return nil
+	}
+}
+
+// WithHostMetrics Опция для включения метрик хоста (память и загрузка CPU).
+func WithHostMetrics(enabled bool) Option {
+	return func(a *Agent) error {
+		a.cfg.DisableHostMetrics = !enabled
+		return nil
+	}
+}
+
+// WithMaxBytesPerSecond Опция для ограничения объема отправляемых данных.
+func WithMaxBytesPerSecond(maxBytes int) Option {
+	return func(a *Agent) error {
+		a.cfg.MaxBytesPerSecond = maxBytes
+		return nil
+	}
+}
@@ -1,17 +1,17 @@
 // Code generated by protoc-gen-go-grpc. DO NOT EDIT.
 // versions:
 // - protoc-gen-go-grpc v1.5.1
-// - protoc             v3.6.1
+// - protoc             (unknown)
 // source: metricsapi.proto
 
 package api
 
 import (
 	context "context"
-	empty "github.com/golang/protobuf/ptypes/empty"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -22,6 +22,10 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	MetricsApi_UpdateAllMetrics_FullMethodName = "/metricsapi.MetricsApi/UpdateAllMetrics"
 	MetricsApi_GetPing_FullMethodName          = "/metricsapi.MetricsApi/GetPing"
+	MetricsApi_DeleteMetric_FullMethodName     = "/metricsapi.MetricsApi/DeleteMetric"
+	MetricsApi_DeleteMetrics_FullMethodName    = "/metricsapi.MetricsApi/DeleteMetrics"
+	MetricsApi_RenameMetric_FullMethodName     = "/metricsapi.MetricsApi/RenameMetric"
+	MetricsApi_ResetCounter_FullMethodName     = "/metricsapi.MetricsApi/ResetCounter"
 )
 
 // MetricsApiClient is the client API for MetricsApi service.
@@ -29,7 +33,12 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type MetricsApiClient interface {
 	UpdateAllMetrics(ctx context.Context, in *MetricsList, opts ...grpc.CallOption) (*UpdateResponse, error)
-	GetPing(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Pong, error)
+	GetPing(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Pong, error)
+	// Админские методы требуют токен в метаданных authorization: Bearer <token>.
+	DeleteMetric(ctx context.Context, in *MetricRef, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteMetrics(ctx context.Context, in *MetricFilter, opts ...grpc.CallOption) (*DeleteResponse, error)
+	RenameMetric(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResetCounter(ctx context.Context, in *MetricRef, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type metricsApiClient struct {
@@ -50,7 +59,7 @@ func (c *metricsApiClient) UpdateAllMetrics(ctx context.Context, in *MetricsList
 	return out, nil
 }
 
-func (c *metricsApiClient) GetPing(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Pong, error) {
+func (c *metricsApiClient) GetPing(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Pong, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(Pong)
 	err := c.cc.Invoke(ctx, MetricsApi_GetPing_FullMethodName, in, out, cOpts...)
@@ -60,12 +69,57 @@ func (c *metricsApiClient) GetPing(ctx context.Context, in *empty.Empty, opts ..
 	return out, nil
 }
 
+func (c *metricsApiClient) DeleteMetric(ctx context.Context, in *MetricRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, MetricsApi_DeleteMetric_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsApiClient) DeleteMetrics(ctx context.Context, in *MetricFilter, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, MetricsApi_DeleteMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsApiClient) RenameMetric(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, MetricsApi_RenameMetric_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *metricsApiClient) ResetCounter(ctx context.Context, in *MetricRef, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, MetricsApi_ResetCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetricsApiServer is the server API for MetricsApi service.
 // All implementations must embed UnimplementedMetricsApiServer
 // for forward compatibility.
 type MetricsApiServer interface {
 	UpdateAllMetrics(context.Context, *MetricsList) (*UpdateResponse, error)
-	GetPing(context.Context, *empty.Empty) (*Pong, error)
+	GetPing(context.Context, *emptypb.Empty) (*Pong, error)
+	// Админские методы требуют токен в метаданных authorization: Bearer <token>.
+	DeleteMetric(context.Context, *MetricRef) (*emptypb.Empty, error)
+	DeleteMetrics(context.Context, *MetricFilter) (*DeleteResponse, error)
+	RenameMetric(context.Context, *RenameRequest) (*emptypb.Empty, error)
+	ResetCounter(context.Context, *MetricRef) (*emptypb.Empty, error)
 	mustEmbedUnimplementedMetricsApiServer()
 }
 
@@ -79,9 +133,21 @@ type UnimplementedMetricsApiServer struct{}
 func (UnimplementedMetricsApiServer) UpdateAllMetrics(context.Context, *MetricsList) (*UpdateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateAllMetrics not implemented")
 }
-func (UnimplementedMetricsApiServer) GetPing(context.Context, *empty.Empty) (*Pong, error) {
+func (UnimplementedMetricsApiServer) GetPing(context.Context, *emptypb.Empty) (*Pong, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPing not implemented")
 }
+func (UnimplementedMetricsApiServer) DeleteMetric(context.Context, *MetricRef) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMetric not implemented")
+}
+func (UnimplementedMetricsApiServer) DeleteMetrics(context.Context, *MetricFilter) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMetrics not implemented")
+}
+func (UnimplementedMetricsApiServer) RenameMetric(context.Context, *RenameRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameMetric not implemented")
+}
+func (UnimplementedMetricsApiServer) ResetCounter(context.Context, *MetricRef) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetCounter not implemented")
+}
 func (UnimplementedMetricsApiServer) mustEmbedUnimplementedMetricsApiServer() {}
 func (UnimplementedMetricsApiServer) testEmbeddedByValue()                    {}
 
@@ -122,7 +188,7 @@ func _MetricsApi_UpdateAllMetrics_Handler(srv interface{}, ctx context.Context,
 }
 
 func _MetricsApi_GetPing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -134,7 +200,79 @@ func _MetricsApi_GetPing_Handler(srv interface{}, ctx context.Context, dec func(
 		FullMethod: MetricsApi_GetPing_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(MetricsApiServer).GetPing(ctx, req.(*empty.Empty))
+		return srv.(MetricsApiServer).GetPing(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsApi_DeleteMetric_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsApiServer).DeleteMetric(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsApi_DeleteMetric_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsApiServer).DeleteMetric(ctx, req.(*MetricRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsApi_DeleteMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsApiServer).DeleteMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsApi_DeleteMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsApiServer).DeleteMetrics(ctx, req.(*MetricFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsApi_RenameMetric_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsApiServer).RenameMetric(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsApi_RenameMetric_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsApiServer).RenameMetric(ctx, req.(*RenameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MetricsApi_ResetCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsApiServer).ResetCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetricsApi_ResetCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsApiServer).ResetCounter(ctx, req.(*MetricRef))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -154,6 +292,22 @@ var MetricsApi_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPing",
 			Handler:    _MetricsApi_GetPing_Handler,
 		},
+		{
+			MethodName: "DeleteMetric",
+			Handler:    _MetricsApi_DeleteMetric_Handler,
+		},
+		{
+			MethodName: "DeleteMetrics",
+			Handler:    _MetricsApi_DeleteMetrics_Handler,
+		},
+		{
+			MethodName: "RenameMetric",
+			Handler:    _MetricsApi_RenameMetric_Handler,
+		},
+		{
+			MethodName: "ResetCounter",
+			Handler:    _MetricsApi_ResetCounter_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "metricsapi.proto",
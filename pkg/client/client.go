@@ -0,0 +1,262 @@
+// Package client отправляет на сервер метрик пользовательские метрики приложения.
+//
+//	cli, err := client.New("localhost:8080", client.WithConnection(transport.WithBodyHashKey(key)))
+//	if err != nil {
+//		return err
+//	}
+//	defer cli.Close()
+//	go cli.Run(ctx)
+//
+//	requests := cli.Counter("requests")
+//	requests.Inc()
+//	cli.Gauge("queue_size").Set(float64(len(queue)))
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NStegura/metrics/internal/clients/base"
+	"github.com/NStegura/metrics/internal/clients/metric"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
+	"github.com/NStegura/metrics/pkg/transport"
+)
+
+const (
+	defaultFlushInterval = 10 * time.Second
+	flushTimeout         = 5 * time.Second
+
+	gaugeType   = "gauge"
+	counterType = "counter"
+)
+
+// pendingBatch - пакет, отправка которого не подтверждена, повторяется с тем же ключом.
+type pendingBatch struct {
+	metrics []transport.Metric
+	key     string
+}
+
+// Client накапливает метрики и отправляет их батчами.
+type Client struct {
+	sender   transport.Sender
+	counters map[string]int64
+	gauges   map[string]float64
+	// lastGauges хранит последние значения gauge, от них считается Gauge.Add после отправки.
+	lastGauges map[string]float64
+	pending    *pendingBatch
+	flushCh    chan struct{}
+	logger     *logrus.Logger
+
+	connOpts      []transport.Option
+	flushInterval time.Duration
+	maxPending    int
+
+	mu      sync.Mutex
+	flushMu sync.Mutex
+}
+
+// New создает клиент к серверу метрик addr, по умолчанию метрики отправляются по HTTP.
+// Протокол и параметры подключения задаются WithConnection.
+func New(addr string, options ...Option) (*Client, error) {
+	c := &Client{
+		counters:      make(map[string]int64),
+		gauges:        make(map[string]float64),
+		lastGauges:    make(map[string]float64),
+		flushCh:       make(chan struct{}, 1),
+		logger:        logrus.New(),
+		flushInterval: defaultFlushInterval,
+	}
+	for _, opt := range options {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	c.sender, err = transport.New(addr, c.logger, c.connOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close закрывает подключение к серверу, вызывается после остановки Run.
+func (c *Client) Close() error {
+	if err := c.sender.Close(); err != nil {
+		return fmt.Errorf("failed to close client: %w", err)
+	}
+	return nil
+}
+
+// Counter возвращает counter метрику с именем name.
+func (c *Client) Counter(name string) *Counter {
+	return &Counter{client: c, name: name}
+}
+
+// Gauge возвращает gauge метрику с именем name.
+func (c *Client) Gauge(name string) *Gauge {
+	return &Gauge{client: c, name: name}
+}
+
+// Run периодически отправляет накопленные метрики, пока не отменен ctx.
+// Перед выходом оставшиеся метрики отправляются последний раз.
+func (c *Client) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+			return c.Flush(flushCtx)
+		case <-ticker.C:
+		case <-c.flushCh:
+		}
+		if err := c.Flush(ctx); err != nil {
+			c.logger.Error(err)
+		}
+	}
+}
+
+// Flush синхронно отправляет накопленные метрики.
+// Пакет, отправку которого сервер не подтвердил из-за временной ошибки, при следующем вызове
+// отправляется повторно с тем же ключом идемпотентности: если сервер уже применил его, counter не удвоится.
+// Новые метрики копятся в буфере, пока такой пакет не отправлен. Пакет, который сервер отклонил,
+// отбрасывается с ошибкой, иначе он не дал бы отправить новые метрики.
+func (c *Client) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	if c.pending != nil {
+		if err := c.send(ctx); err != nil {
+			return err
+		}
+	}
+
+	key, err := idempotency.NewKey()
+	if err != nil {
+		return fmt.Errorf("failed to flush metrics: %w", err)
+	}
+	c.mu.Lock()
+	counters, gauges := c.counters, c.gauges
+	c.counters = make(map[string]int64, len(counters))
+	c.gauges = make(map[string]float64, len(gauges))
+	c.mu.Unlock()
+
+	if len(counters) == 0 && len(gauges) == 0 {
+		return nil
+	}
+
+	batch := make([]transport.Metric, 0, len(counters)+len(gauges))
+	for name, delta := range counters {
+		batch = append(batch, transport.Metric{ID: name, MType: counterType, Delta: &delta})
+	}
+	for name, value := range gauges {
+		batch = append(batch, transport.Metric{ID: name, MType: gaugeType, Value: &value})
+	}
+	c.pending = &pendingBatch{metrics: batch, key: key}
+	return c.send(ctx)
+}
+
+// send отправляет неподтвержденный пакет, вызывается под flushMu.
+// Пакет остается неподтвержденным только после временной ошибки.
+func (c *Client) send(ctx context.Context) error {
+	err := c.sender.UpdateMetrics(idempotency.WithKey(ctx, c.pending.key), c.pending.metrics)
+	if err != nil && retryable(err) {
+		return fmt.Errorf("failed to flush metrics: %w", err)
+	}
+	c.pending = nil
+	if err != nil {
+		return fmt.Errorf("failed to flush metrics, batch dropped: %w", err)
+	}
+	return nil
+}
+
+// retryable проверяет, что пакет может пройти при повторе: ошибка сети или отмена контекста,
+// 429 и 5xx по HTTP, временные коды gRPC. Остальные ошибки повторятся при каждой отправке.
+func retryable(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // ошибки частей пакета
+		return slices.ContainsFunc(joined.Unwrap(), retryable)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var retryErr *base.RetryAfterError
+	if errors.As(err, &retryErr) {
+		return retryErr.Delay >= 0
+	}
+	var reqErr *metric.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode == http.StatusTooManyRequests || reqErr.StatusCode >= http.StatusInternalServerError
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.Internal, codes.DeadlineExceeded, codes.Canceled,
+			codes.ResourceExhausted, codes.Aborted:
+			return true
+		default:
+			return false
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *Client) addCounter(name string, delta int64) {
+	c.mu.Lock()
+	c.counters[name] += delta
+	pending := len(c.counters) + len(c.gauges)
+	c.mu.Unlock()
+	c.notifyPending(pending)
+}
+
+func (c *Client) setGauge(name string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		c.logger.Errorf("skip gauge %s: value %v is not finite", name, value)
+		return
+	}
+	c.mu.Lock()
+	c.gauges[name] = value
+	c.lastGauges[name] = value
+	pending := len(c.counters) + len(c.gauges)
+	c.mu.Unlock()
+	c.notifyPending(pending)
+}
+
+func (c *Client) addGauge(name string, delta float64) {
+	c.mu.Lock()
+	value := c.lastGauges[name] + delta
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		c.mu.Unlock()
+		c.logger.Errorf("skip gauge %s: value %v is not finite", name, value)
+		return
+	}
+	c.lastGauges[name] = value
+	c.gauges[name] = value
+	pending := len(c.counters) + len(c.gauges)
+	c.mu.Unlock()
+	c.notifyPending(pending)
+}
+
+// notifyPending запрашивает внеочередную отправку, если накоплено maxPending метрик.
+func (c *Client) notifyPending(pending int) {
+	if c.maxPending <= 0 || pending < c.maxPending {
+		return
+	}
+	select {
+	case c.flushCh <- struct{}{}:
+	default:
+	}
+}
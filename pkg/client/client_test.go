@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NStegura/metrics/config"
+	"github.com/NStegura/metrics/internal/app/metricsapi/httpserver"
+	"github.com/NStegura/metrics/internal/business"
+	"github.com/NStegura/metrics/internal/clients/metric"
+	"github.com/NStegura/metrics/internal/repo"
+	"github.com/NStegura/metrics/internal/utils/idempotency"
+	"github.com/NStegura/metrics/pkg/transport"
+)
+
+// recordingSender запоминает ключи и пакеты, ошибка возвращается, пока задана.
+type recordingSender struct {
+	err     error
+	keys    []string
+	batches [][]transport.Metric
+	closed  bool
+}
+
+func (s *recordingSender) UpdateMetrics(ctx context.Context, batch []transport.Metric) error {
+	key, err := idempotency.ChunkKey(ctx, 0)
+	if err != nil {
+		return err
+	}
+	s.keys = append(s.keys, key)
+	s.batches = append(s.batches, batch)
+	return s.err
+}
+
+func (s *recordingSender) Close() error {
+	s.closed = true
+	return nil
+}
+
+func initServer(t *testing.T) (*httptest.Server, httpserver.Bll) {
+	t.Helper()
+	l := logrus.New()
+	r, err := repo.New(context.TODO(), "", 100, "", false, l)
+	require.NoError(t, err)
+	bll := business.New(r, l)
+	server, err := httpserver.New(config.NewSrvConfig(), bll, l)
+	require.NoError(t, err)
+	server.ConfigRouter()
+	return httptest.NewServer(server.Router), bll
+}
+
+func TestClient_Flush(t *testing.T) {
+	ts, bll := initServer(t)
+	defer ts.Close()
+
+	cli, err := New(ts.URL, WithConnection(transport.WithBodyHashKey(""), transport.WithCompressType("gzip")))
+	require.NoError(t, err)
+
+	requests := cli.Counter("requests")
+	requests.Inc()
+	requests.Add(2)
+	cli.Gauge("queue_size").Set(10)
+	cli.Gauge("queue_size").Add(1.5)
+
+	require.NoError(t, cli.Flush(context.Background()))
+	requests.Inc()
+	cli.Gauge("queue_size").Add(1)
+	require.NoError(t, cli.Flush(context.Background()))
+
+	cm, err := bll.GetCounterMetric(context.Background(), "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), cm)
+
+	gm, err := bll.GetGaugeMetric(context.Background(), "queue_size")
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, gm, "Add after flush changes the last sent value")
+}
+
+func TestClient_Flush__retrySameKey(t *testing.T) {
+	cli, err := New("localhost:8080")
+	require.NoError(t, err)
+	sender := &recordingSender{err: fmt.Errorf("failed to send: %w", context.DeadlineExceeded)}
+	cli.sender = sender
+
+	cli.Counter("requests").Add(2)
+	cli.Gauge("queue_size").Set(1)
+	require.Error(t, cli.Flush(context.Background()))
+
+	cli.Counter("requests").Inc()
+	cli.Gauge("queue_size").Add(1)
+	require.Error(t, cli.Flush(context.Background()))
+	assert.Equal(t, int64(1), cli.counters["requests"], "new metrics wait for the unconfirmed batch")
+	assert.Equal(t, 2.0, cli.gauges["queue_size"])
+
+	sender.err = nil
+	require.NoError(t, cli.Flush(context.Background()))
+	require.Len(t, sender.keys, 4)
+	assert.Equal(t, sender.keys[0], sender.keys[1], "unconfirmed batch is resent with the same key")
+	assert.Equal(t, sender.keys[0], sender.keys[2])
+	assert.Equal(t, sender.batches[0], sender.batches[2])
+	assert.NotEqual(t, sender.keys[0], sender.keys[3])
+	assert.Len(t, sender.batches[3], 2)
+	assert.Nil(t, cli.pending)
+}
+
+func TestClient_Flush__dropRejected(t *testing.T) {
+	cli, err := New("localhost:8080")
+	require.NoError(t, err)
+	sender := &recordingSender{err: &metric.RequestError{StatusCode: http.StatusBadRequest}}
+	cli.sender = sender
+
+	cli.Counter("requests").Add(2)
+	require.Error(t, cli.Flush(context.Background()))
+	assert.Nil(t, cli.pending, "rejected batch is not resent")
+
+	sender.err = nil
+	cli.Counter("errors").Inc()
+	require.NoError(t, cli.Flush(context.Background()))
+	require.Len(t, sender.batches, 2)
+	require.Len(t, sender.batches[1], 1)
+	assert.Equal(t, "errors", sender.batches[1][0].ID)
+	assert.NotEqual(t, sender.keys[0], sender.keys[1])
+
+	require.NoError(t, cli.Close())
+	assert.True(t, sender.closed)
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err       error
+		name      string
+		retryable bool
+	}{
+		{name: "ctx", err: context.Canceled, retryable: true},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, retryable: true},
+		{name: "5xx", err: &metric.RequestError{StatusCode: http.StatusBadGateway}, retryable: true},
+		{name: "4xx", err: &metric.RequestError{StatusCode: http.StatusForbidden}},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "down"), retryable: true},
+		{name: "grpc invalid", err: fmt.Errorf("send: %w", status.Error(codes.InvalidArgument, "bad"))},
+		{name: "encode", err: fmt.Errorf("failed to decode metrics, err %w", &json.UnsupportedValueError{})},
+		{
+			name:      "one chunk retryable",
+			err:       errors.Join(&metric.RequestError{StatusCode: http.StatusBadRequest}, context.DeadlineExceeded),
+			retryable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, retryable(tt.err))
+		})
+	}
+}
+
+func TestGauge__notFinite(t *testing.T) {
+	cli, err := New("localhost:8080")
+	require.NoError(t, err)
+
+	cli.Gauge("nan").Set(math.NaN())
+	cli.Gauge("inf").Set(math.Inf(-1))
+	assert.Empty(t, cli.gauges)
+
+	cli.Gauge("load").Set(math.MaxFloat64)
+	cli.Gauge("load").Add(math.MaxFloat64)
+	assert.Equal(t, math.MaxFloat64, cli.gauges["load"])
+}
+
+func TestClient_Run(t *testing.T) {
+	ts, bll := initServer(t)
+	defer ts.Close()
+
+	cli, err := New(ts.URL, WithFlushInterval(time.Hour), WithMaxPending(1))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- cli.Run(ctx) }()
+
+	cli.Counter("runs").Inc()
+	assert.Eventually(t, func() bool {
+		v, err := bll.GetCounterMetric(context.Background(), "runs")
+		return err == nil && v == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cli.Counter("runs").Inc()
+	cancel()
+	require.NoError(t, <-done)
+
+	v, err := bll.GetCounterMetric(context.Background(), "runs")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), v)
+}
@@ -0,0 +1,34 @@
+package client
+
+// Counter метрика, значение которой накапливается на сервере.
+type Counter struct {
+	client *Client
+	name   string
+}
+
+// Inc увеличивает counter на единицу.
+func (c *Counter) Inc() {
+	c.client.addCounter(c.name, 1)
+}
+
+// Add увеличивает counter на delta.
+func (c *Counter) Add(delta int64) {
+	c.client.addCounter(c.name, delta)
+}
+
+// Gauge метрика, на сервере хранится последнее отправленное значение.
+type Gauge struct {
+	client *Client
+	name   string
+}
+
+// Set устанавливает значение gauge, NaN и бесконечность пропускаются с ошибкой в логе.
+func (g *Gauge) Set(value float64) {
+	g.client.setGauge(g.name, value)
+}
+
+// Add изменяет на delta последнее значение gauge, заданное через этот клиент.
+// Если значение перестает быть конечным числом, изменение пропускается с ошибкой в логе.
+func (g *Gauge) Add(delta float64) {
+	g.client.addGauge(g.name, delta)
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/NStegura/metrics/pkg/transport"
+)
+
+// Option настраивает клиент.
+type Option func(*Client) error
+
+// WithConnection Опция для настройки подключения к серверу: протокола, TLS, подписи, сжатия и повторов.
+func WithConnection(options ...transport.Option) Option {
+	return func(c *Client) error {
+		c.connOpts = append(c.connOpts, options...)
+		return nil
+	}
+}
+
+// WithLogger Опция для настройки логгера.
+func WithLogger(logger *logrus.Logger) Option {
+	return func(c *Client) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithFlushInterval Опция для настройки частоты отправки накопленных метрик.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(c *Client) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid flush interval: %v", interval)
+		}
+		c.flushInterval = interval
+		return nil
+	}
+}
+
+// WithMaxPending Опция для внеочередной отправки, когда накоплено maxPending метрик.
+func WithMaxPending(maxPending int) Option {
+	return func(c *Client) error {
+		c.maxPending = maxPending
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/NStegura/metrics/internal/clients/base"
+)
+
+// Option настраивает подключение к серверу метрик.
+type Option func(*Options) error
+
+// WithTransport Опция для выбора протокола отправки метрик.
+func WithTransport(transport Transport) Option {
+	return func(o *Options) error {
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithBodyHashKey Опция для настройки ключа подписи тела запроса.
+func WithBodyHashKey(key string) Option {
+	return func(o *Options) error {
+		o.clientOpts = append(o.clientOpts, base.WithBodyHashKey(key))
+		return nil
+	}
+}
+
+// WithSigningKey Опция для подписи запросов собственным ключом Ed25519 или ECDSA.
+// Пустой keyID заменяется отпечатком публичного ключа.
+func WithSigningKey(path, keyID string) Option {
+	return func(o *Options) error {
+		o.clientOpts = append(o.clientOpts, base.WithSigningKey(path, keyID))
+		return nil
+	}
+}
+
+// WithCryptoKey Опция для настройки пути к публичному ключу шифрования.
+func WithCryptoKey(path string) Option {
+	return func(o *Options) error {
+		o.clientOpts = append(o.clientOpts, base.WithCryptoKey(path))
+		return nil
+	}
+}
+
+// WithTLSConfig Опция для подключения к серверу по TLS.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *Options) error {
+		o.clientOpts = append(o.clientOpts, base.WithTLSConfig(tlsConfig))
+		return nil
+	}
+}
+
+// WithCompressType Опция для настройки типа сжатия.
+func WithCompressType(compressType string) Option {
+	return func(o *Options) error {
+		o.clientOpts = append(o.clientOpts, base.WithCompressType(compressType))
+		return nil
+	}
+}
+
+// WithMaxBatch Опция для ограничения размера одного запроса в байтах и метриках.
+func WithMaxBatch(maxBytes, maxMetrics int) Option {
+	return func(o *Options) error {
+		o.clientOpts = append(o.clientOpts,
+			base.WithMaxBatchBytes(maxBytes),
+			base.WithMaxBatchMetrics(maxMetrics),
+		)
+		return nil
+	}
+}
+
+// WithRetryPolicy Опция для настройки задержек между повторными отправками.
+func WithRetryPolicy(backoffs ...time.Duration) Option {
+	return func(o *Options) error {
+		o.retryPolicy = backoffs
+		return nil
+	}
+}
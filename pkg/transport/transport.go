@@ -0,0 +1,106 @@
+// Package transport содержит общие для pkg/agent и pkg/client настройки подключения к серверу метрик.
+//
+//	cli, err := client.New("localhost:8080", client.WithConnection(
+//		transport.WithTransport(transport.GRPC),
+//		transport.WithTLSConfig(tlsConfig),
+//		transport.WithRetryPolicy(time.Second, 3*time.Second),
+//	))
+package transport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/NStegura/metrics/internal/clients/base"
+	"github.com/NStegura/metrics/internal/clients/metric"
+)
+
+// Transport протокол отправки метрик на сервер.
+type Transport string
+
+const (
+	HTTP Transport = "http"
+	GRPC Transport = "grpc"
+)
+
+// Metric метрика для отправки на сервер: у counter задан Delta, у gauge - Value.
+type Metric struct {
+	Delta *int64   `json:"delta,omitempty"`
+	Value *float64 `json:"value,omitempty"`
+	ID    string   `json:"id"`
+	MType string   `json:"type"`
+}
+
+// Sender отправляет набор метрик на сервер, Close освобождает подключение.
+type Sender interface {
+	UpdateMetrics(context.Context, []Metric) error
+	Close() error
+}
+
+// metricClient клиент из internal/clients/metric.
+type metricClient interface {
+	UpdateMetrics(context.Context, []metric.Metrics) error
+	Close() error
+}
+
+// sender переводит метрики в формат клиента.
+type sender struct {
+	client metricClient
+}
+
+func (s *sender) UpdateMetrics(ctx context.Context, metrics []Metric) error {
+	batch := make([]metric.Metrics, 0, len(metrics))
+	for _, m := range metrics {
+		batch = append(batch, metric.Metrics(m))
+	}
+	return s.client.UpdateMetrics(ctx, batch) //nolint:wrapcheck // proxy
+}
+
+func (s *sender) Close() error {
+	return s.client.Close() //nolint:wrapcheck // proxy
+}
+
+// Options настройки подключения к серверу метрик.
+type Options struct {
+	transport   Transport
+	clientOpts  []base.Option
+	retryPolicy []time.Duration
+}
+
+// New создает клиент к серверу метрик addr, по умолчанию метрики отправляются по HTTP.
+func New(addr string, logger *logrus.Logger, options ...Option) (Sender, error) {
+	o := &Options{transport: HTTP}
+	for _, opt := range options {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	clientOpts := append([]base.Option{base.WithLogger(logger)}, o.clientOpts...)
+
+	var (
+		client metricClient
+		err    error
+	)
+	switch o.transport {
+	case HTTP:
+		if o.retryPolicy != nil {
+			clientOpts = append(clientOpts, base.WithRetryPolicy(o.retryPolicy, base.IsRetryableHTTPRequest))
+		}
+		client, err = metric.NewHTTPClient(addr, clientOpts...)
+	case GRPC:
+		if o.retryPolicy != nil {
+			clientOpts = append(clientOpts, base.WithRetryPolicy(o.retryPolicy, base.IsRetryableGRPCRequest))
+		}
+		client, err = metric.NewGRPCClient(addr, clientOpts...)
+	default:
+		return nil, fmt.Errorf("unknown transport: %s", o.transport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to init metric client: %w", err)
+	}
+	return &sender{client: client}, nil
+}
@@ -0,0 +1,31 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NStegura/metrics/internal/clients/metric"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New("localhost:8080", logrus.New())
+	require.NoError(t, err)
+	require.IsType(t, &sender{}, s)
+	assert.IsType(t, &metric.Client{}, s.(*sender).client)
+	assert.NoError(t, s.Close())
+
+	s, err = New("localhost:8080", logrus.New(), WithTransport(GRPC), WithCompressType("gzip"))
+	require.NoError(t, err)
+	require.IsType(t, &sender{}, s)
+	assert.IsType(t, &metric.GRPCClient{}, s.(*sender).client)
+	assert.NoError(t, s.Close())
+	assert.Error(t, s.UpdateMetrics(context.Background(), []Metric{{ID: "Alloc", MType: "gauge"}}),
+		"closed connection does not send")
+
+	_, err = New("localhost:8080", logrus.New(), WithTransport("udp"))
+	assert.Error(t, err)
+}